@@ -1,21 +1,48 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	"github.com/regiwitanto/go-scaffold/internal/application/service"
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/apikey"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/dependency"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/preset"
 	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/scaffold"
 	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/template"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/webhook"
 	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/handler"
 	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/routes"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/cli"
+	"github.com/regiwitanto/go-scaffold/templates"
 )
 
 func main() {
+	// Subcommands run without starting the server
+	if len(os.Args) > 1 && os.Args[1] == "lint-templates" {
+		linter := service.NewGeneratorService(nil, nil, os.TempDir())
+		os.Exit(cli.LintTemplates(os.Args[2:], linter, os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		verifier := service.NewGeneratorService(nil, nil, os.TempDir())
+		os.Exit(cli.Verify(os.Args[2:], verifier, os.Stdout, os.Stderr))
+	}
+
 	// Load .env file if it exists
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found or error loading it. Using environment variables.")
@@ -25,6 +52,12 @@ func main() {
 	e := echo.New()
 	e.HideBanner = true
 
+	// Clients are rate limited by IP address, so only trust forwarding headers behind a proxy
+	e.IPExtractor = echo.ExtractIPDirect()
+	if os.Getenv("BEHIND_PROXY") == "true" {
+		e.IPExtractor = echo.ExtractIPFromXFFHeader()
+	}
+
 	// We don't need assets directories for a pure backend service
 
 	// Set up temp directory for scaffold generation
@@ -36,39 +69,196 @@ func main() {
 		log.Fatalf("Failed to create temp directory: %v", err)
 	}
 
-	// Create the templates directory structure if it doesn't exist
+	// Built-in templates are embedded; TEMPLATE_DIR optionally overrides them from disk
 	templatesDir := os.Getenv("TEMPLATE_DIR")
-	if templatesDir == "" {
-		templatesDir = "templates"
-	}
-	templateDirs := []string{
-		filepath.Join(templatesDir, "api", "chi"),
-		filepath.Join(templatesDir, "api", "echo"),
-		filepath.Join(templatesDir, "api", "gin"),
-		filepath.Join(templatesDir, "api", "standard"),
-	}
 
-	for _, dir := range templateDirs {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			log.Fatalf("Failed to create template directory %s: %v", dir, err)
+	// Templates can be uploaded through the admin API when an admin token is configured
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	var uploadRepo *template.ManagedRepository
+	if adminToken != "" {
+		uploadDir := os.Getenv("TEMPLATE_UPLOAD_DIR")
+		if uploadDir == "" {
+			uploadDir = filepath.Join("data", "templates")
+		}
+		var err error
+		if uploadRepo, err = template.NewManagedRepository(uploadDir); err != nil {
+			log.Fatalf("Failed to initialize template upload repository: %v", err)
 		}
 	}
 
 	// Initialize repositories
-	templateRepo, err := template.NewFilesystemRepository("templates")
+	templateRepo, templateRoots, err := newTemplateRepository(templatesDir, uploadRepo)
 	if err != nil {
 		log.Fatalf("Failed to initialize template repository: %v", err)
 	}
 	scaffoldRepo := scaffold.NewInMemoryRepository()
+	dependencyRepo, err := newDependencyRepository(templatesDir)
+	if err != nil {
+		log.Fatalf("Failed to load dependency catalog: %v", err)
+	}
+	apiKeyRepo, err := newAPIKeyRepository(os.Getenv("API_KEYS_FILE"))
+	if err != nil {
+		log.Fatalf("Failed to load API keys: %v", err)
+	}
+	presetRepo, err := newPresetRepository(templatesDir, os.Getenv("PRESETS_FILE"))
+	if err != nil {
+		log.Fatalf("Failed to load presets: %v", err)
+	}
+	webhookPath := os.Getenv("WEBHOOKS_FILE")
+	if webhookPath == "" {
+		webhookPath = filepath.Join("data", "webhooks.json")
+	}
+	webhookRepo, err := webhook.NewFileRepository(webhookPath)
+	if err != nil {
+		log.Fatalf("Failed to load webhooks: %v", err)
+	}
+	limits, err := limitsFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid limits: %v", err)
+	}
+	// Webhooks only target public addresses unless private ones are allowed, e.g. in development
+	var webhookOptions []service.WebhookOption
+	if os.Getenv("WEBHOOK_ALLOW_PRIVATE") == "true" {
+		webhookOptions = append(webhookOptions, service.WithPrivateWebhookTargets())
+		log.Println("Warning: webhooks may target loopback, private and link-local addresses")
+	}
+	webhookService := service.NewWebhookService(webhookRepo, webhookOptions...)
+	generatorOptions := []service.GeneratorOption{
+		service.WithDependencyRepository(dependencyRepo),
+		service.WithPresetRepository(presetRepo),
+		service.WithEventPublisher(webhookService),
+	}
+
+	// Scaffolds expire after a retention period when one is configured
+	var retention time.Duration
+	if raw := os.Getenv("SCAFFOLD_RETENTION"); raw != "" {
+		if retention, err = time.ParseDuration(raw); err != nil || retention < 0 {
+			log.Fatalf("SCAFFOLD_RETENTION must be a non-negative duration, got %q", raw)
+		}
+		generatorOptions = append(generatorOptions, service.WithRetention(retention))
+	}
+
+	// Archives are signed when a signing key is configured
+	if keyFile := os.Getenv("SIGNING_KEY_FILE"); keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			log.Fatalf("Failed to read signing key: %v", err)
+		}
+		signingKey, err := service.ParsePrivateKey(data)
+		if err != nil {
+			log.Fatalf("Invalid signing key: %v", err)
+		}
+		generatorOptions = append(generatorOptions, service.WithSigningKey(signingKey))
+	} else {
+		log.Println("No SIGNING_KEY_FILE set, archives are not signed")
+	}
 
 	// Initialize services
-	generatorService := service.NewGeneratorService(templateRepo, scaffoldRepo, tempDir)
+	generatorService := service.NewGeneratorService(templateRepo, scaffoldRepo, tempDir, generatorOptions...)
+	presetService := service.NewPresetService(presetRepo, generatorService)
+
+	// Compile template trees up front so broken templates are reported at startup
+	if err := generatorService.CompileTemplates(); err != nil {
+		log.Printf("Warning: failed to precompile templates: %v", err)
+	}
+
+	// In development, recompile templates as they change on disk
+	if os.Getenv("TEMPLATE_HOT_RELOAD") == "true" {
+		reload := func(paths []string) {
+			log.Printf("Templates changed (%d files), recompiling", len(paths))
+			if err := generatorService.CompileTemplates(); err != nil {
+				log.Printf("Template reload failed, serving last valid templates:\n%v", err)
+				return
+			}
+			log.Println("Templates reloaded")
+		}
+		for _, root := range templateRoots {
+			watcher, err := template.NewWatcher(root, template.DefaultWatchDebounce, reload)
+			if err != nil {
+				log.Fatalf("Failed to watch templates: %v", err)
+			}
+			defer watcher.Close()
+		}
+		if len(templateRoots) == 0 {
+			log.Println("Warning: template hot reload only watches directories on disk; set TEMPLATE_DIR to edit the built-in templates")
+		} else {
+			log.Println("Template hot reload enabled")
+		}
+	}
+
+	// Fetch the git template repository regularly, so branches are tracked
+	if os.Getenv("TEMPLATE_GIT_URL") != "" {
+		interval := 5 * time.Minute
+		if raw := os.Getenv("TEMPLATE_GIT_REFRESH"); raw != "" {
+			var err error
+			if interval, err = time.ParseDuration(raw); err != nil || interval < 0 {
+				log.Fatalf("TEMPLATE_GIT_REFRESH must be a non-negative duration, got %q", raw)
+			}
+		}
+		if interval > 0 {
+			go func() {
+				for range time.Tick(interval) {
+					if err := templateRepo.Refresh(); err != nil {
+						log.Printf("Failed to refresh templates: %v", err)
+						continue
+					}
+					if err := generatorService.CompileTemplates(); err != nil {
+						log.Printf("Warning: failed to compile refreshed templates:\n%v", err)
+					}
+				}
+			}()
+		}
+	}
+
+	usageService := service.NewUsageService(limits)
+
+	handlerOptions := []handler.GeneratorHandlerOption{handler.WithUsageService(usageService)}
+
+	// Downloads require a signed, expiring link when a link secret is configured
+	if secret := os.Getenv("DOWNLOAD_LINK_SECRET"); secret != "" {
+		linkOptions := []service.DownloadLinkOption{}
+		if raw := os.Getenv("DOWNLOAD_LINK_TTL"); raw != "" {
+			ttl, err := time.ParseDuration(raw)
+			if err != nil || ttl < time.Second || ttl > service.MaxLinkTTL {
+				log.Fatalf("DOWNLOAD_LINK_TTL must be a duration between 1s and %s, got %q", service.MaxLinkTTL, raw)
+			}
+			linkOptions = append(linkOptions, service.WithLinkTTL(ttl))
+		}
+		handlerOptions = append(handlerOptions, handler.WithDownloadLinks(service.NewDownloadLinkService([]byte(secret), linkOptions...)))
+	} else {
+		log.Println("No DOWNLOAD_LINK_SECRET set, downloads do not require a signed link")
+	}
+
+	// Remove expired scaffolds and free the storage quota of their owners
+	if retention > 0 {
+		go func() {
+			for now := range time.Tick(time.Minute) {
+				expired, err := generatorService.ExpireScaffolds(now)
+				if err != nil {
+					log.Printf("Failed to expire scaffolds: %v", err)
+				}
+				for _, scaffold := range expired {
+					usageService.ReleaseStorage(scaffold.Owner, scaffold)
+				}
+				if len(expired) > 0 {
+					log.Printf("Expired %d scaffolds", len(expired))
+				}
+			}
+		}()
+	}
 
 	// Initialize handlers
-	generatorHandler := handler.NewGeneratorHandler(generatorService)
+	generatorHandler := handler.NewGeneratorHandler(generatorService, handlerOptions...)
+	presetHandler := handler.NewPresetHandler(presetService, generatorService)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
 
 	// Setup routes
-	routes.SetupRoutes(e, generatorHandler)
+	routes.SetupRoutes(e, generatorHandler, presetHandler, webhookHandler, apiKeyRepo, usageService)
+	if uploadRepo != nil {
+		adminService := service.NewTemplateAdminService(uploadRepo, generatorService, tempDir)
+		routes.SetupAdminRoutes(e, handler.NewTemplateAdminHandler(adminService), adminToken)
+		log.Println("Template admin API enabled")
+	}
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -81,5 +271,166 @@ func main() {
 	log.Printf("Starting server on %s", serverURL)
 	log.Printf("API Documentation at %s/api/docs", serverURL)
 
-	e.Logger.Fatal(e.Start(":" + port))
+	go func() {
+		if err := e.Start(":" + port); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			e.Logger.Fatal(err)
+		}
+	}()
+
+	// Stop serving on interrupt, then give up webhook deliveries still pending
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	log.Println("Shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to shut down server: %v", err)
+	}
+	webhookService.Close()
+}
+
+// newTemplateRepository combines the template sources configured in the
+// environment and returns it with the directories it reads from. Sources are
+// searched in priority order: templates uploaded through the admin API when
+// uploads is set, then team template packs in TEMPLATE_PACKS_DIR, then
+// the git repository in TEMPLATE_GIT_URL, then the directories listed in
+// TEMPLATE_EXTRA_DIRS, then templatesDir when set, then the built-in templates
+// embedded in the binary.
+func newTemplateRepository(templatesDir string, uploads *template.ManagedRepository) (*template.CompositeRepository, []string, error) {
+	var sources []template.Source
+	var roots []string
+
+	if uploads != nil {
+		sources = append(sources, template.Source{Origin: template.OriginUpload, Repository: uploads})
+	}
+
+	if packsDir := os.Getenv("TEMPLATE_PACKS_DIR"); packsDir != "" {
+		packs, err := template.PackSources(packsDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		sources = append(sources, packs...)
+		roots = append(roots, packsDir)
+	}
+
+	if gitURL := os.Getenv("TEMPLATE_GIT_URL"); gitURL != "" {
+		ref := os.Getenv("TEMPLATE_GIT_REF")
+		if ref == "" {
+			ref = "main"
+		}
+		cacheDir := os.Getenv("TEMPLATE_GIT_CACHE")
+		if cacheDir == "" {
+			cacheDir = filepath.Join(os.TempDir(), "go-scaffold", "git")
+		}
+
+		source, err := template.GitSource(gitURL, ref, cacheDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		sources = append(sources, source)
+	}
+
+	extraDirs := filepath.SplitList(os.Getenv("TEMPLATE_EXTRA_DIRS"))
+	for _, dir := range append(extraDirs, templatesDir) {
+		if dir == "" {
+			continue
+		}
+		source, err := template.DirectorySource(dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		sources = append(sources, source)
+		roots = append(roots, dir)
+	}
+
+	sources = append(sources, template.Source{
+		Origin:     template.OriginBuiltin,
+		Repository: template.NewFSRepository(templates.FS),
+	})
+
+	repo := template.NewCompositeRepository(sources...)
+
+	duplicates, err := repo.Duplicates()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, duplicate := range duplicates {
+		log.Printf("Warning: template %s from %s shadows %s", duplicate.ID, duplicate.Origin, strings.Join(duplicate.Shadowed, ", "))
+	}
+
+	return repo, roots, nil
+}
+
+// newDependencyRepository loads dependencies.json from templatesDir when it has
+// one, and the catalog embedded in the binary otherwise
+func newDependencyRepository(templatesDir string) (repository.DependencyRepository, error) {
+	if templatesDir != "" {
+		path := filepath.Join(templatesDir, "dependencies.json")
+		if _, err := os.Stat(path); err == nil {
+			return dependency.NewCatalogRepository(path)
+		}
+	}
+
+	return dependency.NewCatalogRepositoryFS(templates.FS, "dependencies.json")
+}
+
+// newPresetRepository stores presets in the file at path, data/presets.json by
+// default, next to the built-in presets from presets.json in templatesDir when
+// it has one, and those embedded in the binary otherwise
+func newPresetRepository(templatesDir, path string) (repository.PresetRepository, error) {
+	var fsys fs.FS = templates.FS
+	if templatesDir != "" {
+		if _, err := os.Stat(filepath.Join(templatesDir, "presets.json")); err == nil {
+			fsys = os.DirFS(templatesDir)
+		}
+	}
+	builtIn, err := preset.LoadBuiltInFS(fsys, "presets.json")
+	if err != nil {
+		return nil, err
+	}
+
+	if path == "" {
+		path = filepath.Join("data", "presets.json")
+	}
+	return preset.NewFileRepository(path, builtIn)
+}
+
+// newAPIKeyRepository loads the API keys file at path; without one, no API keys
+// are accepted and premium features cannot be requested
+func newAPIKeyRepository(path string) (repository.APIKeyRepository, error) {
+	if path == "" {
+		return apikey.NewEmptyRepository(), nil
+	}
+
+	return apikey.NewFileRepository(path)
+}
+
+// limitsFromEnv returns the rate limit and quotas of clients without a plan
+// setting their own; 0 disables a limit
+func limitsFromEnv() (model.Limits, error) {
+	values := make(map[string]int64)
+	for name, fallback := range map[string]int64{
+		"RATE_LIMIT_PER_MINUTE":  60,
+		"RATE_LIMIT_BURST":       20,
+		"DAILY_GENERATION_QUOTA": 100,
+		"STORAGE_QUOTA_MB":       512,
+	} {
+		values[name] = fallback
+		if raw := os.Getenv(name); raw != "" {
+			value, err := strconv.ParseInt(raw, 10, 32)
+			if err != nil || value < 0 {
+				return model.Limits{}, fmt.Errorf("%s must be a non-negative integer, got %q", name, raw)
+			}
+			values[name] = value
+		}
+	}
+
+	return model.Limits{
+		RequestsPerMinute: int(values["RATE_LIMIT_PER_MINUTE"]),
+		Burst:             int(values["RATE_LIMIT_BURST"]),
+		DailyGenerations:  int(values["DAILY_GENERATION_QUOTA"]),
+		StorageBytes:      values["STORAGE_QUOTA_MB"] << 20,
+	}, nil
 }
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	domainservice "github.com/regiwitanto/go-scaffold/internal/domain/service"
+)
+
+const (
+	// DefaultLinkTTL is how long download links work unless configured otherwise
+	DefaultLinkTTL = time.Hour
+	// MaxLinkTTL is the longest lifetime a download link can be issued with
+	MaxLinkTTL = 7 * 24 * time.Hour
+)
+
+// DownloadLinkServiceImpl implements the DownloadLinkService interface with
+// HMAC-SHA256 signatures. Used one-time links are remembered in memory until
+// they expire, so they are tracked per server instance.
+type DownloadLinkServiceImpl struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+	used   map[string]time.Time // Expiry of used one-time links by nonce
+	mutex  sync.Mutex
+}
+
+// DownloadLinkOption configures the download link service
+type DownloadLinkOption func(*DownloadLinkServiceImpl)
+
+// WithLinkTTL sets how long links work when no lifetime is requested
+func WithLinkTTL(ttl time.Duration) DownloadLinkOption {
+	return func(s *DownloadLinkServiceImpl) {
+		s.ttl = ttl
+	}
+}
+
+// WithLinkClock sets the clock links expire by, instead of time.Now
+func WithLinkClock(now func() time.Time) DownloadLinkOption {
+	return func(s *DownloadLinkServiceImpl) {
+		s.now = now
+	}
+}
+
+// NewDownloadLinkService creates a new download link service signing links with secret
+func NewDownloadLinkService(secret []byte, opts ...DownloadLinkOption) *DownloadLinkServiceImpl {
+	s := &DownloadLinkServiceImpl{
+		secret: secret,
+		ttl:    DefaultLinkTTL,
+		now:    time.Now,
+		used:   make(map[string]time.Time),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// IssueLink signs a link to the archive of a scaffold that expires after ttl,
+// or the default lifetime when ttl is zero
+func (s *DownloadLinkServiceImpl) IssueLink(scaffoldID string, ttl time.Duration, oneTime bool) (*model.DownloadLink, error) {
+	if ttl == 0 {
+		ttl = s.ttl
+	}
+	if ttl < time.Second || ttl > MaxLinkTTL {
+		return nil, &domainservice.ValidationError{
+			Message: "invalid download link",
+			Problems: []domainservice.Problem{{
+				Field:   "expiresIn",
+				Code:    domainservice.ProblemInvalidValue,
+				Message: fmt.Sprintf("expiresIn must be between 1 and %d seconds", int(MaxLinkTTL.Seconds())),
+			}},
+		}
+	}
+
+	link := &model.DownloadLink{
+		ScaffoldID: scaffoldID,
+		ExpiresAt:  s.now().Add(ttl).Truncate(time.Second),
+	}
+	if oneTime {
+		nonce := make([]byte, 16)
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, fmt.Errorf("failed to generate link nonce: %w", err)
+		}
+		link.Nonce = hex.EncodeToString(nonce)
+	}
+	link.Signature = s.sign(link)
+
+	return link, nil
+}
+
+// CheckLink checks a link presented for a download without using it up
+func (s *DownloadLinkServiceImpl) CheckLink(link *model.DownloadLink) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.check(link)
+}
+
+// RedeemLink checks a link presented for a download and uses up one-time links
+func (s *DownloadLinkServiceImpl) RedeemLink(link *model.DownloadLink) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.check(link); err != nil {
+		return err
+	}
+	if link.OneTime() {
+		s.used[link.Nonce] = link.ExpiresAt
+	}
+	return nil
+}
+
+// check returns why a link cannot be used, if it cannot. The caller must hold the mutex.
+func (s *DownloadLinkServiceImpl) check(link *model.DownloadLink) error {
+	if !hmac.Equal([]byte(link.Signature), []byte(s.sign(link))) {
+		return domainservice.ErrLinkInvalid
+	}
+
+	now := s.now()
+	if !now.Before(link.ExpiresAt) {
+		return domainservice.ErrLinkExpired
+	}
+	if !link.OneTime() {
+		return nil
+	}
+
+	// Forget used links once they have expired anyway
+	for nonce, expiresAt := range s.used {
+		if !now.Before(expiresAt) {
+			delete(s.used, nonce)
+		}
+	}
+	if _, ok := s.used[link.Nonce]; ok {
+		return domainservice.ErrLinkUsed
+	}
+	return nil
+}
+
+// sign returns the signature of a link over its scaffold ID, expiry and nonce
+func (s *DownloadLinkServiceImpl) sign(link *model.DownloadLink) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(link.ScaffoldID + "\n" + strconv.FormatInt(link.ExpiresAt.Unix(), 10) + "\n" + link.Nonce))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
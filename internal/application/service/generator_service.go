@@ -2,18 +2,27 @@ package service
 
 import (
 	"archive/zip"
+	"crypto/ed25519"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"math/big"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"text/template"
 	"time"
 
 	"github.com/regiwitanto/go-scaffold/internal/domain/model"
 	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	domainservice "github.com/regiwitanto/go-scaffold/internal/domain/service"
+	"golang.org/x/mod/semver"
 )
 
 // GeneratorServiceImpl implements the GeneratorService interface
@@ -21,6 +30,53 @@ type GeneratorServiceImpl struct {
 	templateRepo repository.TemplateRepository
 	scaffoldRepo repository.ScaffoldRepository
 	tempDir      string
+	templates    *templateCache
+	archives     sync.Mutex // Serializes sharing archives with removing them
+
+	// Optional collaborators configured through GeneratorOption
+	dependencyRepo repository.DependencyRepository
+	presetRepo     repository.PresetRepository
+	signingKey     ed25519.PrivateKey
+	retention      time.Duration
+	publisher      domainservice.EventPublisher
+}
+
+// GeneratorOption configures optional collaborators of the generator service
+type GeneratorOption func(*GeneratorServiceImpl)
+
+// WithDependencyRepository sets the dependency catalog used by the "dep" template function
+func WithDependencyRepository(dependencyRepo repository.DependencyRepository) GeneratorOption {
+	return func(s *GeneratorServiceImpl) {
+		s.dependencyRepo = dependencyRepo
+	}
+}
+
+// WithPresetRepository sets the presets generation requests can start from
+func WithPresetRepository(presetRepo repository.PresetRepository) GeneratorOption {
+	return func(s *GeneratorServiceImpl) {
+		s.presetRepo = presetRepo
+	}
+}
+
+// WithSigningKey signs every archive with an ed25519 private key
+func WithSigningKey(key ed25519.PrivateKey) GeneratorOption {
+	return func(s *GeneratorServiceImpl) {
+		s.signingKey = key
+	}
+}
+
+// WithRetention expires scaffolds a duration after they are generated
+func WithRetention(retention time.Duration) GeneratorOption {
+	return func(s *GeneratorServiceImpl) {
+		s.retention = retention
+	}
+}
+
+// WithEventPublisher publishes scaffold events to the webhooks of their owners
+func WithEventPublisher(publisher domainservice.EventPublisher) GeneratorOption {
+	return func(s *GeneratorServiceImpl) {
+		s.publisher = publisher
+	}
 }
 
 // NewGeneratorService creates a new generator service
@@ -28,16 +84,61 @@ func NewGeneratorService(
 	templateRepo repository.TemplateRepository,
 	scaffoldRepo repository.ScaffoldRepository,
 	tempDir string,
+	opts ...GeneratorOption,
 ) *GeneratorServiceImpl {
-	return &GeneratorServiceImpl{
+	s := &GeneratorServiceImpl{
 		templateRepo: templateRepo,
 		scaffoldRepo: scaffoldRepo,
 		tempDir:      tempDir,
+		templates:    newTemplateCache(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
 // GenerateScaffold generates a scaffold based on the provided options
 func (s *GeneratorServiceImpl) GenerateScaffold(options model.ScaffoldOptions) (*model.GeneratedScaffold, error) {
+	scaffold, err := s.generateScaffold(options)
+	if err != nil {
+		s.publish(options.Owner, model.EventScaffoldFailed, model.ScaffoldFailure{Options: options, Error: err.Error()})
+		return nil, err
+	}
+
+	s.publish(scaffold.Owner, model.EventScaffoldGenerated, scaffold)
+	return scaffold, nil
+}
+
+// ResolveOptions returns options with the preset they request applied and
+// defaults filled in. Lists are never nil, so applying the preset again to the
+// resolved options leaves them unchanged even if the preset was edited meanwhile.
+func (s *GeneratorServiceImpl) ResolveOptions(options model.ScaffoldOptions) (model.ScaffoldOptions, error) {
+	// Start from the requested preset, then fill in what neither set
+	options, err := s.applyPreset(options)
+	if err != nil {
+		return options, err
+	}
+	options = withDefaults(options)
+
+	if options.Features == nil {
+		options.Features = []string{}
+	}
+	if options.PremiumFeatures == nil {
+		options.PremiumFeatures = []string{}
+	}
+	return options, nil
+}
+
+// generateScaffold generates or shares the scaffold of the provided options
+func (s *GeneratorServiceImpl) generateScaffold(options model.ScaffoldOptions) (*model.GeneratedScaffold, error) {
+	options, err := s.ResolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
 	// Validate options
 	if err := s.validateOptions(options); err != nil {
 		return nil, err
@@ -49,6 +150,19 @@ func (s *GeneratorServiceImpl) GenerateScaffold(options model.ScaffoldOptions) (
 		return nil, err
 	}
 
+	// Identical options render identical files, so share the archive of an earlier scaffold
+	compiled, err := s.templates.get(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	hash := optionsHash(options, tmpl, compiled.digest)
+	if !options.Fresh {
+		scaffold, err := s.shareScaffold(options, hash)
+		if err != nil || scaffold != nil {
+			return scaffold, err
+		}
+	}
+
 	// Create a temporary directory for the scaffold
 	scaffoldID := generateID()
 	scaffoldDir := filepath.Join(s.tempDir, scaffoldID)
@@ -65,9 +179,11 @@ func (s *GeneratorServiceImpl) GenerateScaffold(options model.ScaffoldOptions) (
 
 	// Create a ZIP file
 	zipPath := filepath.Join(s.tempDir, scaffoldID+".zip")
-	if err := s.createZipArchive(scaffoldDir, zipPath); err != nil {
+	checksum, err := s.createZipArchive(scaffoldDir, zipPath)
+	if err != nil {
 		// Clean up on error
 		os.RemoveAll(scaffoldDir)
+		os.Remove(zipPath)
 		return nil, err
 	}
 
@@ -81,12 +197,31 @@ func (s *GeneratorServiceImpl) GenerateScaffold(options model.ScaffoldOptions) (
 	}
 
 	// Create and save the scaffold record
+	now := time.Now()
 	scaffold := &model.GeneratedScaffold{
 		ID:        scaffoldID,
 		Options:   options,
-		CreatedAt: time.Now().Format(time.RFC3339),
+		CreatedAt: now.Format(time.RFC3339),
 		FilePath:  zipPath,
 		Size:      fileInfo.Size(),
+		SHA256:    checksum,
+		Owner:     options.Owner,
+
+		ArchiveName: projectName(options.ModulePath) + ".zip",
+		ExpiresAt:   s.expiresAt(now),
+
+		OptionsHash: hash,
+
+		TemplateID:       tmpl.ID,
+		TemplateVersion:  tmpl.Version,
+		TemplateRevision: tmpl.Revision,
+	}
+
+	if err := s.signArchive(scaffold); err != nil {
+		// Clean up on error
+		os.RemoveAll(scaffoldDir)
+		os.Remove(zipPath)
+		return nil, err
 	}
 
 	if err := s.scaffoldRepo.Save(scaffold); err != nil {
@@ -102,11 +237,141 @@ func (s *GeneratorServiceImpl) GenerateScaffold(options model.ScaffoldOptions) (
 	return scaffold, nil
 }
 
+// shareScaffold saves and returns a scaffold sharing the archive of the newest
+// scaffold with the options hash, or returns nil if there is none or its
+// archive is gone
+func (s *GeneratorServiceImpl) shareScaffold(options model.ScaffoldOptions, hash string) (*model.GeneratedScaffold, error) {
+	s.archives.Lock()
+	defer s.archives.Unlock()
+
+	existing, err := s.scaffoldRepo.FindByHash(hash)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(existing.FilePath); err != nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	scaffold := *existing
+	scaffold.ID = generateID()
+	scaffold.Options = options
+	scaffold.CreatedAt = now.Format(time.RFC3339)
+	scaffold.ExpiresAt = s.expiresAt(now)
+	scaffold.Owner = options.Owner
+	if err := s.scaffoldRepo.Save(&scaffold); err != nil {
+		return nil, err
+	}
+
+	return &scaffold, nil
+}
+
 // GetScaffold returns a generated scaffold by ID
 func (s *GeneratorServiceImpl) GetScaffold(id string) (*model.GeneratedScaffold, error) {
 	return s.scaffoldRepo.GetByID(id)
 }
 
+// ListScaffolds returns a page of the generated scaffolds matching a filter, newest first
+func (s *GeneratorServiceImpl) ListScaffolds(filter model.ScaffoldFilter) (*model.ScaffoldPage, error) {
+	scaffolds, total, err := s.scaffoldRepo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ScaffoldPage{
+		Scaffolds: scaffolds,
+		Total:     total,
+		Offset:    filter.Offset,
+		Limit:     filter.Limit,
+	}, nil
+}
+
+// DeleteScaffold removes a generated scaffold, and its archive unless other
+// scaffolds share it
+func (s *GeneratorServiceImpl) DeleteScaffold(id string) error {
+	scaffold, err := s.scaffoldRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	s.archives.Lock()
+	defer s.archives.Unlock()
+
+	references, err := s.scaffoldRepo.Delete(id)
+	if err != nil {
+		return err
+	}
+	if references > 0 {
+		return nil
+	}
+
+	// The record is gone, so a leftover archive can no longer be downloaded
+	if err := os.Remove(scaffold.FilePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove scaffold archive: %w", err)
+	}
+
+	return nil
+}
+
+// ExpireScaffolds removes every scaffold that has expired at now, and its
+// archive unless other scaffolds share it, and returns the removed scaffolds
+func (s *GeneratorServiceImpl) ExpireScaffolds(now time.Time) ([]*model.GeneratedScaffold, error) {
+	scaffolds, _, err := s.scaffoldRepo.List(model.ScaffoldFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	s.archives.Lock()
+	defer s.archives.Unlock()
+
+	var expired []*model.GeneratedScaffold
+	var errs []error
+	for _, scaffold := range scaffolds {
+		if !scaffold.Expired(now) {
+			continue
+		}
+
+		references, err := s.scaffoldRepo.Expire(scaffold.ID)
+		if errors.Is(err, repository.ErrNotFound) {
+			// Deleted since it was listed
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		expired = append(expired, scaffold)
+		s.publish(scaffold.Owner, model.EventScaffoldExpired, scaffold)
+
+		if references == 0 {
+			if err := os.Remove(scaffold.FilePath); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("failed to remove scaffold archive: %w", err))
+			}
+		}
+	}
+
+	return expired, errors.Join(errs...)
+}
+
+// publish publishes an event to the webhooks of owner, if events are published
+func (s *GeneratorServiceImpl) publish(owner, eventType string, data interface{}) {
+	if s.publisher != nil {
+		s.publisher.Publish(owner, eventType, data)
+	}
+}
+
+// expiresAt returns when a scaffold generated at a time expires, or an empty
+// string if scaffolds are kept until deleted
+func (s *GeneratorServiceImpl) expiresAt(generated time.Time) string {
+	if s.retention <= 0 {
+		return ""
+	}
+	return generated.Add(s.retention).Format(time.RFC3339)
+}
+
 // GetAllTemplates returns all available templates
 func (s *GeneratorServiceImpl) GetAllTemplates() ([]*model.Template, error) {
 	return s.templateRepo.GetAll()
@@ -117,6 +382,48 @@ func (s *GeneratorServiceImpl) GetTemplatesByType(templateType string) ([]*model
 	return s.templateRepo.GetByType(templateType)
 }
 
+// CompileTemplates parses and test-renders every available template tree and
+// caches the trees that succeed. A tree that fails keeps serving its last valid
+// version; the returned error lists every failure.
+func (s *GeneratorServiceImpl) CompileTemplates() error {
+	templates, err := s.templateRepo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, tmpl := range templates {
+		compiled, err := compileTemplate(tmpl)
+		if err == nil {
+			err = s.validateTemplate(tmpl, compiled)
+		}
+		if err != nil {
+			if tmpl.Origin != "" {
+				err = fmt.Errorf("%s: %w", tmpl.Origin, err)
+			}
+			errs = append(errs, fmt.Errorf("template %s: %w", tmpl.ID, err))
+			continue
+		}
+
+		s.templates.put(tmpl, compiled)
+	}
+
+	return errors.Join(errs...)
+}
+
+// GetTemplateVersions returns every available version of a template, newest first
+func (s *GeneratorServiceImpl) GetTemplateVersions(id string) ([]*model.Template, error) {
+	if versioned, ok := s.templateRepo.(repository.VersionedTemplateRepository); ok {
+		return versioned.GetVersions(id)
+	}
+
+	tmpl, err := s.templateRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return []*model.Template{tmpl}, nil
+}
+
 // GetAvailableFeatures returns all available features
 func (s *GeneratorServiceImpl) GetAvailableFeatures() ([]*model.Feature, error) {
 	// This would typically come from a repository or configuration
@@ -181,6 +488,8 @@ func (s *GeneratorServiceImpl) GetAvailableFeatures() ([]*model.Feature, error)
 			Name:        "SQL Migrations",
 			Description: "Database migration tools",
 			IsPremium:   false,
+
+			RequiresDatabase: true,
 		},
 		{
 			ID:          "automatic-https",
@@ -199,6 +508,8 @@ func (s *GeneratorServiceImpl) GetAvailableFeatures() ([]*model.Feature, error)
 			Name:        "User Accounts",
 			Description: "User authentication and management",
 			IsPremium:   true,
+
+			RequiresDatabase: true,
 		},
 	}
 
@@ -207,61 +518,63 @@ func (s *GeneratorServiceImpl) GetAvailableFeatures() ([]*model.Feature, error)
 
 // Helper functions
 
-// validateOptions validates the scaffold options
+// validateOptions validates the scaffold options against the options schema,
+// reporting every problem at once with the JSON path of the offending field
 func (s *GeneratorServiceImpl) validateOptions(options model.ScaffoldOptions) error {
-	if options.AppType != "api" {
-		return errors.New("invalid application type (only 'api' is supported)")
+	schema, err := s.GetOptionsSchema()
+	if err != nil {
+		return err
 	}
 
-	if options.RouterType == "" {
-		return errors.New("router type is required")
-	}
+	// The preset has been applied, so the options must be complete on their own
+	instance := optionsInstance(options)
+	delete(instance, "presetId")
 
-	if options.ModulePath == "" {
-		return errors.New("module path is required")
+	problems := validateSchema(schema, instance, "")
+	if len(problems) > 0 {
+		return &domainservice.ValidationError{Message: "invalid scaffold options", Problems: problems}
 	}
+	return nil
+}
 
-	// Validate features
-	for _, feature := range options.Features {
-		// Check if the feature exists
-		exists := false
-		features, _ := s.GetAvailableFeatures()
-		for _, f := range features {
-			if f.ID == feature && !f.IsPremium {
-				exists = true
-				break
-			}
-		}
-
-		if !exists {
-			return fmt.Errorf("invalid feature: %s", feature)
-		}
+// applyPreset returns options applied to the preset they request, if any
+func (s *GeneratorServiceImpl) applyPreset(options model.ScaffoldOptions) (model.ScaffoldOptions, error) {
+	if options.PresetID == "" {
+		return options, nil
 	}
 
-	// Validate premium features
-	for _, feature := range options.PremiumFeatures {
-		// Check if the feature exists and is premium
-		exists := false
-		features, _ := s.GetAvailableFeatures()
-		for _, f := range features {
-			if f.ID == feature && f.IsPremium {
-				exists = true
-				break
-			}
-		}
-
-		if !exists {
-			return fmt.Errorf("invalid premium feature: %s", feature)
+	var preset *model.Preset
+	err := fmt.Errorf("preset %s: %w", options.PresetID, repository.ErrNotFound)
+	if s.presetRepo != nil {
+		preset, err = s.presetRepo.GetByID(options.PresetID)
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return options, &domainservice.ValidationError{
+			Message: "invalid scaffold options",
+			Problems: []domainservice.Problem{{
+				Field:   "presetId",
+				Code:    domainservice.ProblemInvalidValue,
+				Message: fmt.Sprintf("preset %q does not exist", options.PresetID),
+			}},
 		}
 	}
+	if err != nil {
+		return options, err
+	}
 
-	return nil
+	return preset.Apply(options), nil
 }
 
 // getTemplateForOptions returns the appropriate template for the provided options
 func (s *GeneratorServiceImpl) getTemplateForOptions(options model.ScaffoldOptions) (*model.Template, error) {
-	// This is a simplified implementation
-	// In a real application, we would select the template based on the options
+	if options.TemplateVersion != "" {
+		versioned, ok := s.templateRepo.(repository.VersionedTemplateRepository)
+		if !ok {
+			return nil, fmt.Errorf("template versions are not supported by the template repository")
+		}
+		return versioned.GetVersion(model.NewTemplateID(options.AppType, options.RouterType), options.TemplateVersion)
+	}
+
 	templates, err := s.templateRepo.GetByType(options.AppType)
 	if err != nil {
 		return nil, err
@@ -271,205 +584,377 @@ func (s *GeneratorServiceImpl) getTemplateForOptions(options model.ScaffoldOptio
 		return nil, fmt.Errorf("no templates found for application type: %s", options.AppType)
 	}
 
-	// Find a template that matches the router type
+	// Find the template of the router type
+	id := model.NewTemplateID(options.AppType, options.RouterType)
 	for _, tmpl := range templates {
-		if tmpl.ID == options.AppType+"-"+options.RouterType {
+		if tmpl.ID == id {
 			return tmpl, nil
 		}
 	}
 
-	// If no exact match, return the first template
-	return templates[0], nil
+	return nil, fmt.Errorf("template %s: %w", id, repository.ErrNotFound)
 }
 
-// processTemplate processes the template with the provided options
-func (s *GeneratorServiceImpl) processTemplate(tmpl *model.Template, options model.ScaffoldOptions, outputDir string) error {
-	// Create template data with all options and helper functions
-	templateData := map[string]interface{}{
-		"AppType":      options.AppType,
-		"DatabaseType": options.DatabaseType,
-		"RouterType":   options.RouterType,
-		"ConfigType":   options.ConfigType,
-		"LogFormat":    options.LogFormat,
-		"ModulePath":   options.ModulePath,
-		"Features":     options.Features,
-		"Premium":      options.PremiumFeatures,
-		// Helper functions
-		"HasFeature": func(feature string) bool {
-			for _, f := range options.Features {
-				if f == feature {
-					return true
-				}
+// ValidateTemplate checks a template tree before it is made available. Every
+// template file is parsed on its own so that all syntax errors are reported
+// at once; a tree that parses is then test-rendered with sample options. All
+// problems found are returned as a ValidationError.
+func (s *GeneratorServiceImpl) ValidateTemplate(tmpl *model.Template) error {
+	var problems []domainservice.Problem
+	for _, dir := range []string{tmpl.PartialsPath, tmpl.BasePath, tmpl.Path} {
+		if dir == "" {
+			continue
+		}
+
+		err := walkTemplateLayer(layer(tmpl, dir), func(file templateFile) error {
+			if !file.IsTemplate {
+				return nil
 			}
-			for _, f := range options.PremiumFeatures {
-				if f == feature {
-					return true
-				}
+			if err := parseTemplateFile(template.New("").Funcs(parseFuncs()), file); err != nil {
+				problems = append(problems, domainservice.Problem{Code: domainservice.ProblemInvalidTemplate, Message: err.Error()})
 			}
-			return false
-		},
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read template %s: %w", tmpl.ID, err)
+		}
 	}
 
-	// Walk through the template directory
-	return filepath.Walk(tmpl.Path, func(path string, info os.FileInfo, err error) error {
+	if len(problems) == 0 {
+		compiled, err := compileTemplate(tmpl)
+		if err == nil {
+			err = s.validateTemplate(tmpl, compiled)
+		}
 		if err != nil {
-			return err
+			problems = append(problems, domainservice.Problem{Code: domainservice.ProblemInvalidTemplate, Message: err.Error()})
 		}
+	}
 
-		// Skip directories
-		if info.IsDir() {
-			return nil
+	if len(problems) > 0 {
+		return &domainservice.ValidationError{
+			Message:  fmt.Sprintf("template %s is invalid", tmpl.ID),
+			Problems: problems,
 		}
+	}
 
-		// Check if this is a template file
-		isTemplate := filepath.Ext(path) == ".tmpl"
+	return nil
+}
 
-		// Get the relative path from the template root
-		relPath, err := filepath.Rel(tmpl.Path, path)
-		if err != nil {
-			return fmt.Errorf("failed to get relative path: %w", err)
-		}
+// validateTemplate executes every file of a compiled tree with sample options
+// to catch errors that only surface at execution time
+func (s *GeneratorServiceImpl) validateTemplate(tmpl *model.Template, compiled *compiledTemplate) error {
+	_, router, err := model.ParseTemplateID(tmpl.ID)
+	if err != nil {
+		return err
+	}
+
+	options := model.ScaffoldOptions{
+		AppType:      tmpl.Type,
+		RouterType:   router,
+		DatabaseType: "postgresql",
+		ConfigType:   "env",
+		LogFormat:    "json",
+		ModulePath:   "github.com/example/app",
+	}
 
-		// Determine the output file path
-		outputPath := filepath.Join(outputDir, relPath)
-		if isTemplate {
-			// Remove .tmpl extension for template files
-			outputPath = outputPath[:len(outputPath)-5]
+	funcMap := TemplateFuncs(options)
+	funcMap["dep"] = s.depFunc(make(map[string]*model.Dependency))
+
+	set, err := compiled.bind(funcMap)
+	if err != nil {
+		return err
+	}
+
+	data := TemplateData(options)
+	for _, file := range compiled.files {
+		if !file.IsTemplate {
+			continue
 		}
+		if err := set.ExecuteTemplate(io.Discard, file.Name, data); err != nil {
+			return newTemplateError("execute", file.SourcePath, err)
+		}
+	}
+
+	return nil
+}
+
+// processTemplate processes the template with the provided options
+func (s *GeneratorServiceImpl) processTemplate(tmpl *model.Template, options model.ScaffoldOptions, outputDir string) error {
+	// Create template data and the function library for these options
+	templateData := TemplateData(options)
+	funcMap := TemplateFuncs(options)
+
+	// Dependencies referenced through {{dep "name"}} are recorded so that
+	// their indirect requirements and go.sum entries can be written once
+	// rendering is done
+	usedDeps := make(map[string]*model.Dependency)
+	funcMap["dep"] = s.depFunc(usedDeps)
+
+	// Get the compiled template set and bind it to this request's functions
+	compiled, err := s.templates.get(tmpl)
+	if err != nil {
+		return err
+	}
+	set, err := compiled.bind(funcMap)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range compiled.files {
+		outputPath := filepath.Join(outputDir, filepath.FromSlash(outputName(file)))
 
 		// Create directory structure if it doesn't exist
 		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
 			return fmt.Errorf("failed to create output directory: %w", err)
 		}
 
-		// If this is a template file, process it
-		if isTemplate {
-			// Parse the template
-			t, err := template.New(filepath.Base(path)).ParseFiles(path)
-			if err != nil {
-				return fmt.Errorf("failed to parse template %s: %w", path, err)
-			}
+		if file.IsTemplate {
+			err = renderTemplateFile(set, file, templateData, outputPath)
+		} else {
+			err = copyFile(file, outputPath)
+		}
+		if err != nil {
+			return err
+		}
+	}
 
-			// Create output file
-			out, err := os.Create(outputPath)
-			if err != nil {
-				return fmt.Errorf("failed to create output file %s: %w", outputPath, err)
-			}
-			defer out.Close()
+	if err := writeIndirectRequires(outputDir, usedDeps); err != nil {
+		return err
+	}
+	return writeGoSum(outputDir, usedDeps)
+}
 
-			// Execute the template
-			if err := t.Execute(out, templateData); err != nil {
-				return fmt.Errorf("failed to execute template %s: %w", path, err)
-			}
-		} else {
-			// For non-template files, just copy them
-			srcFile, err := os.Open(path)
-			if err != nil {
-				return fmt.Errorf("failed to open source file %s: %w", path, err)
-			}
-			defer srcFile.Close()
+// depFunc returns the "dep" template function, recording every resolved dependency in used
+func (s *GeneratorServiceImpl) depFunc(used map[string]*model.Dependency) func(name string) (string, error) {
+	return func(name string) (string, error) {
+		if s.dependencyRepo == nil {
+			return "", errors.New("dependency catalog is not configured")
+		}
+		dep, err := s.dependencyRepo.GetByName(name)
+		if err != nil {
+			return "", err
+		}
+		used[dep.Name] = dep
+		return dep.Path + " " + dep.Version, nil
+	}
+}
 
-			dstFile, err := os.Create(outputPath)
-			if err != nil {
-				return fmt.Errorf("failed to create destination file %s: %w", outputPath, err)
-			}
-			defer dstFile.Close()
+// renderTemplateFile executes a template of the set into the output path
+func renderTemplateFile(set *template.Template, file templateFile, data interface{}, outputPath string) error {
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file %s: %w", outputPath, err)
+	}
+	defer out.Close()
 
-			if _, err := io.Copy(dstFile, srcFile); err != nil {
-				return fmt.Errorf("failed to copy file %s: %w", path, err)
+	if err := set.ExecuteTemplate(out, file.Name, data); err != nil {
+		return newTemplateError("execute", file.SourcePath, err)
+	}
+
+	return nil
+}
+
+// copyFile copies a non-template file into the output path
+func copyFile(file templateFile, outputPath string) error {
+	srcPath := file.SourcePath
+	srcFile, err := file.fsys.Open(file.fsPath)
+	if err != nil {
+		return fmt.Errorf("failed to open source file %s: %w", srcPath, err)
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create destination file %s: %w", outputPath, err)
+	}
+	defer dstFile.Close()
+
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		return fmt.Errorf("failed to copy file %s: %w", srcPath, err)
+	}
+
+	return nil
+}
+
+// writeGoSum writes go.sum entries for the catalog dependencies used while
+// rendering and the modules they require
+func writeGoSum(outputDir string, deps map[string]*model.Dependency) error {
+	if len(deps) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var lines []string
+	for _, dep := range deps {
+		own := []string{
+			fmt.Sprintf("%s %s %s", dep.Path, dep.Version, dep.Sum),
+			fmt.Sprintf("%s %s/go.mod %s", dep.Path, dep.Version, dep.GoModSum),
+		}
+		for _, line := range append(own, dep.Sums...) {
+			if !seen[line] {
+				seen[line] = true
+				lines = append(lines, line)
 			}
 		}
+	}
+	sort.Strings(lines)
 
-		return nil
-	})
+	sumPath := filepath.Join(outputDir, "go.sum")
+	if err := os.WriteFile(sumPath, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write go.sum: %w", err)
+	}
+
+	return nil
 }
 
-// createZipArchive creates a ZIP archive from the scaffold directory
-func (s *GeneratorServiceImpl) createZipArchive(dir, zipPath string) error {
-	zipFile, err := os.Create(zipPath)
-	if err != nil {
-		return fmt.Errorf("failed to create zip file: %w", err)
+// writeIndirectRequires appends the modules required by the catalog
+// dependencies used while rendering to go.mod as indirect requirements, at the
+// highest version any of them requires, unless the project requires them directly
+func writeIndirectRequires(outputDir string, deps map[string]*model.Dependency) error {
+	direct := make(map[string]bool, len(deps))
+	for _, dep := range deps {
+		direct[dep.Path] = true
+	}
+
+	versions := make(map[string]string)
+	for _, dep := range deps {
+		for _, require := range dep.Requires {
+			fields := strings.Fields(require)
+			path, version := fields[0], fields[1]
+			if !direct[path] && semver.Compare(version, versions[path]) > 0 {
+				versions[path] = version
+			}
+		}
+	}
+	if len(versions) == 0 {
+		return nil
 	}
-	defer zipFile.Close()
 
-	// Create a new zip writer
-	zw := zip.NewWriter(zipFile)
-	defer zw.Close()
+	var block strings.Builder
+	block.WriteString("\nrequire (\n")
+	for _, path := range sortedKeys(versions) {
+		fmt.Fprintf(&block, "\t%s %s // indirect\n", path, versions[path])
+	}
+	block.WriteString(")\n")
 
-	// Create a codebase/ directory in the zip root
-	codebaseDir := "codebase/"
-	codebaseHeader := &zip.FileHeader{
-		Name:   codebaseDir,
-		Method: zip.Deflate,
+	modPath := filepath.Join(outputDir, "go.mod")
+	file, err := os.OpenFile(modPath, os.O_APPEND|os.O_WRONLY, 0)
+	if os.IsNotExist(err) {
+		// Not a module, so there is nothing to require them from
+		return nil
 	}
-	codebaseHeader.SetMode(0755 | os.ModeDir)
-	_, err = zw.CreateHeader(codebaseHeader)
 	if err != nil {
-		return fmt.Errorf("failed to create codebase directory in zip: %w", err)
+		return fmt.Errorf("failed to add indirect requirements to go.mod: %w", err)
 	}
+	if _, err := file.WriteString(block.String()); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to add indirect requirements to go.mod: %w", err)
+	}
+	return file.Close()
+}
 
-	// Walk through the directory tree
-	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+// archiveModTime is the modification time of every archive entry, so that the
+// same files always give the same archive bytes
+var archiveModTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// createZipArchive creates a reproducible ZIP archive from the scaffold
+// directory and returns its hex-encoded SHA-256. The files are stored below
+// codebase/ and listed with their SHA-256 in a manifest at the root. Entries
+// are sorted by name and carry a fixed timestamp and normalized permissions,
+// so the archive depends only on the names and contents of the files.
+func (s *GeneratorServiceImpl) createZipArchive(dir, zipPath string) (string, error) {
+	// Collect the entries, directories with a trailing slash and no contents
+	entries := map[string][]byte{"codebase/": nil}
+	sums := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// Create a zip header based on the file info
-		header, err := zip.FileInfoHeader(info)
-		if err != nil {
-			return fmt.Errorf("failed to create file header: %w", err)
-		}
-
-		// Set the relative path as the name in the zip, but prefix with "codebase/"
 		relPath, err := filepath.Rel(dir, path)
 		if err != nil {
 			return fmt.Errorf("failed to get relative path: %w", err)
 		}
-
-		// Skip the root directory
 		if relPath == "." {
 			return nil
 		}
 
-		// Add "codebase/" prefix
-		if info.IsDir() {
-			// Add trailing slash to folders
-			header.Name = codebaseDir + relPath + "/"
-		} else {
-			header.Name = codebaseDir + relPath
-		}
-
-		// Set compression method
-		header.Method = zip.Deflate
-
-		if info.IsDir() {
-			// For directories, just write the header
-			_, err = zw.CreateHeader(header)
-			return err
+		name := "codebase/" + filepath.ToSlash(relPath)
+		if entry.IsDir() {
+			entries[name+"/"] = nil
+			return nil
 		}
 
-		// Create the file in the zip
-		writer, err := zw.CreateHeader(header)
+		data, err := os.ReadFile(path)
 		if err != nil {
-			return fmt.Errorf("failed to create file in zip: %w", err)
+			return fmt.Errorf("failed to read %s: %w", path, err)
 		}
+		entries[name] = data
+		sum := sha256.Sum256(data)
+		sums[name] = hex.EncodeToString(sum[:])
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to walk directory: %w", err)
+	}
+	entries[ManifestName] = formatManifest(sums)
 
-		// Open the file for reading
-		file, err := os.Open(path)
-		if err != nil {
-			return fmt.Errorf("failed to open file for reading: %w", err)
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	zipFile, err := os.Create(zipPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create zip file: %w", err)
+	}
+	defer zipFile.Close()
+
+	hash := sha256.New()
+	zw := zip.NewWriter(io.MultiWriter(zipFile, hash))
+
+	for _, name := range names {
+		if err := addZipEntry(zw, name, entries[name]); err != nil {
+			return "", err
 		}
-		defer file.Close()
+	}
 
-		// Copy the file contents to the zip
-		_, err = io.Copy(writer, file)
-		return err
-	})
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("failed to write zip file: %w", err)
+	}
+	if err := zipFile.Close(); err != nil {
+		return "", fmt.Errorf("failed to write zip file: %w", err)
+	}
 
-	if err != nil {
-		return fmt.Errorf("failed to walk directory: %w", err)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// addZipEntry writes a file, or a directory when the name ends with a slash,
+// to a ZIP archive. Directories get mode 0755 and files 0644.
+func addZipEntry(zw *zip.Writer, name string, data []byte) error {
+	header := &zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: archiveModTime,
 	}
 
+	if strings.HasSuffix(name, "/") {
+		header.SetMode(0755 | fs.ModeDir)
+		if _, err := zw.CreateHeader(header); err != nil {
+			return fmt.Errorf("failed to create directory %s in zip: %w", name, err)
+		}
+		return nil
+	}
+
+	header.SetMode(0644)
+	writer, err := zw.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s in zip: %w", name, err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		return fmt.Errorf("failed to add %s to zip: %w", name, err)
+	}
 	return nil
 }
 
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+)
+
+// optionsHash returns the canonical hash of the options and template tree a
+// scaffold is rendered from. Scaffolds with the same hash have the same files,
+// so it leaves out what never reaches the templates: the client, the preset the
+// options were taken from and whether a fresh archive was requested.
+func optionsHash(options model.ScaffoldOptions, tmpl *model.Template, digest string) string {
+	// Lists keep their order, which templates may range over; nil and empty lists render alike
+	canonical := struct {
+		AppType          string   `json:"appType"`
+		DatabaseType     string   `json:"databaseType"`
+		RouterType       string   `json:"routerType"`
+		ConfigType       string   `json:"configType"`
+		LogFormat        string   `json:"logFormat"`
+		ModulePath       string   `json:"modulePath"`
+		Features         []string `json:"features"`
+		PremiumFeatures  []string `json:"premiumFeatures"`
+		TemplateID       string   `json:"templateId"`
+		TemplateVersion  string   `json:"templateVersion"`
+		TemplateRevision string   `json:"templateRevision"`
+		TemplateOrigin   string   `json:"templateOrigin"`
+		TemplateDigest   string   `json:"templateDigest"`
+	}{
+		AppType:          options.AppType,
+		DatabaseType:     options.DatabaseType,
+		RouterType:       options.RouterType,
+		ConfigType:       options.ConfigType,
+		LogFormat:        options.LogFormat,
+		ModulePath:       options.ModulePath,
+		Features:         append([]string{}, options.Features...),
+		PremiumFeatures:  append([]string{}, options.PremiumFeatures...),
+		TemplateID:       tmpl.ID,
+		TemplateVersion:  tmpl.Version,
+		TemplateRevision: tmpl.Revision,
+		TemplateOrigin:   tmpl.Origin,
+		TemplateDigest:   digest,
+	}
+
+	data, _ := json.Marshal(canonical)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,391 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	domainservice "github.com/regiwitanto/go-scaffold/internal/domain/service"
+	"golang.org/x/mod/module"
+)
+
+// formatModulePath is the JSON Schema format of Go module paths, checked with module.CheckPath
+const formatModulePath = "go-module-path"
+
+// Defaults of the options that neither a request nor its preset set
+const (
+	defaultDatabaseType = "none"
+	defaultConfigType   = "env"
+	defaultLogFormat    = "json"
+)
+
+var (
+	// appTypes are the application types a scaffold can be generated for
+	appTypes = []string{"api"}
+
+	// databaseTypes are the database types a scaffold can be generated for
+	databaseTypes = []string{"none", "postgresql", "mysql"}
+
+	// configTypes are the ways a generated application can be configured
+	configTypes = []string{"env", "flags"}
+
+	// logFormats are the log formats a generated application can write
+	logFormats = []string{"json", "text"}
+)
+
+// GetOptionsSchema returns the JSON Schema of scaffold options, built from the
+// current template and feature catalogs. Requests are validated against it.
+func (s *GeneratorServiceImpl) GetOptionsSchema() (*model.JSONSchema, error) {
+	var routers []string
+	for _, appType := range appTypes {
+		templates, err := s.templateRepo.GetByType(appType)
+		if err != nil {
+			return nil, err
+		}
+		for _, tmpl := range templates {
+			if _, router, err := model.ParseTemplateID(tmpl.ID); err == nil && !contains(routers, router) {
+				routers = append(routers, router)
+			}
+		}
+	}
+	sort.Strings(routers)
+
+	catalog, err := s.GetAvailableFeatures()
+	if err != nil {
+		return nil, err
+	}
+
+	var presets []string
+	if s.presetRepo != nil {
+		all, err := s.presetRepo.GetAll()
+		if err != nil {
+			return nil, err
+		}
+		for _, preset := range all {
+			presets = append(presets, preset.ID)
+		}
+	}
+
+	// Versions are looked up by template ID and may have routers the catalog no longer has
+	rules := []*model.JSONSchema{{
+		If: &model.JSONSchema{Required: []string{"templateVersion"}},
+		Else: &model.JSONSchema{
+			Properties: map[string]*model.JSONSchema{
+				"routerType": {Title: "Router type", Enum: routers},
+			},
+		},
+	}}
+
+	var features, premiumFeatures []string
+	for _, feature := range catalog {
+		list := "features"
+		if feature.IsPremium {
+			list = "premiumFeatures"
+			premiumFeatures = append(premiumFeatures, feature.ID)
+		} else {
+			features = append(features, feature.ID)
+		}
+
+		if feature.RequiresDatabase {
+			rules = append(rules, requiresDatabase(list, feature.ID))
+		}
+	}
+
+	properties := map[string]*model.JSONSchema{
+		"appType": {
+			Title: "Application type",
+			Type:  "string",
+			Enum:  appTypes,
+		},
+		"routerType": {
+			Title:       "Router type",
+			Description: "Router of the template to generate from; one of the routers of the catalog unless templateVersion is set",
+			Type:        "string",
+		},
+		"databaseType": {
+			Title:   "Database type",
+			Type:    "string",
+			Enum:    databaseTypes,
+			Default: defaultDatabaseType,
+		},
+		"configType": {
+			Title:   "Config type",
+			Type:    "string",
+			Enum:    configTypes,
+			Default: defaultConfigType,
+		},
+		"logFormat": {
+			Title:   "Log format",
+			Type:    "string",
+			Enum:    logFormats,
+			Default: defaultLogFormat,
+		},
+		"modulePath": {
+			Title:       "Module path",
+			Description: "Go module path of the generated project, e.g. github.com/username/project",
+			Type:        "string",
+			Format:      formatModulePath,
+		},
+		"templateVersion": {
+			Title:       "Template version",
+			Description: "Version of the template to generate from; empty for the default version",
+			Type:        "string",
+		},
+		"features": {
+			Title:       "Features",
+			Type:        "array",
+			Items:       &model.JSONSchema{Title: "Feature", Type: "string", Enum: features},
+			UniqueItems: true,
+		},
+		"premiumFeatures": {
+			Title:       "Premium features",
+			Type:        "array",
+			Items:       &model.JSONSchema{Title: "Premium feature", Type: "string", Enum: premiumFeatures},
+			UniqueItems: true,
+		},
+		"presetId": {
+			Title:       "Preset",
+			Description: "Preset the options start from; the other options override those of the preset",
+			Type:        "string",
+			Enum:        presets,
+		},
+	}
+
+	// Options left out are taken from the preset, if one is requested
+	required := &model.JSONSchema{
+		Required:   []string{"appType", "routerType", "modulePath"},
+		Properties: make(map[string]*model.JSONSchema),
+	}
+	for _, name := range required.Required {
+		required.Properties[name] = &model.JSONSchema{Title: properties[name].Title}
+	}
+	rules = append(rules, &model.JSONSchema{
+		If:   &model.JSONSchema{Required: []string{"presetId"}},
+		Else: required,
+	})
+
+	return &model.JSONSchema{
+		Schema:     "https://json-schema.org/draft/2020-12/schema",
+		Title:      "Scaffold options",
+		Type:       "object",
+		Properties: properties,
+		AllOf:      rules,
+	}, nil
+}
+
+// requiresDatabase returns the rule that a feature in list can only be
+// selected together with a database
+func requiresDatabase(list, feature string) *model.JSONSchema {
+	var databases []string
+	for _, db := range databaseTypes {
+		if db != "none" {
+			databases = append(databases, db)
+		}
+	}
+
+	return &model.JSONSchema{
+		If: &model.JSONSchema{
+			Required: []string{list},
+			Properties: map[string]*model.JSONSchema{
+				list: {Contains: &model.JSONSchema{Const: feature}},
+			},
+		},
+		Then: &model.JSONSchema{
+			Description: fmt.Sprintf("feature %q requires a database: %s", feature, strings.Join(databases, " or ")),
+			Required:    []string{"databaseType"},
+			Properties: map[string]*model.JSONSchema{
+				"databaseType": {Enum: databases},
+			},
+		},
+	}
+}
+
+// withDefaults returns options with the options left out set to their defaults
+func withDefaults(options model.ScaffoldOptions) model.ScaffoldOptions {
+	if options.DatabaseType == "" {
+		options.DatabaseType = defaultDatabaseType
+	}
+	if options.ConfigType == "" {
+		options.ConfigType = defaultConfigType
+	}
+	if options.LogFormat == "" {
+		options.LogFormat = defaultLogFormat
+	}
+	return options
+}
+
+// optionsInstance returns the JSON value of options that is validated against
+// the options schema. Empty strings and lists are left out, since the JSON
+// encoding of ScaffoldOptions does not omit them.
+func optionsInstance(options model.ScaffoldOptions) map[string]interface{} {
+	data, _ := json.Marshal(options)
+
+	var instance map[string]interface{}
+	json.Unmarshal(data, &instance)
+
+	for name, value := range instance {
+		switch v := value.(type) {
+		case nil:
+			delete(instance, name)
+		case string:
+			if v == "" {
+				delete(instance, name)
+			}
+		case []interface{}:
+			if len(v) == 0 {
+				delete(instance, name)
+			}
+		}
+	}
+
+	return instance
+}
+
+// validateSchema validates a JSON value against a schema and returns every
+// problem found. path is the JSON path of the value, empty for the root.
+func validateSchema(schema *model.JSONSchema, value interface{}, path string) []domainservice.Problem {
+	var problems []domainservice.Problem
+	add := func(field, code, format string, args ...interface{}) {
+		problems = append(problems, domainservice.Problem{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if schema.Type != "" && !hasType(value, schema.Type) {
+		add(path, domainservice.ProblemInvalidValue, "%s must be of type %s", label(schema, path), schema.Type)
+		return problems
+	}
+
+	if s, ok := value.(string); ok {
+		switch {
+		case schema.Const != "" && s != schema.Const:
+			add(path, domainservice.ProblemInvalidValue, "%s must be %q", label(schema, path), schema.Const)
+		case len(schema.Enum) > 0 && !contains(schema.Enum, s):
+			add(path, domainservice.ProblemInvalidValue, "invalid %s %q; valid %ss: %s",
+				label(schema, path), s, label(schema, path), strings.Join(schema.Enum, ", "))
+		}
+
+		if schema.Format == formatModulePath {
+			if err := module.CheckPath(s); err != nil {
+				add(path, domainservice.ProblemInvalidFormat, "%v", err)
+			}
+		}
+	}
+
+	if object, ok := value.(map[string]interface{}); ok {
+		// Properties are checked in a stable order so that problems are too
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		for _, name := range schema.Required {
+			if schema.Properties[name] == nil {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			field := joinPath(path, name)
+			property := schema.Properties[name]
+			if property == nil {
+				property = &model.JSONSchema{}
+			}
+
+			v, present := object[name]
+			switch {
+			case !present && contains(schema.Required, name):
+				add(field, domainservice.ProblemRequired, "%s is required", label(property, field))
+			case present:
+				problems = append(problems, validateSchema(property, v, field)...)
+			}
+		}
+	}
+
+	if array, ok := value.([]interface{}); ok {
+		first := make(map[string]int)
+		matched := false
+		for i, item := range array {
+			field := fmt.Sprintf("%s[%d]", path, i)
+			if schema.Items != nil {
+				problems = append(problems, validateSchema(schema.Items, item, field)...)
+			}
+			if schema.Contains != nil && len(validateSchema(schema.Contains, item, field)) == 0 {
+				matched = true
+			}
+
+			if !schema.UniqueItems {
+				continue
+			}
+			key := fmt.Sprint(item)
+			if j, ok := first[key]; ok {
+				add(field, domainservice.ProblemDuplicate, "%s %q is already listed at %s[%d]",
+					label(schema.Items, field), key, path, j)
+			} else {
+				first[key] = i
+			}
+		}
+
+		if schema.Contains != nil && !matched {
+			add(path, domainservice.ProblemInvalidValue, "%s must contain a matching item", label(schema, path))
+		}
+	}
+
+	for _, sub := range schema.AllOf {
+		problems = append(problems, validateSchema(sub, value, path)...)
+	}
+
+	if schema.If != nil {
+		branch := schema.Else
+		if len(validateSchema(schema.If, value, path)) == 0 {
+			branch = schema.Then
+		}
+		if branch != nil {
+			for _, problem := range validateSchema(branch, value, path) {
+				// The rule's description explains the problem better than the keyword that failed
+				if branch.Description != "" {
+					problem.Message = branch.Description
+				}
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	return problems
+}
+
+// hasType reports whether a JSON value is of a JSON Schema type
+func hasType(value interface{}, schemaType string) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	}
+	return true
+}
+
+// label returns the name of a value in validation messages: the lowercased
+// title of its schema, or its path when the schema has no title
+func label(schema *model.JSONSchema, path string) string {
+	if schema == nil || schema.Title == "" {
+		if path == "" {
+			return "value"
+		}
+		return path
+	}
+	return strings.ToLower(schema.Title[:1]) + schema.Title[1:]
+}
+
+// joinPath returns the JSON path of a property of the value at path
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	domainservice "github.com/regiwitanto/go-scaffold/internal/domain/service"
+)
+
+// presetIDPattern matches preset IDs, e.g. "echo-postgres"
+var presetIDPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// OptionsSchemaSource provides the JSON Schema of scaffold options
+type OptionsSchemaSource interface {
+	GetOptionsSchema() (*model.JSONSchema, error)
+}
+
+// PresetServiceImpl implements the PresetService interface
+type PresetServiceImpl struct {
+	repo    repository.PresetRepository
+	schemas OptionsSchemaSource
+}
+
+// NewPresetService creates a new preset service validating preset options against the options schema
+func NewPresetService(repo repository.PresetRepository, schemas OptionsSchemaSource) *PresetServiceImpl {
+	return &PresetServiceImpl{
+		repo:    repo,
+		schemas: schemas,
+	}
+}
+
+// ListPresets returns every preset, built-in ones included, sorted by ID
+func (s *PresetServiceImpl) ListPresets() ([]*model.Preset, error) {
+	return s.repo.GetAll()
+}
+
+// GetPreset returns a preset by ID
+func (s *PresetServiceImpl) GetPreset(id string) (*model.Preset, error) {
+	return s.repo.GetByID(id)
+}
+
+// CreatePreset validates and stores a new preset
+func (s *PresetServiceImpl) CreatePreset(preset *model.Preset) (*model.Preset, error) {
+	if err := s.validatePreset(preset); err != nil {
+		return nil, err
+	}
+
+	preset.BuiltIn = false
+	if err := s.repo.Save(preset); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(preset.ID)
+}
+
+// UpdatePreset validates and replaces a stored preset
+func (s *PresetServiceImpl) UpdatePreset(preset *model.Preset) (*model.Preset, error) {
+	if err := s.validatePreset(preset); err != nil {
+		return nil, err
+	}
+
+	preset.BuiltIn = false
+	if err := s.repo.Update(preset); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(preset.ID)
+}
+
+// DeletePreset removes a stored preset
+func (s *PresetServiceImpl) DeletePreset(id string) error {
+	return s.repo.Delete(id)
+}
+
+// validatePreset checks a preset, reporting every problem at once. Its options
+// may be partial, so they are checked against the options schema without the
+// rule requiring options when no preset is requested.
+func (s *PresetServiceImpl) validatePreset(preset *model.Preset) error {
+	var problems []domainservice.Problem
+
+	if !presetIDPattern.MatchString(preset.ID) {
+		problems = append(problems, domainservice.Problem{
+			Field:   "id",
+			Code:    domainservice.ProblemInvalidFormat,
+			Message: fmt.Sprintf("%q must be lowercase letters and digits separated by dashes", preset.ID),
+		})
+	}
+
+	if preset.Name == "" {
+		problems = append(problems, domainservice.Problem{
+			Field:   "name",
+			Code:    domainservice.ProblemRequired,
+			Message: "name is required",
+		})
+	}
+
+	if preset.Options.PresetID != "" {
+		problems = append(problems, domainservice.Problem{
+			Field:   "options.presetId",
+			Code:    domainservice.ProblemInvalidValue,
+			Message: "presets cannot start from another preset",
+		})
+	}
+
+	schema, err := s.schemas.GetOptionsSchema()
+	if err != nil {
+		return err
+	}
+	partial := &model.JSONSchema{Type: schema.Type, Properties: schema.Properties}
+	for _, rule := range schema.AllOf {
+		if rule.If == nil || !contains(rule.If.Required, "presetId") {
+			partial.AllOf = append(partial.AllOf, rule)
+		}
+	}
+	instance := optionsInstance(preset.Options)
+	delete(instance, "presetId")
+	problems = append(problems, validateSchema(partial, instance, "options")...)
+
+	if len(problems) > 0 {
+		return &domainservice.ValidationError{Message: "invalid preset", Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+)
+
+// ManifestName is the name of the checksum manifest at the root of every archive,
+// listing the SHA-256 of every file in the format of sha256sum
+const ManifestName = "SHA256SUMS"
+
+// ParsePrivateKey parses a PEM-encoded PKCS #8 ed25519 private key, as written
+// by openssl genpkey -algorithm ed25519
+func ParsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, errors.New("signing key is not a PEM-encoded PKCS #8 private key")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key is a %T, not an ed25519 key", key)
+	}
+
+	return privateKey, nil
+}
+
+// ParsePublicKey parses a PEM-encoded PKIX ed25519 public key, as published by GET /api/keys
+func ParsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, errors.New("public key is not a PEM-encoded PKIX public key")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is a %T, not an ed25519 key", key)
+	}
+
+	return publicKey, nil
+}
+
+// GetPublicKeys returns the public keys that archive signatures can be verified
+// with; there are none when archives are not signed
+func (s *GeneratorServiceImpl) GetPublicKeys() ([]*model.PublicKey, error) {
+	if s.signingKey == nil {
+		return []*model.PublicKey{}, nil
+	}
+
+	publicKey := s.signingKey.Public().(ed25519.PublicKey)
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+
+	return []*model.PublicKey{{
+		ID:        keyID(publicKey),
+		Algorithm: "ed25519",
+		PublicKey: base64.StdEncoding.EncodeToString(publicKey),
+		PEM:       string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	}}, nil
+}
+
+// signArchive sets the detached signature of the archive of a scaffold, if archives are signed
+func (s *GeneratorServiceImpl) signArchive(scaffold *model.GeneratedScaffold) error {
+	if s.signingKey == nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(scaffold.FilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read archive for signing: %w", err)
+	}
+
+	scaffold.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.signingKey, data))
+	scaffold.SigningKeyID = keyID(s.signingKey.Public().(ed25519.PublicKey))
+	return nil
+}
+
+// VerifyArchive checks the files of an archive against its checksum manifest
+// and, when a PEM-encoded public key is given, the detached signature of the
+// archive. The error lists every problem found.
+func (s *GeneratorServiceImpl) VerifyArchive(archive, signature, publicKeyPEM []byte) error {
+	var errs []error
+
+	if publicKeyPEM != nil {
+		publicKey, err := ParsePublicKey(publicKeyPEM)
+		if err != nil {
+			return err
+		}
+		if len(signature) != ed25519.SignatureSize || !ed25519.Verify(publicKey, archive, signature) {
+			errs = append(errs, fmt.Errorf("signature was not made by key %s over this archive", keyID(publicKey)))
+		}
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return errors.Join(append(errs, fmt.Errorf("failed to read archive: %w", err))...)
+	}
+
+	// Hash every file of the archive but the manifest
+	sums := make(map[string]string)
+	var manifest []byte
+	hasManifest := false
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		hasManifest = hasManifest || f.Name == ManifestName
+		data, err := readZipEntry(f)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if f.Name == ManifestName {
+			manifest = data
+			continue
+		}
+		sum := sha256.Sum256(data)
+		sums[f.Name] = hex.EncodeToString(sum[:])
+	}
+	if !hasManifest {
+		return errors.Join(append(errs, fmt.Errorf("archive has no %s manifest", ManifestName))...)
+	}
+	if manifest == nil {
+		return errors.Join(errs...)
+	}
+
+	listed, err := parseManifest(manifest)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	for _, name := range sortedKeys(listed) {
+		sum, ok := sums[name]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("%s: listed in %s but missing from the archive", name, ManifestName))
+		case sum != listed[name]:
+			errs = append(errs, fmt.Errorf("%s: checksum does not match %s", name, ManifestName))
+		}
+	}
+	for _, name := range sortedKeys(sums) {
+		if _, ok := listed[name]; !ok {
+			errs = append(errs, fmt.Errorf("%s: not listed in %s", name, ManifestName))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// formatManifest returns the checksum manifest of files, given their hex-encoded SHA-256 by name
+func formatManifest(sums map[string]string) []byte {
+	var b strings.Builder
+	for _, name := range sortedKeys(sums) {
+		fmt.Fprintf(&b, "%s  %s\n", sums[name], name)
+	}
+	return []byte(b.String())
+}
+
+// parseManifest returns the hex-encoded SHA-256 by file name listed in a checksum manifest
+func parseManifest(data []byte) (map[string]string, error) {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		sum, name, ok := strings.Cut(scanner.Text(), "  ")
+		if !ok || len(sum) != sha256.Size*2 || name == "" {
+			return nil, fmt.Errorf("%s:%d: expected a SHA-256 and a file name", ManifestName, line)
+		}
+		sums[name] = sum
+	}
+	return sums, scanner.Err()
+}
+
+// readZipEntry returns the contents of a file of a ZIP archive
+func readZipEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	return data, nil
+}
+
+// keyID returns the ID of a public key: the first 16 hex digits of its SHA-256
+func keyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:8])
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	domainservice "github.com/regiwitanto/go-scaffold/internal/domain/service"
+)
+
+// maxUploadSize is the largest template archive accepted, in bytes
+const maxUploadSize = 32 << 20
+
+// templateIDPattern matches the IDs of uploaded templates, e.g. "api-fiber" or "api-go-kit"
+var templateIDPattern = regexp.MustCompile(`^[a-z][a-z0-9]*-[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// TemplateValidator checks a template tree before it is stored
+type TemplateValidator interface {
+	ValidateTemplate(tmpl *model.Template) error
+}
+
+// TemplateAdminServiceImpl implements the TemplateAdminService interface
+type TemplateAdminServiceImpl struct {
+	store     repository.ManagedTemplateRepository
+	validator TemplateValidator
+	tempDir   string
+}
+
+// NewTemplateAdminService creates a new template admin service
+func NewTemplateAdminService(
+	store repository.ManagedTemplateRepository,
+	validator TemplateValidator,
+	tempDir string,
+) *TemplateAdminServiceImpl {
+	return &TemplateAdminServiceImpl{
+		store:     store,
+		validator: validator,
+		tempDir:   tempDir,
+	}
+}
+
+// UploadTemplate validates a ZIP or tar archive of a template tree and stores
+// it as a draft. The version is stored in canonical form without a "v" prefix.
+func (s *TemplateAdminServiceImpl) UploadTemplate(upload model.TemplateUpload, archive io.Reader) (*model.Template, error) {
+	if err := validateUpload(upload); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(archive, maxUploadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template archive: %w", err)
+	}
+	if len(data) > maxUploadSize {
+		return nil, invalidArchive(fmt.Errorf("archive exceeds %d MiB", maxUploadSize>>20))
+	}
+
+	staging, err := os.MkdirTemp(s.tempDir, "template-upload-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	root, err := extractArchive(bytes.NewReader(data), staging)
+	if err != nil {
+		return nil, invalidArchive(err)
+	}
+
+	sum := sha256.Sum256(data)
+	templateType, router, err := model.ParseTemplateID(upload.ID)
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &model.Template{
+		ID:          upload.ID,
+		Name:        upload.Name,
+		Description: upload.Description,
+		Type:        templateType,
+		Router:      router,
+		Version:     model.CanonicalVersion(upload.Version),
+		Revision:    hex.EncodeToString(sum[:]),
+		Status:      model.TemplateStatusDraft,
+		FS:          os.DirFS(root),
+		Path:        ".",
+	}
+	if tmpl.Name == "" {
+		tmpl.Name = upload.ID
+	}
+
+	if err := s.validator.ValidateTemplate(tmpl); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Save(tmpl, tmpl.FS); err != nil {
+		return nil, err
+	}
+
+	return s.find(tmpl.ID, tmpl.Version)
+}
+
+// ListTemplates returns every uploaded template version, including drafts
+func (s *TemplateAdminServiceImpl) ListTemplates() ([]*model.Template, error) {
+	return s.store.List()
+}
+
+// PublishTemplate makes an uploaded template version available for generation
+func (s *TemplateAdminServiceImpl) PublishTemplate(id, version string) (*model.Template, error) {
+	return s.store.SetStatus(id, version, model.TemplateStatusPublished)
+}
+
+// DeprecateTemplate keeps a published template version available by version only
+func (s *TemplateAdminServiceImpl) DeprecateTemplate(id, version string) (*model.Template, error) {
+	tmpl, err := s.find(id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deprecating a draft would make it available for generation
+	if tmpl.Status == model.TemplateStatusDraft {
+		return nil, &domainservice.ValidationError{
+			Message: fmt.Sprintf("template %s version %s is not published", id, version),
+		}
+	}
+
+	return s.store.SetStatus(id, version, model.TemplateStatusDeprecated)
+}
+
+// DeleteTemplate removes an uploaded template version
+func (s *TemplateAdminServiceImpl) DeleteTemplate(id, version string) error {
+	return s.store.Delete(id, version)
+}
+
+// find returns a stored template version, whatever its status
+func (s *TemplateAdminServiceImpl) find(id, version string) (*model.Template, error) {
+	templates, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tmpl := range templates {
+		if tmpl.ID == id && model.SameVersion(tmpl.Version, version) {
+			return tmpl, nil
+		}
+	}
+
+	return nil, fmt.Errorf("template %s version %s: %w", id, version, repository.ErrNotFound)
+}
+
+// validateUpload checks the metadata of an upload, reporting every problem at once
+func validateUpload(upload model.TemplateUpload) error {
+	var problems []domainservice.Problem
+
+	if !templateIDPattern.MatchString(upload.ID) {
+		problems = append(problems, domainservice.Problem{
+			Field:   "id",
+			Code:    domainservice.ProblemInvalidFormat,
+			Message: fmt.Sprintf("%q must be <type>-<router> in lowercase letters, digits and dashes", upload.ID),
+		})
+	}
+
+	if model.CanonicalVersion(upload.Version) == "" {
+		problems = append(problems, domainservice.Problem{
+			Field:   "version",
+			Code:    domainservice.ProblemInvalidFormat,
+			Message: fmt.Sprintf("%q is not a semantic version", upload.Version),
+		})
+	}
+
+	if len(problems) > 0 {
+		return &domainservice.ValidationError{Message: "invalid template upload", Problems: problems}
+	}
+	return nil
+}
+
+// invalidArchive reports an archive that cannot be extracted as a validation error
+func invalidArchive(err error) error {
+	return &domainservice.ValidationError{
+		Message:  "invalid template archive",
+		Problems: []domainservice.Problem{{Code: domainservice.ProblemInvalidArchive, Message: err.Error()}},
+	}
+}
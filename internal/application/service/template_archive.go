@@ -0,0 +1,202 @@
+package service
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// maxArchiveFiles is the largest number of files accepted in a template archive
+	maxArchiveFiles = 1000
+
+	// maxArchiveExtractedSize is the largest total size of the files in a template archive, in bytes
+	maxArchiveExtractedSize = 64 << 20
+)
+
+// archiveExtractor writes the entries of an archive below a directory,
+// enforcing the limits on file count and size
+type archiveExtractor struct {
+	dir   string
+	files int
+	size  int64
+}
+
+// extractArchive extracts a ZIP, tar or gzipped tar archive into dir and
+// returns the root of the extracted tree. Entries that would escape dir,
+// links and special files are rejected. When every entry is inside a single
+// top-level directory, that directory is returned as the root.
+func extractArchive(archive *bytes.Reader, dir string) (string, error) {
+	magic := make([]byte, 4)
+	n, _ := archive.ReadAt(magic, 0)
+	magic = magic[:n]
+
+	x := &archiveExtractor{dir: dir}
+
+	var err error
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		err = x.extractZip(archive)
+	case bytes.HasPrefix(magic, []byte("\x1f\x8b")):
+		var gz *gzip.Reader
+		if gz, err = gzip.NewReader(archive); err == nil {
+			err = x.extractTar(gz)
+		}
+	default:
+		err = x.extractTar(archive)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if x.files == 0 {
+		return "", errors.New("archive contains no files")
+	}
+
+	return archiveRoot(dir)
+}
+
+// extractZip extracts the entries of a ZIP archive
+func (x *archiveExtractor) extractZip(archive *bytes.Reader) error {
+	zr, err := zip.NewReader(archive, archive.Size())
+	if err != nil {
+		return fmt.Errorf("failed to read zip archive: %w", err)
+	}
+
+	for _, f := range zr.File {
+		mode := f.Mode()
+		if mode.IsDir() {
+			if err := x.mkdir(f.Name); err != nil {
+				return err
+			}
+			continue
+		}
+		if !mode.IsRegular() {
+			return fmt.Errorf("%s: links and special files are not allowed", f.Name)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", f.Name, err)
+		}
+		err = x.writeFile(f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extractTar extracts the entries of a tar archive
+func (x *archiveExtractor) extractTar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar archive: %w", err)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = x.mkdir(header.Name)
+		case tar.TypeReg:
+			err = x.writeFile(header.Name, tr)
+		case tar.TypeXGlobalHeader:
+			// Archive-wide metadata, e.g. written by git archive
+		default:
+			err = fmt.Errorf("%s: links and special files are not allowed", header.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// mkdir creates a directory entry
+func (x *archiveExtractor) mkdir(name string) error {
+	target, err := x.target(name)
+	if err != nil || target == "" {
+		return err
+	}
+
+	return os.MkdirAll(target, 0755)
+}
+
+// writeFile creates a file entry from r
+func (x *archiveExtractor) writeFile(name string, r io.Reader) error {
+	target, err := x.target(name)
+	if err != nil {
+		return err
+	}
+	if target == "" {
+		return fmt.Errorf("%s: invalid file name", name)
+	}
+
+	x.files++
+	if x.files > maxArchiveFiles {
+		return fmt.Errorf("archive contains more than %d files", maxArchiveFiles)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+	defer f.Close()
+
+	// Read one byte past the remaining budget to detect oversized archives
+	remaining := maxArchiveExtractedSize - x.size
+	n, err := io.Copy(f, io.LimitReader(r, remaining+1))
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+	x.size += n
+	if x.size > maxArchiveExtractedSize {
+		return fmt.Errorf("archive contents exceed %d MiB", maxArchiveExtractedSize>>20)
+	}
+
+	return nil
+}
+
+// target returns the path an entry is extracted to, or an empty path for the
+// archive root. Names that are absolute, contain ".." elements or backslashes
+// are rejected so that no entry can be written outside the directory.
+func (x *archiveExtractor) target(name string) (string, error) {
+	clean := strings.TrimSuffix(strings.TrimPrefix(name, "./"), "/")
+	if clean == "" || clean == "." {
+		return "", nil
+	}
+	if strings.Contains(clean, `\`) || !fs.ValidPath(clean) {
+		return "", fmt.Errorf("%s: invalid path", name)
+	}
+
+	return filepath.Join(x.dir, filepath.FromSlash(clean)), nil
+}
+
+// archiveRoot returns the single top-level directory of an extracted tree, or dir itself
+func archiveRoot(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read extracted archive: %w", err)
+	}
+
+	if len(entries) == 1 && entries[0].IsDir() {
+		return filepath.Join(dir, entries[0].Name()), nil
+	}
+	return dir, nil
+}
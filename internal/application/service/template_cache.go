@@ -0,0 +1,140 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"sort"
+	"sync"
+	"text/template"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+)
+
+// templateCacheKey identifies a compiled template tree
+type templateCacheKey struct {
+	ID       string
+	Version  string
+	Revision string
+	Origin   string
+}
+
+// compiledTemplate is a parsed template set together with the files of its tree
+type compiledTemplate struct {
+	set    *template.Template
+	files  []templateFile
+	digest string // Hex SHA-256 of the sources of the tree
+}
+
+// templateCache holds compiled template sets keyed by template ID, version, revision and origin.
+// Sets are parsed with placeholder functions and re-bound to the functions of
+// each request before execution, so a single parse serves every request.
+type templateCache struct {
+	mutex    sync.RWMutex
+	compiled map[templateCacheKey]*compiledTemplate
+}
+
+// newTemplateCache creates an empty template cache
+func newTemplateCache() *templateCache {
+	return &templateCache{
+		compiled: make(map[templateCacheKey]*compiledTemplate),
+	}
+}
+
+// get returns the compiled set for a template, parsing it on first use
+func (c *templateCache) get(tmpl *model.Template) (*compiledTemplate, error) {
+	c.mutex.RLock()
+	compiled, ok := c.compiled[cacheKey(tmpl)]
+	c.mutex.RUnlock()
+	if ok {
+		return compiled, nil
+	}
+
+	compiled, err := compileTemplate(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	c.put(tmpl, compiled)
+
+	return compiled, nil
+}
+
+// put stores the compiled set for a template, replacing any previous version
+func (c *templateCache) put(tmpl *model.Template, compiled *compiledTemplate) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.compiled[cacheKey(tmpl)] = compiled
+}
+
+// cacheKey returns the cache key of a template
+func cacheKey(tmpl *model.Template) templateCacheKey {
+	return templateCacheKey{ID: tmpl.ID, Version: tmpl.Version, Revision: tmpl.Revision, Origin: tmpl.Origin}
+}
+
+// compileTemplate parses a template tree into a compiled set
+func compileTemplate(tmpl *model.Template) (*compiledTemplate, error) {
+	set, files, err := parseTemplateSet(tmpl, parseFuncs())
+	if err != nil {
+		return nil, err
+	}
+
+	digest, err := digestTemplate(set, files)
+	if err != nil {
+		return nil, err
+	}
+
+	return &compiledTemplate{set: set, files: files, digest: digest}, nil
+}
+
+// digestTemplate returns the hex SHA-256 of the parsed templates of a set,
+// partials included, and of the files of its tree that are copied as is
+func digestTemplate(set *template.Template, files []templateFile) (string, error) {
+	h := sha256.New()
+
+	parsed := set.Templates()
+	sort.Slice(parsed, func(i, j int) bool {
+		return parsed[i].Name() < parsed[j].Name()
+	})
+	for _, t := range parsed {
+		if t.Tree != nil && t.Tree.Root != nil {
+			fmt.Fprintf(h, "%s\x00%s\x00", t.Name(), t.Tree.Root.String())
+		}
+	}
+
+	for _, file := range files {
+		if file.IsTemplate {
+			continue
+		}
+		data, err := fs.ReadFile(file.fsys, file.fsPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", file.SourcePath, err)
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", file.Name, len(data))
+		h.Write(data)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// bind returns a copy of the compiled set that executes with the given functions
+func (c *compiledTemplate) bind(funcMap template.FuncMap) (*template.Template, error) {
+	set, err := c.set.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone template set: %w", err)
+	}
+
+	return set.Funcs(funcMap), nil
+}
+
+// parseFuncs returns the function names known to templates at parse time.
+// Parsing only checks that functions exist; the implementations used during
+// execution are bound per request.
+func parseFuncs() template.FuncMap {
+	funcMap := TemplateFuncs(model.ScaffoldOptions{})
+	funcMap["dep"] = func(name string) (string, error) {
+		return "", fmt.Errorf("dependency %s used outside of generation", name)
+	}
+	return funcMap
+}
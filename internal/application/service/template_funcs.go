@@ -0,0 +1,245 @@
+package service
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+)
+
+// majorVersionSuffix matches the /vN suffix of a major-versioned module path
+var majorVersionSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+// TemplateData returns the data passed to every template for the provided options
+func TemplateData(options model.ScaffoldOptions) map[string]interface{} {
+	return map[string]interface{}{
+		"AppType":      options.AppType,
+		"DatabaseType": options.DatabaseType,
+		"RouterType":   options.RouterType,
+		"ConfigType":   options.ConfigType,
+		"LogFormat":    options.LogFormat,
+		"ModulePath":   options.ModulePath,
+		"Features":     options.Features,
+		"Premium":      options.PremiumFeatures,
+		// Kept for templates written before hasFeature was registered as a function
+		"HasFeature": func(feature string) bool {
+			return hasFeature(options, feature)
+		},
+	}
+}
+
+// TemplateFuncs returns the function library available to every template for the provided options
+func TemplateFuncs(options model.ScaffoldOptions) template.FuncMap {
+	return template.FuncMap{
+		// Option helpers
+		"hasFeature": func(feature string) bool {
+			return hasFeature(options, feature)
+		},
+		"hasAny": func(features ...string) bool {
+			for _, feature := range features {
+				if hasFeature(options, feature) {
+					return true
+				}
+			}
+			return false
+		},
+		"hasAll": func(features ...string) bool {
+			for _, feature := range features {
+				if !hasFeature(options, feature) {
+					return false
+				}
+			}
+			return true
+		},
+		"dbIs": func(databaseTypes ...string) bool {
+			return contains(databaseTypes, options.DatabaseType)
+		},
+		"routerIs": func(routerTypes ...string) bool {
+			return contains(routerTypes, options.RouterType)
+		},
+		"projectName": func() string {
+			return projectName(options.ModulePath)
+		},
+
+		// String case helpers
+		"camel":  camelCase,
+		"pascal": pascalCase,
+		"snake":  snakeCase,
+		"kebab":  kebabCase,
+		"plural": plural,
+
+		// Formatting helpers
+		"indent":  indent,
+		"quote":   strconv.Quote,
+		"toJSON":  toJSON,
+		"default": defaultValue,
+	}
+}
+
+// hasFeature reports whether a regular or premium feature was requested
+func hasFeature(options model.ScaffoldOptions, feature string) bool {
+	return contains(options.Features, feature) || contains(options.PremiumFeatures, feature)
+}
+
+// contains reports whether value is in values
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// projectName derives a project name from a module path,
+// e.g. "github.com/acme/billing-api/v2" becomes "billing-api"
+func projectName(modulePath string) string {
+	modulePath = strings.TrimSuffix(modulePath, "/")
+	name := path.Base(modulePath)
+	if majorVersionSuffix.MatchString(name) {
+		name = path.Base(path.Dir(modulePath))
+	}
+	if name == "." || name == "/" {
+		return "app"
+	}
+	return name
+}
+
+// splitWords splits an identifier into lower-case words on separators and case changes
+func splitWords(s string) []string {
+	var words []string
+	var current []rune
+
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r):
+			// Start a new word on "fooBar" and on the last capital of "HTTPServer"
+			if i > 0 && (unicode.IsLower(runes[i-1]) ||
+				(unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				flush()
+			}
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+	}
+	flush()
+
+	return words
+}
+
+// camelCase converts a string to camelCase
+func camelCase(s string) string {
+	words := splitWords(s)
+	for i := 1; i < len(words); i++ {
+		words[i] = upperFirst(words[i])
+	}
+	return strings.Join(words, "")
+}
+
+// pascalCase converts a string to PascalCase
+func pascalCase(s string) string {
+	words := splitWords(s)
+	for i := range words {
+		words[i] = upperFirst(words[i])
+	}
+	return strings.Join(words, "")
+}
+
+// snakeCase converts a string to snake_case
+func snakeCase(s string) string {
+	return strings.Join(splitWords(s), "_")
+}
+
+// kebabCase converts a string to kebab-case
+func kebabCase(s string) string {
+	return strings.Join(splitWords(s), "-")
+}
+
+// upperFirst upper-cases the first letter of a word
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	runes := []rune(s)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// plural returns the English plural of a singular noun using common suffix rules
+func plural(s string) string {
+	lower := strings.ToLower(s)
+	switch {
+	case s == "":
+		return s
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return s + "es"
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return s[:len(s)-1] + "ies"
+	default:
+		return s + "s"
+	}
+}
+
+// indent prefixes every non-empty line of s with the given number of spaces
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = pad + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// toJSON encodes a value as compact JSON
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// defaultValue returns given unless it is empty, in which case def is returned.
+// The argument order allows piping: {{.LogFormat | default "text"}}
+func defaultValue(def interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || isEmpty(given[0]) {
+		return def
+	}
+	return given[0]
+}
+
+// isEmpty reports whether v is nil or the zero value of its type
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return rv.IsZero()
+	}
+}
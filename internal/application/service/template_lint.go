@@ -0,0 +1,348 @@
+package service
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+)
+
+// templateLinter checks the template files of one tree
+type templateLinter struct {
+	fields   map[string]bool // Fields of the template data
+	features map[string]bool // IDs of the available features
+	findings []model.LintFinding
+}
+
+// fileLint holds the state of linting a single file
+type fileLint struct {
+	path    string
+	content string
+	router  string // Router the file is rendered for, empty when it is shared
+	body    bool   // Whether the tree being walked is the body of the file
+	output  bool   // Whether the body has any output
+	reached bool   // Whether any output of the body is reachable
+}
+
+// LintTemplates parses every .tmpl file of fsys with the functions and data
+// used during generation and reports references to unknown fields, unknown
+// feature IDs and files whose output is behind conditions that are never true
+func (s *GeneratorServiceImpl) LintTemplates(fsys fs.FS) ([]model.LintFinding, error) {
+	features, err := s.GetAvailableFeatures()
+	if err != nil {
+		return nil, err
+	}
+
+	l := &templateLinter{
+		fields:   make(map[string]bool),
+		features: make(map[string]bool),
+	}
+	for field := range TemplateData(model.ScaffoldOptions{}) {
+		l.fields[field] = true
+	}
+	for _, feature := range features {
+		l.features[feature.ID] = true
+	}
+
+	err = fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || path.Ext(name) != ".tmpl" {
+			return nil
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("failed to read template %s: %w", name, err)
+		}
+		l.lintFile(name, string(content))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		if l.findings[i].Path != l.findings[j].Path {
+			return l.findings[i].Path < l.findings[j].Path
+		}
+		return l.findings[i].Line < l.findings[j].Line
+	})
+
+	return l.findings, nil
+}
+
+// lintFile checks a single template file
+func (l *templateLinter) lintFile(name, content string) {
+	f := &fileLint{path: name, content: content, router: routerOf(name)}
+
+	set, err := template.New(name).Funcs(parseFuncs()).Parse(content)
+	if err != nil {
+		templateErr := newTemplateError("parse", name, err)
+		l.report(f, model.LintRuleParseError, model.LintSeverityError, templateErr.Line, templateErr.Message)
+		return
+	}
+
+	for _, t := range set.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		// Only the body of the file is output; {{define}} blocks are output where they are used
+		f.body = t.Name() == name
+		l.walk(f, t.Tree.Root, true, true)
+	}
+
+	if f.output && !f.reached {
+		l.report(f, model.LintRuleUnreachableFile, model.LintSeverityWarning, 0,
+			"every output of the file is behind conditions that are never true, so it always renders empty")
+	}
+}
+
+// walk checks a node of a template tree. rootDot reports whether dot is the
+// template data, and reachable whether the node can be executed when node is
+// part of the file's output.
+func (l *templateLinter) walk(f *fileLint, node parse.Node, rootDot, reachable bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			l.walk(f, child, rootDot, reachable)
+		}
+	case *parse.TextNode:
+		if strings.TrimSpace(string(n.Text)) != "" {
+			l.markOutput(f, reachable)
+		}
+	case *parse.ActionNode:
+		l.checkPipe(f, n.Pipe, rootDot)
+		if len(n.Pipe.Decl) == 0 {
+			l.markOutput(f, reachable)
+		}
+	case *parse.TemplateNode:
+		l.checkPipe(f, n.Pipe, rootDot)
+		l.markOutput(f, reachable)
+	case *parse.IfNode:
+		l.checkPipe(f, n.Pipe, rootDot)
+		known, value := l.staticValue(f, n.Pipe)
+		l.walk(f, n.List, rootDot, reachable && (!known || value))
+		l.walk(f, n.ElseList, rootDot, reachable && (!known || !value))
+	case *parse.RangeNode:
+		l.checkPipe(f, n.Pipe, rootDot)
+		l.walk(f, n.List, false, reachable)
+		l.walk(f, n.ElseList, rootDot, reachable)
+	case *parse.WithNode:
+		l.checkPipe(f, n.Pipe, rootDot)
+		l.walk(f, n.List, false, reachable)
+		l.walk(f, n.ElseList, rootDot, reachable)
+	}
+}
+
+// markOutput records output of the body of the file
+func (l *templateLinter) markOutput(f *fileLint, reachable bool) {
+	if !f.body {
+		return
+	}
+	f.output = true
+	if reachable {
+		f.reached = true
+	}
+}
+
+// checkPipe reports unknown fields and feature IDs used in a pipeline
+func (l *templateLinter) checkPipe(f *fileLint, pipe *parse.PipeNode, rootDot bool) {
+	if pipe == nil {
+		return
+	}
+
+	for _, cmd := range pipe.Cmds {
+		features, _ := featureArgs(cmd)
+		for _, feature := range features {
+			if !l.features[feature.Text] {
+				l.report(f, model.LintRuleUnknownFeature, model.LintSeverityError, f.line(feature.Position()),
+					fmt.Sprintf("unknown feature %q", feature.Text))
+			}
+		}
+
+		for _, arg := range cmd.Args {
+			switch a := arg.(type) {
+			case *parse.FieldNode:
+				if rootDot && !l.fields[a.Ident[0]] {
+					l.report(f, model.LintRuleUnknownField, model.LintSeverityError, f.line(a.Position()),
+						fmt.Sprintf("unknown field .%s", a.Ident[0]))
+				}
+			case *parse.VariableNode:
+				if a.Ident[0] == "$" && len(a.Ident) > 1 && !l.fields[a.Ident[1]] {
+					l.report(f, model.LintRuleUnknownField, model.LintSeverityError, f.line(a.Position()),
+						fmt.Sprintf("unknown field $.%s", a.Ident[1]))
+				}
+			case *parse.PipeNode:
+				l.checkPipe(f, a, rootDot)
+			case *parse.ChainNode:
+				if p, ok := a.Node.(*parse.PipeNode); ok {
+					l.checkPipe(f, p, rootDot)
+				}
+			}
+		}
+	}
+}
+
+// staticValue evaluates the condition of an {{if}} when its value does not
+// depend on the options: a feature check for unknown features, or a router
+// check in a file that is only rendered for one router
+func (l *templateLinter) staticValue(f *fileLint, pipe *parse.PipeNode) (known, value bool) {
+	if len(pipe.Decl) > 0 || len(pipe.Cmds) != 1 {
+		return false, false
+	}
+
+	cmd := pipe.Cmds[0]
+	if len(cmd.Args) == 1 {
+		if inner, ok := cmd.Args[0].(*parse.PipeNode); ok {
+			return l.staticValue(f, inner)
+		}
+	}
+
+	fn, ok := cmd.Args[0].(*parse.IdentifierNode)
+	if !ok {
+		return false, false
+	}
+
+	args := stringArgs(cmd.Args[1:])
+	switch fn.Ident {
+	case "not":
+		if len(cmd.Args) == 2 {
+			if inner, ok := cmd.Args[1].(*parse.PipeNode); ok {
+				known, value := l.staticValue(f, inner)
+				return known, !value
+			}
+		}
+	case "hasFeature", "hasAny", "call":
+		// A check that only names unknown features is never true
+		features, literal := featureArgs(cmd)
+		if len(features) == 0 || !literal {
+			return false, false
+		}
+		for _, feature := range features {
+			if l.features[feature.Text] {
+				return false, false
+			}
+		}
+		return true, false
+	case "hasAll":
+		// A check that needs an unknown feature is never true
+		features, _ := featureArgs(cmd)
+		for _, feature := range features {
+			if !l.features[feature.Text] {
+				return true, false
+			}
+		}
+	case "routerIs":
+		if f.router != "" && len(args) == len(cmd.Args)-1 {
+			return true, contains(args, f.router)
+		}
+	case "dbIs":
+		if len(args) == len(cmd.Args)-1 {
+			for _, db := range args {
+				if contains(databaseTypes, db) {
+					return false, false
+				}
+			}
+			return true, false
+		}
+	}
+
+	return false, false
+}
+
+// report records a finding for a file, once per line
+func (l *templateLinter) report(f *fileLint, rule, severity string, line int, message string) {
+	finding := model.LintFinding{
+		Rule:     rule,
+		Severity: severity,
+		Path:     f.path,
+		Line:     line,
+		Message:  message,
+	}
+
+	for _, existing := range l.findings {
+		if existing == finding {
+			return
+		}
+	}
+	l.findings = append(l.findings, finding)
+}
+
+// line returns the line of a position in the file
+func (f *fileLint) line(pos parse.Pos) int {
+	if int(pos) > len(f.content) {
+		return 0
+	}
+	return 1 + strings.Count(f.content[:pos], "\n")
+}
+
+// featureArgs returns the feature IDs passed as string literals to
+// hasFeature, hasAny, hasAll or the legacy call .HasFeature form, and whether
+// every feature argument is a literal
+func featureArgs(cmd *parse.CommandNode) ([]*parse.StringNode, bool) {
+	fn, ok := cmd.Args[0].(*parse.IdentifierNode)
+	if !ok {
+		return nil, false
+	}
+
+	args := cmd.Args[1:]
+	switch fn.Ident {
+	case "hasFeature", "hasAny", "hasAll":
+	case "call":
+		if len(args) == 0 {
+			return nil, false
+		}
+		field, ok := args[0].(*parse.FieldNode)
+		if !ok || len(field.Ident) != 1 || field.Ident[0] != "HasFeature" {
+			return nil, false
+		}
+		args = args[1:]
+	default:
+		return nil, false
+	}
+
+	var features []*parse.StringNode
+	for _, arg := range args {
+		if s, ok := arg.(*parse.StringNode); ok {
+			features = append(features, s)
+		}
+	}
+	return features, len(features) == len(args)
+}
+
+// stringArgs returns the values of the string literal arguments
+func stringArgs(args []parse.Node) []string {
+	var values []string
+	for _, arg := range args {
+		if s, ok := arg.(*parse.StringNode); ok {
+			values = append(values, s.Text)
+		}
+	}
+	return values
+}
+
+// routerOf returns the router a file of a template tree is rendered for, e.g.
+// "echo" for "api/echo/main.go.tmpl" or "api@1.0.0/echo/main.go.tmpl", or an
+// empty router for shared files and files outside a type directory
+func routerOf(name string) string {
+	parts := strings.Split(name, "/")
+	if len(parts) < 3 || strings.HasPrefix(parts[1], "_") || strings.HasPrefix(parts[1], ".") {
+		return ""
+	}
+
+	templateType, _, _ := strings.Cut(parts[0], "@")
+	if templateType != "api" {
+		return ""
+	}
+	return parts[1]
+}
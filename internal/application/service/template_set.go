@@ -0,0 +1,197 @@
+package service
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+)
+
+// partialsPrefix namespaces partial files inside a template set so they
+// cannot collide with files of the rendered tree
+const partialsPrefix = "_partials/"
+
+// templateErrorLocation matches the "template: NAME:LINE:" prefix of text/template errors
+var templateErrorLocation = regexp.MustCompile(`^template: [^:]*:(\d+):(?:\d+:)? (.*)$`)
+
+// TemplateError describes a template file that failed to parse or execute,
+// with the line of the failure when text/template reports one
+type TemplateError struct {
+	Op      string // "parse" or "execute"
+	Path    string // Filesystem path of the template file
+	Line    int    // Line of the failure, 0 when unknown
+	Message string // Failure description without the location prefix
+	Err     error  // Underlying text/template error
+}
+
+// newTemplateError creates a TemplateError, extracting the line from a text/template error
+func newTemplateError(op, path string, err error) *TemplateError {
+	tmplErr := &TemplateError{Op: op, Path: path, Message: err.Error(), Err: err}
+
+	if m := templateErrorLocation.FindStringSubmatch(err.Error()); m != nil {
+		tmplErr.Line, _ = strconv.Atoi(m[1])
+		tmplErr.Message = m[2]
+	}
+
+	return tmplErr
+}
+
+// Error implements the error interface
+func (e *TemplateError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("failed to %s template %s:%d: %s", e.Op, e.Path, e.Line, e.Message)
+	}
+	return fmt.Sprintf("failed to %s template %s: %s", e.Op, e.Path, e.Message)
+}
+
+// Unwrap returns the underlying text/template error
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// templateFile is a single output file of a composed template tree
+type templateFile struct {
+	Name       string // Slash-separated path relative to the tree root, used as the template name
+	SourcePath string // Path of the file in the highest-priority layer providing it, for messages
+	IsTemplate bool   // Whether the file is rendered rather than copied
+
+	fsys   fs.FS  // Filesystem holding the file
+	fsPath string // Path of the file within fsys
+}
+
+// templateLayer is one directory of a template tree within a filesystem
+type templateLayer struct {
+	fsys fs.FS
+	root string // Root of the layer within fsys
+	path string // Path of the layer root as given by the template, for messages
+}
+
+// layer returns the layer of a template rooted at dir. Templates without a
+// filesystem are read from the OS filesystem with dir as an OS path.
+func layer(tmpl *model.Template, dir string) templateLayer {
+	if tmpl.FS == nil {
+		return templateLayer{fsys: os.DirFS(dir), root: ".", path: dir}
+	}
+	return templateLayer{fsys: tmpl.FS, root: dir, path: dir}
+}
+
+// sourcePath returns the path of a file of the layer for messages
+func (l templateLayer) sourcePath(name string) string {
+	if l.root == "." {
+		return filepath.Join(l.path, filepath.FromSlash(name))
+	}
+	return path.Join(l.path, name)
+}
+
+// parseTemplateSet parses the partials, the base tree and the template tree into a
+// single template set and returns it with the files of the composed tree.
+//
+// Layers are parsed lowest priority first under the file's relative path. A
+// template file whose body is empty only contributes its {{define}} blocks, so
+// it extends the file of the same name in the base tree by overriding blocks;
+// any other template file replaces the base file entirely.
+func parseTemplateSet(tmpl *model.Template, funcMap template.FuncMap) (*template.Template, []templateFile, error) {
+	set := template.New("").Funcs(funcMap)
+
+	if tmpl.PartialsPath != "" {
+		partials := layer(tmpl, tmpl.PartialsPath)
+		err := walkTemplateLayer(partials, func(file templateFile) error {
+			if !file.IsTemplate {
+				return nil
+			}
+			file.Name = partialsPrefix + file.Name
+			return parseTemplateFile(set, file)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	files := make(map[string]templateFile)
+	for _, dir := range []string{tmpl.BasePath, tmpl.Path} {
+		if dir == "" {
+			continue
+		}
+
+		err := walkTemplateLayer(layer(tmpl, dir), func(file templateFile) error {
+			if file.IsTemplate {
+				if err := parseTemplateFile(set, file); err != nil {
+					return err
+				}
+			}
+
+			files[file.Name] = file
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	ordered := make([]templateFile, 0, len(files))
+	for _, file := range files {
+		ordered = append(ordered, file)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].Name < ordered[j].Name
+	})
+
+	return set, ordered, nil
+}
+
+// walkTemplateLayer calls fn for every file of a layer
+func walkTemplateLayer(l templateLayer, fn func(file templateFile) error) error {
+	return fs.WalkDir(l.fsys, l.root, func(fsPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip directories
+		if entry.IsDir() {
+			return nil
+		}
+
+		name := fsPath
+		if l.root != "." {
+			name = strings.TrimPrefix(fsPath, l.root+"/")
+		}
+
+		return fn(templateFile{
+			Name:       name,
+			SourcePath: l.sourcePath(name),
+			IsTemplate: path.Ext(name) == ".tmpl",
+			fsys:       l.fsys,
+			fsPath:     fsPath,
+		})
+	})
+}
+
+// parseTemplateFile parses a template file into the set under its name
+func parseTemplateFile(set *template.Template, file templateFile) error {
+	content, err := fs.ReadFile(file.fsys, file.fsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read template %s: %w", file.SourcePath, err)
+	}
+
+	if _, err := set.New(file.Name).Parse(string(content)); err != nil {
+		return newTemplateError("parse", file.SourcePath, err)
+	}
+
+	return nil
+}
+
+// outputName returns the output path of a template file relative to the scaffold root
+func outputName(file templateFile) string {
+	if file.IsTemplate {
+		return strings.TrimSuffix(file.Name, ".tmpl")
+	}
+	return file.Name
+}
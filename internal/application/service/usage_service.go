@@ -0,0 +1,252 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	domainservice "github.com/regiwitanto/go-scaffold/internal/domain/service"
+)
+
+// pruneThreshold is the number of tracked clients above which idle ones are forgotten
+const pruneThreshold = 4096
+
+// UsageServiceImpl implements the UsageService interface in memory, so usage
+// is tracked per server instance and starts over when it restarts
+type UsageServiceImpl struct {
+	defaults model.Limits
+	now      func() time.Time
+	clients  map[string]*clientUsage
+	mutex    sync.Mutex
+}
+
+// clientUsage is the usage of a single client
+type clientUsage struct {
+	limits      model.Limits   // Limits the client was last checked against
+	tokens      float64        // Requests left in the token bucket
+	refilled    time.Time      // When the token bucket was last refilled
+	day         time.Time      // Start of the UTC day generations are counted for
+	generations int            // Generations reserved on day
+	storage     int64          // Bytes of generated scaffold archives stored
+	archives    map[string]int // Scaffolds per archive file, as identical options share one archive
+}
+
+// UsageOption configures the usage service
+type UsageOption func(*UsageServiceImpl)
+
+// WithClock sets the clock usage is tracked with, instead of time.Now
+func WithClock(now func() time.Time) UsageOption {
+	return func(s *UsageServiceImpl) {
+		s.now = now
+	}
+}
+
+// NewUsageService creates a new usage service limiting clients without a plan of their own to defaults
+func NewUsageService(defaults model.Limits, opts ...UsageOption) *UsageServiceImpl {
+	s := &UsageServiceImpl{
+		defaults: defaults,
+		now:      time.Now,
+		clients:  make(map[string]*clientUsage),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Allow takes a request from the rate limit of a client, returning a *QuotaError when none is left
+func (s *UsageServiceImpl) Allow(client string, plan *model.Plan) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	if len(s.clients) > pruneThreshold {
+		s.prune(now)
+	}
+
+	usage := s.client(client, plan, now)
+	if usage.limits.RequestsPerMinute <= 0 {
+		return nil
+	}
+
+	if usage.tokens < 1 {
+		perToken := time.Minute / time.Duration(usage.limits.RequestsPerMinute)
+		return &domainservice.QuotaError{
+			Message:    fmt.Sprintf("rate limit of %d requests per minute exceeded", usage.limits.RequestsPerMinute),
+			RetryAfter: time.Duration(math.Ceil((1 - usage.tokens) * float64(perToken))),
+		}
+	}
+
+	usage.tokens--
+	return nil
+}
+
+// ReserveGeneration counts a generation against the daily and storage
+// quotas of a client, returning a *QuotaError when one is used up
+func (s *UsageServiceImpl) ReserveGeneration(client string, plan *model.Plan) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	usage := s.client(client, plan, now)
+
+	if limit := usage.limits.DailyGenerations; limit > 0 && usage.generations >= limit {
+		return &domainservice.QuotaError{
+			Message:    fmt.Sprintf("daily quota of %d generations used up", limit),
+			RetryAfter: usage.day.Add(24 * time.Hour).Sub(now),
+		}
+	}
+
+	// Storage is only freed by deleting scaffolds, so there is no time to retry after
+	if limit := usage.limits.StorageBytes; limit > 0 && usage.storage >= limit {
+		return &domainservice.QuotaError{
+			Message: fmt.Sprintf("storage quota of %d bytes used up", limit),
+		}
+	}
+
+	usage.generations++
+	return nil
+}
+
+// CompleteGeneration records the scaffold of a reserved generation, or
+// releases the reservation when scaffold is nil or does not fit in the
+// storage quota of the client. An archive the client already stores for
+// another scaffold is not counted again.
+func (s *UsageServiceImpl) CompleteGeneration(client string, scaffold *model.GeneratedScaffold) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	usage, ok := s.clients[client]
+	if !ok {
+		return nil
+	}
+
+	if scaffold == nil {
+		if usage.generations > 0 {
+			usage.generations--
+		}
+		return nil
+	}
+
+	if scaffold.FilePath != "" && usage.archives[scaffold.FilePath] > 0 {
+		usage.archives[scaffold.FilePath]++
+		return nil
+	}
+
+	// Reservations only check the storage already used, as the size of the
+	// archive is not known until it is generated
+	if limit := usage.limits.StorageBytes; limit > 0 && usage.storage+scaffold.Size > limit {
+		if usage.generations > 0 {
+			usage.generations--
+		}
+		return &domainservice.QuotaError{
+			Message: fmt.Sprintf("scaffold of %d bytes exceeds the storage quota of %d bytes, %d bytes are used", scaffold.Size, limit, usage.storage),
+		}
+	}
+
+	usage.storage += scaffold.Size
+	if scaffold.FilePath != "" {
+		if usage.archives == nil {
+			usage.archives = make(map[string]int)
+		}
+		usage.archives[scaffold.FilePath] = 1
+	}
+	return nil
+}
+
+// ReleaseStorage frees the storage of a deleted scaffold of a client, once
+// no other scaffold of the client shares its archive
+func (s *UsageServiceImpl) ReleaseStorage(client string, scaffold *model.GeneratedScaffold) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	usage, ok := s.clients[client]
+	if !ok {
+		return
+	}
+
+	if scaffold.FilePath != "" {
+		if usage.archives[scaffold.FilePath] > 1 {
+			usage.archives[scaffold.FilePath]--
+			return
+		}
+		delete(usage.archives, scaffold.FilePath)
+	}
+	usage.storage = max(usage.storage-scaffold.Size, 0)
+}
+
+// GetUsage returns how much of its limits a client has used
+func (s *UsageServiceImpl) GetUsage(client string, plan *model.Plan) *model.Usage {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	usage := s.client(client, plan, s.now())
+
+	result := &model.Usage{
+		Client:             client,
+		Limits:             usage.limits,
+		GenerationsToday:   usage.generations,
+		GenerationsResetAt: usage.day.Add(24 * time.Hour),
+		StorageBytes:       usage.storage,
+	}
+	if plan != nil {
+		result.Plan = plan.ID
+	}
+	if usage.limits.RequestsPerMinute > 0 {
+		result.RequestsRemaining = int(usage.tokens)
+	}
+
+	return result
+}
+
+// client returns the usage of a client with its token bucket refilled and
+// its generations counted for the current day. The caller must hold the mutex.
+func (s *UsageServiceImpl) client(id string, plan *model.Plan, now time.Time) *clientUsage {
+	limits := s.defaults
+	if plan != nil && plan.Limits != nil {
+		limits = *plan.Limits
+	}
+
+	usage, ok := s.clients[id]
+	if !ok {
+		usage = &clientUsage{tokens: bucketSize(limits), refilled: now}
+		s.clients[id] = usage
+	}
+
+	usage.limits = limits
+	elapsed := now.Sub(usage.refilled).Minutes()
+	usage.tokens = math.Min(bucketSize(limits), usage.tokens+elapsed*float64(limits.RequestsPerMinute))
+	usage.refilled = now
+
+	if day := now.UTC().Truncate(24 * time.Hour); !usage.day.Equal(day) {
+		usage.day = day
+		usage.generations = 0
+	}
+
+	return usage
+}
+
+// prune forgets clients whose usage is back to nothing: a full token bucket,
+// no generations today and nothing stored. The caller must hold the mutex.
+func (s *UsageServiceImpl) prune(now time.Time) {
+	today := now.UTC().Truncate(24 * time.Hour)
+	for id, usage := range s.clients {
+		elapsed := now.Sub(usage.refilled).Minutes()
+		full := usage.tokens+elapsed*float64(usage.limits.RequestsPerMinute) >= bucketSize(usage.limits)
+		if full && usage.storage == 0 && (usage.generations == 0 || !usage.day.Equal(today)) {
+			delete(s.clients, id)
+		}
+	}
+}
+
+// bucketSize returns the number of requests the token bucket of limits holds
+func bucketSize(limits model.Limits) float64 {
+	if limits.Burst > 0 {
+		return float64(limits.Burst)
+	}
+	return math.Max(1, float64(limits.RequestsPerMinute))
+}
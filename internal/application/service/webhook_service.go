@@ -0,0 +1,502 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	domainservice "github.com/regiwitanto/go-scaffold/internal/domain/service"
+)
+
+// Headers of webhook deliveries
+const (
+	HeaderWebhookEvent     = "X-Webhook-Event"     // Type of the event
+	HeaderWebhookDelivery  = "X-Webhook-Delivery"  // ID of the delivery, the same for every attempt
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp" // Unix time the attempt was signed at
+	HeaderWebhookSignature = "X-Webhook-Signature" // "sha256=" and the hex HMAC-SHA256 of the timestamp, a dot and the body
+)
+
+const (
+	// DefaultWebhookAttempts is how often a delivery is attempted before it fails
+	DefaultWebhookAttempts = 5
+	// DefaultWebhookBackoff is the delay before the first retry, doubling with every further retry
+	DefaultWebhookBackoff = 2 * time.Second
+	// webhookTimeout is how long an attempt waits for a response
+	webhookTimeout = 10 * time.Second
+	// webhookResolveTimeout is how long resolving the host of a new webhook may take
+	webhookResolveTimeout = 5 * time.Second
+)
+
+var (
+	// errPrivateWebhookTarget is returned when dialing an address webhooks may not be delivered to
+	errPrivateWebhookTarget = errors.New("webhook target is a loopback, private or link-local address")
+
+	// errWebhooksClosed is returned when queueing a delivery after the service was closed
+	errWebhooksClosed = errors.New("webhook deliveries have been stopped")
+)
+
+// deliveryStopped is the error logged on deliveries given up when the service is closed
+const deliveryStopped = "delivery stopped by server shutdown"
+
+// deliveryStates lists the states deliveries can be filtered by
+var deliveryStates = []string{model.DeliveryPending, model.DeliverySucceeded, model.DeliveryFailed}
+
+// WebhookServiceImpl implements the WebhookService and EventPublisher
+// interfaces. Events are delivered in the background, retrying failed
+// attempts with exponential backoff. Unless private targets are allowed,
+// webhooks cannot point at loopback, private or link-local addresses, which
+// is checked when they are registered and again whenever a delivery dials.
+// Close stops every delivery, so the service must be closed on shutdown.
+type WebhookServiceImpl struct {
+	repo         repository.WebhookRepository
+	client       *http.Client
+	resolver     *net.Resolver
+	allowPrivate bool
+	attempts     int
+	backoff      time.Duration
+
+	ctx     context.Context // Cancelled by Close, stopping every delivery
+	stop    context.CancelFunc
+	mutex   sync.Mutex
+	closed  bool
+	pending sync.WaitGroup
+}
+
+// WebhookOption configures the webhook service
+type WebhookOption func(*WebhookServiceImpl)
+
+// WithWebhookClient sets the HTTP client events are delivered with. The
+// client is responsible for refusing private targets at dial time.
+func WithWebhookClient(client *http.Client) WebhookOption {
+	return func(s *WebhookServiceImpl) {
+		s.client = client
+	}
+}
+
+// WithWebhookRetries sets how often a delivery is attempted and the delay before the first retry
+func WithWebhookRetries(attempts int, backoff time.Duration) WebhookOption {
+	return func(s *WebhookServiceImpl) {
+		s.attempts = attempts
+		s.backoff = backoff
+	}
+}
+
+// WithPrivateWebhookTargets allows webhooks to point at loopback, private
+// and link-local addresses, e.g. a local stand-in during development
+func WithPrivateWebhookTargets() WebhookOption {
+	return func(s *WebhookServiceImpl) {
+		s.allowPrivate = true
+	}
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(repo repository.WebhookRepository, opts ...WebhookOption) *WebhookServiceImpl {
+	s := &WebhookServiceImpl{
+		repo:     repo,
+		resolver: net.DefaultResolver,
+		attempts: DefaultWebhookAttempts,
+		backoff:  DefaultWebhookBackoff,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.ctx, s.stop = context.WithCancel(context.Background())
+
+	// Addresses are checked once resolved, so a host cannot switch to a
+	// private address after it was registered. Proxies would hide the target.
+	if s.client == nil {
+		dialer := &net.Dialer{Timeout: webhookTimeout, Control: s.checkDial}
+		s.client = &http.Client{
+			Timeout:   webhookTimeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: webhookTimeout},
+		}
+	}
+
+	return s
+}
+
+// ListWebhooks returns the webhooks of an owner, oldest first
+func (s *WebhookServiceImpl) ListWebhooks(owner string) ([]*model.Webhook, error) {
+	return s.repo.List(owner)
+}
+
+// GetWebhook returns a webhook by ID
+func (s *WebhookServiceImpl) GetWebhook(id string) (*model.Webhook, error) {
+	return s.repo.GetByID(id)
+}
+
+// CreateWebhook validates and stores a new webhook, giving it an ID and a signing secret
+func (s *WebhookServiceImpl) CreateWebhook(webhook *model.Webhook) (*model.Webhook, error) {
+	if err := s.validateWebhook(webhook); err != nil {
+		return nil, err
+	}
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	webhook.ID = generateID()
+	webhook.Secret = "whsec_" + hex.EncodeToString(secret)
+	webhook.CreatedAt = time.Now().Format(time.RFC3339)
+	if err := s.repo.Save(webhook); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(webhook.ID)
+}
+
+// UpdateWebhook validates and replaces the URL and events of a stored webhook, keeping its secret
+func (s *WebhookServiceImpl) UpdateWebhook(webhook *model.Webhook) (*model.Webhook, error) {
+	if err := s.validateWebhook(webhook); err != nil {
+		return nil, err
+	}
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+
+	stored, err := s.repo.GetByID(webhook.ID)
+	if err != nil {
+		return nil, err
+	}
+	updated := *stored
+	updated.URL = webhook.URL
+	updated.Events = webhook.Events
+	if err := s.repo.Update(&updated); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(webhook.ID)
+}
+
+// DeleteWebhook removes a webhook and its delivery log
+func (s *WebhookServiceImpl) DeleteWebhook(id string) error {
+	return s.repo.Delete(id)
+}
+
+// ListDeliveries returns the logged deliveries of a webhook, newest first,
+// only those in a state unless state is empty
+func (s *WebhookServiceImpl) ListDeliveries(webhookID, state string) ([]*model.WebhookDelivery, error) {
+	if state != "" && !contains(deliveryStates, state) {
+		return nil, &domainservice.ValidationError{
+			Message: "invalid delivery filter",
+			Problems: []domainservice.Problem{{
+				Field:   "status",
+				Code:    domainservice.ProblemInvalidValue,
+				Message: fmt.Sprintf("%q is not one of %v", state, deliveryStates),
+			}},
+		}
+	}
+
+	deliveries, err := s.repo.ListDeliveries(webhookID)
+	if err != nil || state == "" {
+		return deliveries, err
+	}
+
+	matches := []*model.WebhookDelivery{}
+	for _, delivery := range deliveries {
+		if delivery.Status == state {
+			matches = append(matches, delivery)
+		}
+	}
+	return matches, nil
+}
+
+// Ping queues a webhook.ping delivery to a webhook and returns it
+func (s *WebhookServiceImpl) Ping(id string) (*model.WebhookDelivery, error) {
+	webhook, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.queue(webhook, newEvent(model.EventWebhookPing, webhook.Redacted()))
+}
+
+// Publish queues the delivery of an event to every webhook of owner subscribed to its type
+func (s *WebhookServiceImpl) Publish(owner, eventType string, data interface{}) {
+	webhooks, err := s.repo.List(owner)
+	if err != nil {
+		log.Printf("Failed to publish %s event: %v", eventType, err)
+		return
+	}
+
+	event := newEvent(eventType, data)
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(eventType) {
+			continue
+		}
+		if _, err := s.queue(webhook, event); err != nil {
+			log.Printf("Failed to queue %s event for webhook %s: %v", eventType, webhook.ID, err)
+		}
+	}
+}
+
+// Wait blocks until every queued delivery has succeeded or failed
+func (s *WebhookServiceImpl) Wait() {
+	s.pending.Wait()
+}
+
+// Close stops delivering events. Deliveries in flight or waiting for a retry
+// are given up and logged as failed, and Close returns once they are. Events
+// published afterwards are not delivered.
+func (s *WebhookServiceImpl) Close() {
+	s.mutex.Lock()
+	s.closed = true
+	s.mutex.Unlock()
+
+	s.stop()
+	s.pending.Wait()
+}
+
+// queue logs a pending delivery of an event to a webhook and delivers it in the background
+func (s *WebhookServiceImpl) queue(webhook *model.Webhook, event *model.WebhookEvent) (*model.WebhookDelivery, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event: %w", event.Type, err)
+	}
+
+	delivery := &model.WebhookDelivery{
+		ID:        generateID(),
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		EventType: event.Type,
+		Status:    model.DeliveryPending,
+		CreatedAt: event.CreatedAt,
+	}
+
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return nil, errWebhooksClosed
+	}
+	s.pending.Add(1)
+	s.mutex.Unlock()
+
+	if err := s.repo.SaveDelivery(delivery); err != nil {
+		s.pending.Done()
+		return nil, err
+	}
+
+	record := *delivery
+	go func() {
+		defer s.pending.Done()
+		s.deliver(webhook, &record, body)
+	}()
+
+	return delivery, nil
+}
+
+// deliver posts an event to a webhook until it is acknowledged or every attempt
+// has failed, waiting twice as long before every further retry, or until the
+// service is closed. Every attempt is logged on the delivery.
+func (s *WebhookServiceImpl) deliver(webhook *model.Webhook, delivery *model.WebhookDelivery, body []byte) {
+	delay := s.backoff
+	for delivery.Attempts < max(s.attempts, 1) {
+		delivery.Attempts++
+		delivery.ResponseStatus, delivery.Error = s.attempt(webhook, delivery, body)
+
+		switch {
+		case delivery.Error == "":
+			delivery.Status = model.DeliverySucceeded
+			delivery.NextAttemptAt = ""
+		case s.ctx.Err() != nil:
+			delivery.Status = model.DeliveryFailed
+			delivery.Error = deliveryStopped
+			delivery.NextAttemptAt = ""
+		case delivery.Attempts >= s.attempts:
+			delivery.Status = model.DeliveryFailed
+			delivery.NextAttemptAt = ""
+		default:
+			delivery.NextAttemptAt = time.Now().Add(delay).Format(time.RFC3339)
+		}
+
+		// The webhook may have been deleted meanwhile, which ends the delivery
+		if err := s.repo.SaveDelivery(delivery); err != nil || delivery.Status != model.DeliveryPending {
+			return
+		}
+
+		retry := time.NewTimer(delay)
+		select {
+		case <-retry.C:
+		case <-s.ctx.Done():
+			retry.Stop()
+			delivery.Status = model.DeliveryFailed
+			delivery.Error = deliveryStopped
+			delivery.NextAttemptAt = ""
+			s.repo.SaveDelivery(delivery)
+			return
+		}
+		delay *= 2
+	}
+}
+
+// attempt posts a signed event to a webhook once, returning the status of the
+// response and why the attempt failed, if it did
+func (s *WebhookServiceImpl) attempt(webhook *model.Webhook, delivery *model.WebhookDelivery, body []byte) (int, string) {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err.Error()
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-scaffold-webhooks")
+	req.Header.Set(HeaderWebhookEvent, delivery.EventType)
+	req.Header.Set(HeaderWebhookDelivery, delivery.ID)
+	req.Header.Set(HeaderWebhookTimestamp, timestamp)
+	req.Header.Set(HeaderWebhookSignature, SignWebhookPayload(webhook.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Sprintf("webhook responded with %s", resp.Status)
+	}
+	return resp.StatusCode, ""
+}
+
+// SignWebhookPayload returns the X-Webhook-Signature of a delivery: "sha256="
+// and the hex HMAC-SHA256, keyed with the secret of the webhook, of the
+// timestamp, a dot and the body
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newEvent returns a new event of a type about data
+func newEvent(eventType string, data interface{}) *model.WebhookEvent {
+	return &model.WebhookEvent{
+		ID:        generateID(),
+		Type:      eventType,
+		CreatedAt: time.Now().Format(time.RFC3339),
+		Data:      data,
+	}
+}
+
+// checkDial refuses connections to addresses webhooks may not be delivered to
+func (s *WebhookServiceImpl) checkDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || (!s.allowPrivate && privateIP(ip)) {
+		return fmt.Errorf("%w: %s", errPrivateWebhookTarget, host)
+	}
+	return nil
+}
+
+// validateWebhook checks a webhook, reporting every problem at once
+func (s *WebhookServiceImpl) validateWebhook(webhook *model.Webhook) error {
+	var problems []domainservice.Problem
+
+	target, err := url.Parse(webhook.URL)
+	switch {
+	case webhook.URL == "":
+		problems = append(problems, domainservice.Problem{
+			Field:   "url",
+			Code:    domainservice.ProblemRequired,
+			Message: "url is required",
+		})
+	case err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "":
+		problems = append(problems, domainservice.Problem{
+			Field:   "url",
+			Code:    domainservice.ProblemInvalidFormat,
+			Message: fmt.Sprintf("%q must be an absolute http or https URL", webhook.URL),
+		})
+	case !s.allowPrivate:
+		if problem := s.checkHost(target.Hostname()); problem != nil {
+			problems = append(problems, *problem)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, eventType := range webhook.Events {
+		field := fmt.Sprintf("events[%d]", i)
+		switch {
+		case !contains(model.WebhookEventTypes, eventType):
+			problems = append(problems, domainservice.Problem{
+				Field:   field,
+				Code:    domainservice.ProblemInvalidValue,
+				Message: fmt.Sprintf("%q is not an event type", eventType),
+			})
+		case seen[eventType]:
+			problems = append(problems, domainservice.Problem{
+				Field:   field,
+				Code:    domainservice.ProblemDuplicate,
+				Message: fmt.Sprintf("%q is listed more than once", eventType),
+			})
+		}
+		seen[eventType] = true
+	}
+
+	if len(problems) > 0 {
+		return &domainservice.ValidationError{
+			Message:  "invalid webhook",
+			Problems: problems,
+		}
+	}
+	return nil
+}
+
+// checkHost resolves the host of a webhook URL and returns the problem with
+// it if it cannot be resolved or has an address webhooks may not target
+func (s *WebhookServiceImpl) checkHost(host string) *domainservice.Problem {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookResolveTimeout)
+	defer cancel()
+
+	addrs, err := s.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return &domainservice.Problem{
+			Field:   "url",
+			Code:    domainservice.ProblemInvalidValue,
+			Message: fmt.Sprintf("host %q cannot be resolved", host),
+		}
+	}
+	for _, addr := range addrs {
+		if privateIP(addr.IP) {
+			return &domainservice.Problem{
+				Field:   "url",
+				Code:    domainservice.ProblemInvalidValue,
+				Message: fmt.Sprintf("host %q resolves to %s, a loopback, private or link-local address", host, addr.IP),
+			}
+		}
+	}
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598, which
+// net.IP.IsPrivate does not cover
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// privateIP reports whether an address is not reachable on the public
+// internet: loopback, private, carrier-grade NAT, link-local, multicast or unspecified
+func privateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || sharedAddressSpace.Contains(ip) || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
@@ -1,5 +1,14 @@
 package model
 
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+)
+
 // ScaffoldOptions represents the options for generating a scaffold
 type ScaffoldOptions struct {
 	// Basic options
@@ -10,20 +19,145 @@ type ScaffoldOptions struct {
 	LogFormat    string `json:"logFormat"`    // "json", "text"
 	ModulePath   string `json:"modulePath"`   // e.g. "github.com/username/project"
 
+	// Template version to generate from, e.g. a git tag; empty for the default version
+	TemplateVersion string `json:"templateVersion,omitempty"`
+
+	// Preset the options start from; the other fields override those of the preset
+	PresetID string `json:"presetId,omitempty"`
+
 	// Additional features
 	Features []string `json:"features"` // List of feature names to include
 
 	// Premium features
 	PremiumFeatures []string `json:"premiumFeatures"` // Premium features
+
+	// Client the scaffold is generated for, set by the API rather than the request
+	Owner string `json:"-"`
+
+	// Render anew even when a scaffold with the same options hash exists, set by the API
+	Fresh bool `json:"-"`
+}
+
+// Preset is a saved combination of scaffold options that generation requests can start from
+type Preset struct {
+	ID          string          `json:"id"`                    // Unique identifier
+	Name        string          `json:"name"`                  // Display name
+	Description string          `json:"description,omitempty"` // Short description
+	Options     ScaffoldOptions `json:"options"`               // Options of the preset; empty fields are left to the request
+	BuiltIn     bool            `json:"builtIn"`               // Shipped with the templates, so it cannot be changed
+	Owner       string          `json:"owner,omitempty"`       // Client that created the preset
+}
+
+// Apply returns the options of the preset overridden by every field set in
+// overrides. Lists are overridden when present, so an empty list clears them.
+func (p *Preset) Apply(overrides ScaffoldOptions) ScaffoldOptions {
+	options := p.Options
+	options.PresetID = p.ID
+	options.Owner = overrides.Owner
+	options.Fresh = overrides.Fresh
+
+	for _, field := range []struct {
+		target   *string
+		override string
+	}{
+		{&options.AppType, overrides.AppType},
+		{&options.DatabaseType, overrides.DatabaseType},
+		{&options.RouterType, overrides.RouterType},
+		{&options.ConfigType, overrides.ConfigType},
+		{&options.LogFormat, overrides.LogFormat},
+		{&options.ModulePath, overrides.ModulePath},
+		{&options.TemplateVersion, overrides.TemplateVersion},
+	} {
+		if field.override != "" {
+			*field.target = field.override
+		}
+	}
+
+	if overrides.Features != nil {
+		options.Features = overrides.Features
+	}
+	if overrides.PremiumFeatures != nil {
+		options.PremiumFeatures = overrides.PremiumFeatures
+	}
+
+	return options
 }
 
 // Template represents a template that can be used for code generation
 type Template struct {
-	ID          string `json:"id"`          // Unique identifier
+	ID          string `json:"id"`                 // Unique identifier
+	Name        string `json:"name"`               // Display name
+	Description string `json:"description"`        // Short description
+	Path        string `json:"path"`               // Filesystem path to template
+	Type        string `json:"type"`               // "api" only
+	Router      string `json:"router"`             // Router the template is built on, e.g. "echo" or "go-kit"
+	Version     string `json:"version,omitempty"`  // Template version, empty for unversioned templates
+	Revision    string `json:"revision,omitempty"` // Immutable revision the version resolved to, e.g. a git commit
+	Origin      string `json:"origin,omitempty"`   // Source the template was loaded from, e.g. "builtin" or "pack:acme"
+	Status      string `json:"status,omitempty"`   // Lifecycle status of uploaded templates, see TemplateStatus*
+
+	// FS holds the template tree; Path, BasePath and PartialsPath are slash-separated
+	// paths within it. Templates without an FS are read from the OS filesystem.
+	FS fs.FS `json:"-"`
+
+	// Shared layers composed with the template tree when rendering
+	BasePath     string `json:"basePath,omitempty"`     // Filesystem path to the base tree this template extends
+	PartialsPath string `json:"partialsPath,omitempty"` // Filesystem path to shared partials
+}
+
+// NewTemplateID returns the ID of the template of an application type and router, e.g. "api-go-kit"
+func NewTemplateID(appType, router string) string {
+	return appType + "-" + router
+}
+
+// ParseTemplateID splits a template ID into its application type and router.
+// Types never contain dashes, so everything after the first dash is the
+// router: "api-go-kit" is the go-kit router of the api type.
+func ParseTemplateID(id string) (appType, router string, err error) {
+	appType, router, ok := strings.Cut(id, "-")
+	if !ok || appType == "" || router == "" {
+		return "", "", fmt.Errorf("invalid template ID %q: expected <type>-<router>", id)
+	}
+	return appType, router, nil
+}
+
+// CanonicalVersion returns a semantic version in the canonical form template
+// versions are stored and compared in, without a "v" prefix, or an empty
+// string if it is not a semantic version. Versions are accepted with or
+// without the "v" prefix, so "v1.2" and "1.2.0" are both "1.2.0".
+func CanonicalVersion(version string) string {
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	if !semver.IsValid(version) {
+		return ""
+	}
+	return strings.TrimPrefix(semver.Canonical(version), "v")
+}
+
+// SameVersion reports whether two template versions are equal, comparing
+// semantic versions in canonical form
+func SameVersion(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ca, cb := CanonicalVersion(a), CanonicalVersion(b)
+	return ca != "" && ca == cb
+}
+
+// Lifecycle statuses of uploaded templates
+const (
+	TemplateStatusDraft      = "draft"      // Uploaded and validated, not yet available for generation
+	TemplateStatusPublished  = "published"  // Available for generation
+	TemplateStatusDeprecated = "deprecated" // Still available when requested by version, but not as the default
+)
+
+// TemplateUpload describes a template tree uploaded through the admin API
+type TemplateUpload struct {
+	ID          string `json:"id"`          // Template ID, e.g. "api-fiber"
+	Version     string `json:"version"`     // Semantic version of the upload
 	Name        string `json:"name"`        // Display name
 	Description string `json:"description"` // Short description
-	Path        string `json:"path"`        // Filesystem path to template
-	Type        string `json:"type"`        // "api" only
 }
 
 // GeneratedScaffold represents a generated scaffold
@@ -31,8 +165,116 @@ type GeneratedScaffold struct {
 	ID        string          `json:"id"`        // Unique identifier
 	Options   ScaffoldOptions `json:"options"`   // Options used to generate the scaffold
 	CreatedAt string          `json:"createdAt"` // Creation timestamp
-	FilePath  string          `json:"filePath"`  // Path to the generated ZIP file
+	FilePath  string          `json:"-"`         // Path to the generated ZIP file
 	Size      int64           `json:"size"`      // Size of the generated ZIP file in bytes
+	SHA256    string          `json:"sha256"`    // Hex-encoded SHA-256 of the generated ZIP file
+	Owner     string          `json:"owner"`     // Client the scaffold was generated for
+
+	// File name the archive is downloaded as, derived from the project name
+	ArchiveName string `json:"archiveName"`
+
+	// When the scaffold expires and its archive is removed, in RFC 3339; empty
+	// if it is kept until deleted
+	ExpiresAt string `json:"expiresAt,omitempty"`
+
+	// Detached ed25519 signature of the ZIP file, if the server signs archives
+	Signature    string `json:"signature,omitempty"`    // Base64-encoded signature
+	SigningKeyID string `json:"signingKeyId,omitempty"` // ID of the key that made it
+
+	// Hash of the options and template the archive was rendered from; scaffolds
+	// with the same hash share one archive
+	OptionsHash string `json:"optionsHash"`
+
+	// Template the scaffold was generated from, so it can be reproduced
+	TemplateID       string `json:"templateId"`                 // ID of the template
+	TemplateVersion  string `json:"templateVersion,omitempty"`  // Version of the template
+	TemplateRevision string `json:"templateRevision,omitempty"` // Revision the version resolved to
+}
+
+// Expired reports whether the scaffold has expired at a time
+func (s *GeneratedScaffold) Expired(now time.Time) bool {
+	if s.ExpiresAt == "" {
+		return false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, s.ExpiresAt)
+	return err == nil && !now.Before(expiresAt)
+}
+
+// PublicKey is a public key that archive signatures can be verified with
+type PublicKey struct {
+	ID        string `json:"id"`        // Key ID, the first 16 hex digits of the SHA-256 of the key
+	Algorithm string `json:"algorithm"` // Signature algorithm, "ed25519"
+	PublicKey string `json:"publicKey"` // Base64-encoded raw public key
+	PEM       string `json:"pem"`       // PEM-encoded PKIX public key
+}
+
+// DownloadLink is a signed link to the archive of a scaffold that expires
+type DownloadLink struct {
+	ScaffoldID string    // Scaffold whose archive the link downloads
+	ExpiresAt  time.Time // When the link stops working, truncated to the second
+	Nonce      string    // Random value identifying a one-time link; empty for links that can be reused
+	Signature  string    // Base64url-encoded HMAC-SHA256 over the other fields
+}
+
+// OneTime reports whether the link is invalidated by its first download
+func (l *DownloadLink) OneTime() bool {
+	return l.Nonce != ""
+}
+
+// ScaffoldFilter selects generated scaffolds; zero values match every scaffold
+type ScaffoldFilter struct {
+	Owner         string    // Client the scaffolds were generated for
+	RouterType    string    // Router of the scaffolds
+	DatabaseType  string    // Database of the scaffolds
+	Feature       string    // Regular or premium feature the scaffolds include
+	CreatedAfter  time.Time // Earliest creation time, inclusive
+	CreatedBefore time.Time // Latest creation time, exclusive
+
+	Offset int // Number of matching scaffolds to skip
+	Limit  int // Maximum number of scaffolds to return; 0 for all
+}
+
+// Matches reports whether a scaffold is selected by the filter, ignoring pagination
+func (f ScaffoldFilter) Matches(scaffold *GeneratedScaffold) bool {
+	options := scaffold.Options
+	if f.Owner != "" && scaffold.Owner != f.Owner ||
+		f.RouterType != "" && options.RouterType != f.RouterType ||
+		f.DatabaseType != "" && options.DatabaseType != f.DatabaseType {
+		return false
+	}
+
+	if f.Feature != "" && !containsString(options.Features, f.Feature) && !containsString(options.PremiumFeatures, f.Feature) {
+		return false
+	}
+
+	if !f.CreatedAfter.IsZero() || !f.CreatedBefore.IsZero() {
+		created, err := time.Parse(time.RFC3339, scaffold.CreatedAt)
+		if err != nil ||
+			!f.CreatedAfter.IsZero() && created.Before(f.CreatedAfter) ||
+			!f.CreatedBefore.IsZero() && !created.Before(f.CreatedBefore) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ScaffoldPage is a page of generated scaffolds
+type ScaffoldPage struct {
+	Scaffolds []*GeneratedScaffold `json:"scaffolds"` // Scaffolds on the page, newest first
+	Total     int                  `json:"total"`     // Number of scaffolds matching the filter
+	Offset    int                  `json:"offset"`    // Number of matching scaffolds before the page
+	Limit     int                  `json:"limit"`     // Maximum number of scaffolds on the page
 }
 
 // Feature represents a feature that can be included in a scaffold
@@ -41,4 +283,126 @@ type Feature struct {
 	Name        string `json:"name"`        // Display name
 	Description string `json:"description"` // Short description
 	IsPremium   bool   `json:"isPremium"`   // Whether this is a premium feature
+
+	// Whether the feature needs a database, i.e. a databaseType other than "none"
+	RequiresDatabase bool `json:"requiresDatabase,omitempty"`
+}
+
+// Plan is a subscription plan, granting entitlements to premium features
+type Plan struct {
+	ID       string   `json:"id"`               // Unique identifier
+	Name     string   `json:"name"`             // Display name
+	Features []string `json:"features"`         // IDs of the premium features included in the plan
+	Limits   *Limits  `json:"limits,omitempty"` // Limits of the plan, replacing the server defaults
+}
+
+// Entitles reports whether the plan includes a premium feature; a nil plan includes none
+func (p *Plan) Entitles(featureID string) bool {
+	if p == nil {
+		return false
+	}
+	for _, id := range p.Features {
+		if id == featureID {
+			return true
+		}
+	}
+	return false
+}
+
+// Limits are the rate limits and quotas of an API client; zero values are unlimited
+type Limits struct {
+	RequestsPerMinute int   `json:"requestsPerMinute,omitempty"` // Sustained rate of API requests
+	Burst             int   `json:"burst,omitempty"`             // Requests that can be made at once
+	DailyGenerations  int   `json:"dailyGenerations,omitempty"`  // Scaffolds generated per UTC day
+	StorageBytes      int64 `json:"storageBytes,omitempty"`      // Bytes of generated scaffolds stored at once
+}
+
+// Usage reports how much of its limits an API client has used
+type Usage struct {
+	Client             string    `json:"client"`             // API key name or IP address of the client
+	Plan               string    `json:"plan,omitempty"`     // ID of the plan of the client
+	Limits             Limits    `json:"limits"`             // Limits of the client
+	RequestsRemaining  int       `json:"requestsRemaining"`  // Requests that can be made at once right now
+	GenerationsToday   int       `json:"generationsToday"`   // Scaffolds generated since midnight UTC
+	GenerationsResetAt time.Time `json:"generationsResetAt"` // When the daily generation count resets
+	StorageBytes       int64     `json:"storageBytes"`       // Bytes of generated scaffolds stored
+}
+
+// APIKey identifies a caller of the API and the plan it is on. Only the
+// SHA-256 hash of the key is stored.
+type APIKey struct {
+	Name   string `json:"name"`   // Owner of the key, e.g. a team
+	PlanID string `json:"plan"`   // ID of the plan
+	SHA256 string `json:"sha256"` // Hex-encoded SHA-256 hash of the key
+
+	Plan *Plan `json:"-"` // Plan the key is on, resolved from PlanID
+}
+
+// Dependency represents a pinned Go module from the curated dependency catalog
+type Dependency struct {
+	Name     string `json:"name"`     // Catalog name used by templates, e.g. "echo"
+	Path     string `json:"path"`     // Module path, e.g. "github.com/labstack/echo/v4"
+	Version  string `json:"version"`  // Pinned module version
+	Sum      string `json:"sum"`      // go.sum hash of the module content
+	GoModSum string `json:"goModSum"` // go.sum hash of the module's go.mod file
+
+	// Modules needed to build the packages templates import from the module,
+	// as "path version", and their go.sum lines
+	Requires []string `json:"requires,omitempty"`
+	Sums     []string `json:"sums,omitempty"`
+}
+
+// Rules reported by the template linter
+const (
+	LintRuleParseError      = "parse-error"      // The template does not parse
+	LintRuleUnknownField    = "unknown-field"    // A field that is not part of the template data
+	LintRuleUnknownFeature  = "unknown-feature"  // A feature ID that is not an available feature
+	LintRuleUnreachableFile = "unreachable-file" // A file whose output is behind conditions that are never true
+)
+
+// Severities of lint findings
+const (
+	LintSeverityError   = "error"
+	LintSeverityWarning = "warning"
+)
+
+// LintFinding is a problem found by the template linter
+type LintFinding struct {
+	Rule     string `json:"rule"`           // Rule that was violated, see LintRule*
+	Severity string `json:"severity"`       // "error" or "warning"
+	Path     string `json:"path"`           // Slash-separated path of the file within the linted directory
+	Line     int    `json:"line,omitempty"` // Line of the problem, 0 when it applies to the whole file
+	Message  string `json:"message"`        // Description of the problem
+}
+
+// JSONSchema is a JSON Schema (draft 2020-12) document, limited to the
+// keywords used to describe scaffold options and API types
+type JSONSchema struct {
+	Schema      string `json:"$schema,omitempty"`     // Dialect of the root schema
+	ID          string `json:"$id,omitempty"`         // URI of the root schema
+	Ref         string `json:"$ref,omitempty"`        // Reference to another schema
+	Title       string `json:"title,omitempty"`       // Short name, also used in validation messages
+	Description string `json:"description,omitempty"` // Longer explanation
+
+	Type   string   `json:"type,omitempty"`   // "object", "array", "string", "integer", "number" or "boolean"
+	Enum   []string `json:"enum,omitempty"`   // Allowed values
+	Const  string   `json:"const,omitempty"`  // The only allowed value
+	Format string   `json:"format,omitempty"` // Format of a string, e.g. "go-module-path"
+
+	Default string `json:"default,omitempty"` // Value used when the property is left out
+
+	Properties map[string]*JSONSchema `json:"properties,omitempty"` // Schemas of object properties
+	Required   []string               `json:"required,omitempty"`   // Properties that must be present
+
+	// Schema of the values of properties not listed in Properties
+	AdditionalProperties *JSONSchema `json:"additionalProperties,omitempty"`
+
+	Items       *JSONSchema `json:"items,omitempty"`       // Schema of every array item
+	UniqueItems bool        `json:"uniqueItems,omitempty"` // Whether array items must be distinct
+	Contains    *JSONSchema `json:"contains,omitempty"`    // Schema at least one array item must match
+
+	AllOf []*JSONSchema `json:"allOf,omitempty"` // Schemas that must all match
+	If    *JSONSchema   `json:"if,omitempty"`    // Condition selecting whether Then or Else applies
+	Then  *JSONSchema   `json:"then,omitempty"`  // Schema that must match when If matches
+	Else  *JSONSchema   `json:"else,omitempty"`  // Schema that must match when If does not match
 }
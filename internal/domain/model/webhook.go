@@ -0,0 +1,78 @@
+package model
+
+// Types of the events delivered to webhooks
+const (
+	EventScaffoldGenerated = "scaffold.generated" // A scaffold was generated; the data is the scaffold
+	EventScaffoldFailed    = "scaffold.failed"    // Generating a scaffold failed; the data is a ScaffoldFailure
+	EventScaffoldExpired   = "scaffold.expired"   // A scaffold expired; the data is the scaffold
+	EventWebhookPing       = "webhook.ping"       // A test delivery requested by the owner; the data is the webhook
+)
+
+// WebhookEventTypes lists the event types webhooks can subscribe to; pings
+// are delivered regardless
+var WebhookEventTypes = []string{EventScaffoldGenerated, EventScaffoldFailed, EventScaffoldExpired}
+
+// States of a webhook delivery
+const (
+	DeliveryPending   = "pending"   // Waiting for its first or next attempt
+	DeliverySucceeded = "succeeded" // Acknowledged with a 2xx response
+	DeliveryFailed    = "failed"    // Every attempt failed
+)
+
+// Webhook subscribes a client to events, which are posted to its URL as signed JSON
+type Webhook struct {
+	ID        string   `json:"id"`               // Unique identifier
+	URL       string   `json:"url"`              // HTTP or HTTPS URL events are posted to
+	Events    []string `json:"events"`           // Event types delivered; every type when empty
+	Secret    string   `json:"secret,omitempty"` // Key of the HMAC-SHA256 signature of deliveries, only returned on creation
+	Owner     string   `json:"owner"`            // Client the webhook belongs to
+	CreatedAt string   `json:"createdAt"`        // Creation timestamp
+}
+
+// Subscribes reports whether the webhook delivers events of a type
+func (w *Webhook) Subscribes(eventType string) bool {
+	if eventType == EventWebhookPing || len(w.Events) == 0 {
+		return true
+	}
+	for _, subscribed := range w.Events {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Redacted returns a copy of the webhook without its secret
+func (w *Webhook) Redacted() *Webhook {
+	redacted := *w
+	redacted.Secret = ""
+	return &redacted
+}
+
+// WebhookEvent is the JSON body posted to webhooks
+type WebhookEvent struct {
+	ID        string      `json:"id"`        // Unique identifier, the same for every webhook the event is delivered to
+	Type      string      `json:"type"`      // One of the Event* types
+	CreatedAt string      `json:"createdAt"` // When the event happened
+	Data      interface{} `json:"data"`      // Subject of the event, depending on its type
+}
+
+// ScaffoldFailure is the data of a scaffold.failed event
+type ScaffoldFailure struct {
+	Options ScaffoldOptions `json:"options"` // Options the generation was requested with
+	Error   string          `json:"error"`   // Why it failed
+}
+
+// WebhookDelivery records the delivery of an event to a webhook
+type WebhookDelivery struct {
+	ID             string `json:"id"`                       // Unique identifier, sent as X-Webhook-Delivery
+	WebhookID      string `json:"webhookId"`                // Webhook the event is delivered to
+	EventID        string `json:"eventId"`                  // ID of the event
+	EventType      string `json:"eventType"`                // Type of the event
+	Status         string `json:"status"`                   // One of the Delivery* states
+	Attempts       int    `json:"attempts"`                 // Attempts made so far
+	ResponseStatus int    `json:"responseStatus,omitempty"` // HTTP status of the last attempt, if it got a response
+	Error          string `json:"error,omitempty"`          // Why the last attempt failed
+	CreatedAt      string `json:"createdAt"`                // When the event was queued
+	NextAttemptAt  string `json:"nextAttemptAt,omitempty"`  // When the next attempt is due while pending
+}
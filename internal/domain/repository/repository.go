@@ -1,6 +1,22 @@
 package repository
 
-import "github.com/regiwitanto/go-scaffold/internal/domain/model"
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+)
+
+var (
+	// ErrNotFound is returned when a requested item does not exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrAlreadyExists is returned when storing an item that already exists
+	ErrAlreadyExists = errors.New("already exists")
+
+	// ErrExpired is returned when a requested item existed but has expired
+	ErrExpired = errors.New("expired")
+)
 
 // TemplateRepository defines the interface for template storage
 type TemplateRepository interface {
@@ -14,14 +30,117 @@ type TemplateRepository interface {
 	GetByType(templateType string) ([]*model.Template, error)
 }
 
+// VersionedTemplateRepository is implemented by template repositories that
+// can serve more than one version of a template
+type VersionedTemplateRepository interface {
+	TemplateRepository
+
+	// GetVersion returns a specific version of a template
+	GetVersion(id, version string) (*model.Template, error)
+
+	// GetVersions returns every available version of a template, newest first
+	GetVersions(id string) ([]*model.Template, error)
+}
+
+// ManagedTemplateRepository is a template repository that templates can be
+// uploaded to and managed through. Its read methods only serve published and
+// deprecated templates; drafts are only visible through List. Semantic
+// versions name the same stored version with or without a "v" prefix.
+type ManagedTemplateRepository interface {
+	VersionedTemplateRepository
+
+	// List returns every stored template version, including drafts
+	List() ([]*model.Template, error)
+
+	// Save stores a new template version with the files of its tree
+	Save(tmpl *model.Template, files fs.FS) error
+
+	// SetStatus changes the status of a stored template version
+	SetStatus(id, version, status string) (*model.Template, error)
+
+	// Delete removes a stored template version
+	Delete(id, version string) error
+}
+
 // ScaffoldRepository defines the interface for scaffold storage
 type ScaffoldRepository interface {
-	// Save stores a generated scaffold
+	// Save stores a generated scaffold, adding a reference to its archive
 	Save(scaffold *model.GeneratedScaffold) error
 
 	// GetByID returns a generated scaffold by ID
 	GetByID(id string) (*model.GeneratedScaffold, error)
 
-	// Delete removes a generated scaffold
+	// List returns a page of the generated scaffolds matching a filter, newest
+	// first, and the number of scaffolds matching it
+	List(filter model.ScaffoldFilter) ([]*model.GeneratedScaffold, int, error)
+
+	// FindByHash returns the newest scaffold with an options hash
+	FindByHash(hash string) (*model.GeneratedScaffold, error)
+
+	// Delete removes a generated scaffold and returns the number of scaffolds
+	// still referencing its archive
+	Delete(id string) (int, error)
+
+	// Expire removes a generated scaffold like Delete, but remembers it so
+	// GetByID reports it as expired rather than not found
+	Expire(id string) (int, error)
+}
+
+// DependencyRepository defines the interface for the curated dependency catalog
+type DependencyRepository interface {
+	// GetAll returns all pinned dependencies
+	GetAll() ([]*model.Dependency, error)
+
+	// GetByName returns a pinned dependency by its catalog name
+	GetByName(name string) (*model.Dependency, error)
+}
+
+// PresetRepository defines the interface for scaffold presets. Built-in
+// presets are listed and found like the others but cannot be changed.
+type PresetRepository interface {
+	// GetAll returns every preset sorted by ID
+	GetAll() ([]*model.Preset, error)
+
+	// GetByID returns a preset by ID
+	GetByID(id string) (*model.Preset, error)
+
+	// Save stores a new preset
+	Save(preset *model.Preset) error
+
+	// Update replaces a stored preset
+	Update(preset *model.Preset) error
+
+	// Delete removes a stored preset
+	Delete(id string) error
+}
+
+// WebhookRepository defines the interface for webhook subscriptions and the
+// log of their deliveries
+type WebhookRepository interface {
+	// List returns the webhooks of an owner, oldest first
+	List(owner string) ([]*model.Webhook, error)
+
+	// GetByID returns a webhook by ID
+	GetByID(id string) (*model.Webhook, error)
+
+	// Save stores a new webhook
+	Save(webhook *model.Webhook) error
+
+	// Update replaces a stored webhook
+	Update(webhook *model.Webhook) error
+
+	// Delete removes a webhook and its deliveries
 	Delete(id string) error
+
+	// SaveDelivery stores a delivery, replacing an earlier record with its ID
+	SaveDelivery(delivery *model.WebhookDelivery) error
+
+	// ListDeliveries returns the logged deliveries of a webhook, newest first
+	ListDeliveries(webhookID string) ([]*model.WebhookDelivery, error)
+}
+
+// APIKeyRepository defines the interface for the API keys callers authenticate with
+type APIKeyRepository interface {
+	// GetByKey returns the API key matching a secret key, with its plan resolved
+	GetByKey(key string) (*model.APIKey, error)
 }
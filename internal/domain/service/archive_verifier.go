@@ -0,0 +1,9 @@
+package service
+
+// ArchiveVerifier defines the interface for checking downloaded scaffold archives
+type ArchiveVerifier interface {
+	// VerifyArchive checks the files of an archive against its checksum
+	// manifest and, when a PEM-encoded public key is given, the detached
+	// signature of the archive. The error lists every problem found.
+	VerifyArchive(archive, signature, publicKeyPEM []byte) error
+}
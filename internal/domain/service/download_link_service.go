@@ -0,0 +1,25 @@
+package service
+
+import (
+	"time"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+)
+
+// DownloadLinkService defines the interface for signed, expiring links to scaffold archives
+type DownloadLinkService interface {
+	// IssueLink signs a link to the archive of a scaffold that expires after
+	// ttl, or the default lifetime when ttl is zero. One-time links are
+	// invalidated by their first download. A *ValidationError reports a ttl
+	// that is negative or longer than the server allows.
+	IssueLink(scaffoldID string, ttl time.Duration, oneTime bool) (*model.DownloadLink, error)
+
+	// CheckLink checks a link presented for a download without using it up.
+	// It returns ErrLinkInvalid, ErrLinkExpired or ErrLinkUsed when the link
+	// cannot be used.
+	CheckLink(link *model.DownloadLink) error
+
+	// RedeemLink checks a link like CheckLink and uses up one-time links. It
+	// is called once the archive is about to be sent.
+	RedeemLink(link *model.DownloadLink) error
+}
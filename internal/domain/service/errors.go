@@ -0,0 +1,70 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Errors reported for download links that cannot be used
+var (
+	ErrLinkInvalid = errors.New("download link is invalid")
+	ErrLinkExpired = errors.New("download link has expired")
+	ErrLinkUsed    = errors.New("download link has already been used")
+)
+
+// Codes identifying the kind of a validation problem
+const (
+	ProblemRequired        = "required"         // A required value is missing
+	ProblemInvalidValue    = "invalid_value"    // A value is not one of the allowed values
+	ProblemInvalidFormat   = "invalid_format"   // A value is not well-formed
+	ProblemDuplicate       = "duplicate"        // A value is repeated in a list
+	ProblemInvalidArchive  = "invalid_archive"  // An uploaded archive cannot be extracted
+	ProblemInvalidTemplate = "invalid_template" // A template file fails to parse or render
+	ProblemNotEntitled     = "not_entitled"     // A premium feature is not included in the plan of the caller
+)
+
+// Problem is a single validation problem
+type Problem struct {
+	Field   string `json:"field,omitempty"` // JSON path of the offending field, e.g. "features[1]"
+	Code    string `json:"code"`            // Kind of problem, one of the Problem* codes
+	Message string `json:"message"`         // Human-readable description
+}
+
+// String returns the problem prefixed with its field
+func (p Problem) String() string {
+	if p.Field == "" {
+		return p.Message
+	}
+	return p.Field + ": " + p.Message
+}
+
+// ValidationError reports every problem found while validating input
+type ValidationError struct {
+	Message  string    // Summary of what failed validation
+	Problems []Problem // Individual problems
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 0 {
+		return e.Message
+	}
+
+	problems := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		problems[i] = p.String()
+	}
+	return e.Message + ": " + strings.Join(problems, "; ")
+}
+
+// QuotaError reports a request rejected by a rate limit or quota of its client
+type QuotaError struct {
+	Message    string        // Which limit was reached
+	RetryAfter time.Duration // How long until the request can succeed, zero if unknown
+}
+
+// Error implements the error interface
+func (e *QuotaError) Error() string {
+	return e.Message
+}
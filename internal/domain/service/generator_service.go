@@ -1,21 +1,48 @@
 package service
 
-import "github.com/regiwitanto/go-scaffold/internal/domain/model"
+import (
+	"time"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+)
 
 // GeneratorService defines the interface for the scaffold generator service
 type GeneratorService interface {
 	// GenerateScaffold generates a scaffold based on the provided options
 	GenerateScaffold(options model.ScaffoldOptions) (*model.GeneratedScaffold, error)
 
+	// ResolveOptions returns options with the preset they request applied and
+	// defaults filled in, which generate the same scaffold whatever the preset becomes
+	ResolveOptions(options model.ScaffoldOptions) (model.ScaffoldOptions, error)
+
 	// GetScaffold returns a generated scaffold by ID
 	GetScaffold(id string) (*model.GeneratedScaffold, error)
 
+	// ListScaffolds returns a page of the generated scaffolds matching a filter, newest first
+	ListScaffolds(filter model.ScaffoldFilter) (*model.ScaffoldPage, error)
+
+	// DeleteScaffold removes a generated scaffold, and its archive unless other scaffolds share it
+	DeleteScaffold(id string) error
+
+	// ExpireScaffolds removes every scaffold that has expired at now, and its
+	// archive unless other scaffolds share it, and returns the removed scaffolds
+	ExpireScaffolds(now time.Time) ([]*model.GeneratedScaffold, error)
+
+	// GetPublicKeys returns the public keys that archive signatures can be verified with
+	GetPublicKeys() ([]*model.PublicKey, error)
+
 	// GetAllTemplates returns all available templates
 	GetAllTemplates() ([]*model.Template, error)
 
 	// GetTemplatesByType returns templates of a specific type
 	GetTemplatesByType(templateType string) ([]*model.Template, error)
 
+	// GetTemplateVersions returns every available version of a template, newest first
+	GetTemplateVersions(id string) ([]*model.Template, error)
+
 	// GetAvailableFeatures returns all available features
 	GetAvailableFeatures() ([]*model.Feature, error)
+
+	// GetOptionsSchema returns the JSON Schema that scaffold options are validated against
+	GetOptionsSchema() (*model.JSONSchema, error)
 }
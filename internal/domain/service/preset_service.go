@@ -0,0 +1,21 @@
+package service
+
+import "github.com/regiwitanto/go-scaffold/internal/domain/model"
+
+// PresetService defines the interface for managing scaffold presets
+type PresetService interface {
+	// ListPresets returns every preset, built-in ones included, sorted by ID
+	ListPresets() ([]*model.Preset, error)
+
+	// GetPreset returns a preset by ID
+	GetPreset(id string) (*model.Preset, error)
+
+	// CreatePreset validates and stores a new preset
+	CreatePreset(preset *model.Preset) (*model.Preset, error)
+
+	// UpdatePreset validates and replaces a stored preset
+	UpdatePreset(preset *model.Preset) (*model.Preset, error)
+
+	// DeletePreset removes a stored preset
+	DeletePreset(id string) error
+}
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"io"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+)
+
+// TemplateAdminService defines the interface for managing uploaded templates
+type TemplateAdminService interface {
+	// UploadTemplate validates a ZIP or tar archive of a template tree and stores it as a draft
+	UploadTemplate(upload model.TemplateUpload, archive io.Reader) (*model.Template, error)
+
+	// ListTemplates returns every uploaded template version, including drafts
+	ListTemplates() ([]*model.Template, error)
+
+	// PublishTemplate makes an uploaded template version available for generation
+	PublishTemplate(id, version string) (*model.Template, error)
+
+	// DeprecateTemplate keeps a template version available by version only
+	DeprecateTemplate(id, version string) (*model.Template, error)
+
+	// DeleteTemplate removes an uploaded template version
+	DeleteTemplate(id, version string) error
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"io/fs"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+)
+
+// TemplateLinter defines the interface for checking template trees without rendering them
+type TemplateLinter interface {
+	// LintTemplates checks every template file of fsys and returns the problems found
+	LintTemplates(fsys fs.FS) ([]model.LintFinding, error)
+}
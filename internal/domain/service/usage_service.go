@@ -0,0 +1,29 @@
+package service
+
+import "github.com/regiwitanto/go-scaffold/internal/domain/model"
+
+// UsageService defines the interface for the rate limits and quotas of API
+// clients. Clients are identified by their API key or IP address and limited
+// by their plan, or by the server defaults when plan is nil or sets no limits.
+type UsageService interface {
+	// Allow takes a request from the rate limit of a client, returning a *QuotaError when none is left
+	Allow(client string, plan *model.Plan) error
+
+	// ReserveGeneration counts a generation against the daily and storage
+	// quotas of a client, returning a *QuotaError when one is used up
+	ReserveGeneration(client string, plan *model.Plan) error
+
+	// CompleteGeneration records the scaffold of a reserved generation, or
+	// releases the reservation when scaffold is nil. It returns a *QuotaError
+	// and releases the reservation when the scaffold does not fit in the
+	// storage quota of the client. An archive shared by several scaffolds of
+	// the client is counted once.
+	CompleteGeneration(client string, scaffold *model.GeneratedScaffold) error
+
+	// ReleaseStorage frees the storage of a deleted scaffold of a client,
+	// once no other scaffold of the client shares its archive
+	ReleaseStorage(client string, scaffold *model.GeneratedScaffold)
+
+	// GetUsage returns how much of its limits a client has used
+	GetUsage(client string, plan *model.Plan) *model.Usage
+}
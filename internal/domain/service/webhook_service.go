@@ -0,0 +1,35 @@
+package service
+
+import "github.com/regiwitanto/go-scaffold/internal/domain/model"
+
+// WebhookService defines the interface for managing webhook subscriptions and
+// inspecting their deliveries
+type WebhookService interface {
+	// ListWebhooks returns the webhooks of an owner, oldest first
+	ListWebhooks(owner string) ([]*model.Webhook, error)
+
+	// GetWebhook returns a webhook by ID
+	GetWebhook(id string) (*model.Webhook, error)
+
+	// CreateWebhook validates and stores a new webhook, giving it an ID and a signing secret
+	CreateWebhook(webhook *model.Webhook) (*model.Webhook, error)
+
+	// UpdateWebhook validates and replaces the URL and events of a stored webhook, keeping its secret
+	UpdateWebhook(webhook *model.Webhook) (*model.Webhook, error)
+
+	// DeleteWebhook removes a webhook and its delivery log
+	DeleteWebhook(id string) error
+
+	// ListDeliveries returns the logged deliveries of a webhook, newest first,
+	// only those in a state unless state is empty
+	ListDeliveries(webhookID, state string) ([]*model.WebhookDelivery, error)
+
+	// Ping queues a webhook.ping delivery to a webhook and returns it
+	Ping(id string) (*model.WebhookDelivery, error)
+}
+
+// EventPublisher defines the interface for announcing events to the webhooks of a client
+type EventPublisher interface {
+	// Publish queues the delivery of an event to every webhook of owner subscribed to its type
+	Publish(owner, eventType string, data interface{})
+}
@@ -0,0 +1,105 @@
+package apikey
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+)
+
+// keysFile is the on-disk layout of the API keys file
+type keysFile struct {
+	Plans map[string]*model.Plan `json:"plans"`
+	Keys  []*model.APIKey        `json:"keys"`
+}
+
+// FileRepository implements the APIKeyRepository interface using a JSON file
+// of plans and the SHA-256 hashes of the keys on them
+type FileRepository struct {
+	keys map[string]*model.APIKey
+}
+
+// NewFileRepository creates a new API key repository from a keys file
+func NewFileRepository(path string) (repository.APIKeyRepository, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys: %w", err)
+	}
+
+	return parseKeys(data)
+}
+
+// NewEmptyRepository creates an API key repository without keys, rejecting every key
+func NewEmptyRepository() repository.APIKeyRepository {
+	return &FileRepository{
+		keys: make(map[string]*model.APIKey),
+	}
+}
+
+// parseKeys creates an API key repository from the contents of a keys file
+func parseKeys(data []byte) (repository.APIKeyRepository, error) {
+	var file keysFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse API keys: %w", err)
+	}
+
+	for id, plan := range file.Plans {
+		if plan == nil {
+			return nil, fmt.Errorf("plan %s has no definition", id)
+		}
+		plan.ID = id
+		if plan.Name == "" {
+			plan.Name = id
+		}
+	}
+
+	keys := make(map[string]*model.APIKey, len(file.Keys))
+	names := make(map[string]bool, len(file.Keys))
+	for i, key := range file.Keys {
+		if key == nil || key.Name == "" {
+			return nil, fmt.Errorf("API key %d must declare a name", i)
+		}
+
+		// Usage is tracked by key name, so names must tell keys apart
+		if names[key.Name] {
+			return nil, fmt.Errorf("API key name %s: %w", key.Name, repository.ErrAlreadyExists)
+		}
+		names[key.Name] = true
+
+		key.SHA256 = strings.ToLower(key.SHA256)
+		if sum, err := hex.DecodeString(key.SHA256); err != nil || len(sum) != sha256.Size {
+			return nil, fmt.Errorf("API key %s must declare the hex-encoded SHA-256 hash of the key", key.Name)
+		}
+		if _, ok := keys[key.SHA256]; ok {
+			return nil, fmt.Errorf("API key %s: %w", key.Name, repository.ErrAlreadyExists)
+		}
+
+		key.Plan = file.Plans[key.PlanID]
+		if key.Plan == nil {
+			return nil, fmt.Errorf("API key %s is on unknown plan %q", key.Name, key.PlanID)
+		}
+		keys[key.SHA256] = key
+	}
+
+	return &FileRepository{
+		keys: keys,
+	}, nil
+}
+
+// GetByKey returns the API key matching a secret key, with its plan resolved
+func (r *FileRepository) GetByKey(key string) (*model.APIKey, error) {
+	sum := sha256.Sum256([]byte(key))
+
+	// Keys are looked up by hash, so lookup time does not depend on how much of a key matches
+	apiKey, ok := r.keys[hex.EncodeToString(sum[:])]
+	if !ok {
+		return nil, fmt.Errorf("API key: %w", repository.ErrNotFound)
+	}
+
+	return apiKey, nil
+}
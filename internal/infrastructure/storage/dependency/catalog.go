@@ -0,0 +1,104 @@
+package dependency
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+)
+
+// catalogFile is the on-disk layout of the dependency catalog
+type catalogFile struct {
+	Dependencies map[string]*model.Dependency `json:"dependencies"`
+}
+
+// CatalogRepository implements the DependencyRepository interface
+// using a JSON catalog file as storage
+type CatalogRepository struct {
+	dependencies map[string]*model.Dependency
+}
+
+// NewCatalogRepository creates a new dependency repository from a catalog file
+func NewCatalogRepository(path string) (repository.DependencyRepository, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dependency catalog: %w", err)
+	}
+
+	return parseCatalog(data)
+}
+
+// NewCatalogRepositoryFS creates a new dependency repository from a catalog file in fsys
+func NewCatalogRepositoryFS(fsys fs.FS, name string) (repository.DependencyRepository, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dependency catalog: %w", err)
+	}
+
+	return parseCatalog(data)
+}
+
+// parseCatalog creates a dependency repository from the contents of a catalog file
+func parseCatalog(data []byte) (repository.DependencyRepository, error) {
+	var catalog catalogFile
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse dependency catalog: %w", err)
+	}
+
+	for name, dep := range catalog.Dependencies {
+		if dep == nil {
+			return nil, fmt.Errorf("dependency %s has no definition", name)
+		}
+		dep.Name = name
+
+		if dep.Path == "" || dep.Version == "" {
+			return nil, fmt.Errorf("dependency %s must declare a path and version", name)
+		}
+		if !strings.HasPrefix(dep.Sum, "h1:") || !strings.HasPrefix(dep.GoModSum, "h1:") {
+			return nil, fmt.Errorf("dependency %s must declare h1: checksums for the module and its go.mod", name)
+		}
+		for _, require := range dep.Requires {
+			if len(strings.Fields(require)) != 2 {
+				return nil, fmt.Errorf("dependency %s: requirement %q must be a module path and version", name, require)
+			}
+		}
+		for _, sum := range dep.Sums {
+			if fields := strings.Fields(sum); len(fields) != 3 || !strings.HasPrefix(fields[2], "h1:") {
+				return nil, fmt.Errorf("dependency %s: %q is not a go.sum line", name, sum)
+			}
+		}
+	}
+
+	return &CatalogRepository{
+		dependencies: catalog.Dependencies,
+	}, nil
+}
+
+// GetAll returns all pinned dependencies sorted by name
+func (r *CatalogRepository) GetAll() ([]*model.Dependency, error) {
+	deps := make([]*model.Dependency, 0, len(r.dependencies))
+	for _, dep := range r.dependencies {
+		deps = append(deps, dep)
+	}
+
+	sort.Slice(deps, func(i, j int) bool {
+		return deps[i].Name < deps[j].Name
+	})
+
+	return deps, nil
+}
+
+// GetByName returns a pinned dependency by its catalog name
+func (r *CatalogRepository) GetByName(name string) (*model.Dependency, error) {
+	dep, ok := r.dependencies[name]
+	if !ok {
+		return nil, fmt.Errorf("dependency not found in catalog: %s", name)
+	}
+
+	return dep, nil
+}
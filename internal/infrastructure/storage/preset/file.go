@@ -0,0 +1,217 @@
+package preset
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+)
+
+// presetsFile is the on-disk layout of built-in and stored presets
+type presetsFile struct {
+	Presets map[string]*model.Preset `json:"presets"`
+}
+
+// FileRepository implements the PresetRepository interface using a JSON
+// file as storage, layered over read-only built-in presets
+type FileRepository struct {
+	path    string
+	builtIn map[string]*model.Preset
+	stored  map[string]*model.Preset
+	mutex   sync.RWMutex
+}
+
+// NewFileRepository creates a preset repository storing presets in the file
+// at path, which is created on the first save
+func NewFileRepository(path string, builtIn []*model.Preset) (*FileRepository, error) {
+	r := &FileRepository{
+		path:    path,
+		builtIn: make(map[string]*model.Preset),
+		stored:  make(map[string]*model.Preset),
+	}
+	for _, preset := range builtIn {
+		r.builtIn[preset.ID] = preset
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read presets: %w", err)
+	}
+
+	stored, err := parsePresets(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, preset := range stored {
+		preset.BuiltIn = false
+		r.stored[preset.ID] = preset
+	}
+
+	return r, nil
+}
+
+// LoadBuiltInFS reads the built-in presets from a presets file in fsys
+func LoadBuiltInFS(fsys fs.FS, name string) ([]*model.Preset, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read built-in presets: %w", err)
+	}
+
+	presets, err := parsePresets(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, preset := range presets {
+		preset.BuiltIn = true
+	}
+
+	return presets, nil
+}
+
+// parsePresets returns the presets of the contents of a presets file
+func parsePresets(data []byte) ([]*model.Preset, error) {
+	var file presetsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse presets: %w", err)
+	}
+
+	presets := make([]*model.Preset, 0, len(file.Presets))
+	for id, preset := range file.Presets {
+		if preset == nil {
+			return nil, fmt.Errorf("preset %s has no definition", id)
+		}
+		preset.ID = id
+		if preset.Name == "" {
+			preset.Name = id
+		}
+		presets = append(presets, preset)
+	}
+
+	return presets, nil
+}
+
+// GetAll returns every preset sorted by ID
+func (r *FileRepository) GetAll() ([]*model.Preset, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	presets := make([]*model.Preset, 0, len(r.builtIn)+len(r.stored))
+	for _, preset := range r.builtIn {
+		presets = append(presets, preset)
+	}
+	for _, preset := range r.stored {
+		presets = append(presets, preset)
+	}
+
+	sort.Slice(presets, func(i, j int) bool {
+		return presets[i].ID < presets[j].ID
+	})
+
+	return presets, nil
+}
+
+// GetByID returns a preset by ID
+func (r *FileRepository) GetByID(id string) (*model.Preset, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if preset, ok := r.builtIn[id]; ok {
+		return preset, nil
+	}
+	if preset, ok := r.stored[id]; ok {
+		return preset, nil
+	}
+
+	return nil, fmt.Errorf("preset %s: %w", id, repository.ErrNotFound)
+}
+
+// Save stores a new preset
+func (r *FileRepository) Save(preset *model.Preset) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.builtIn[preset.ID]; ok {
+		return fmt.Errorf("preset %s: %w", preset.ID, repository.ErrAlreadyExists)
+	}
+	if _, ok := r.stored[preset.ID]; ok {
+		return fmt.Errorf("preset %s: %w", preset.ID, repository.ErrAlreadyExists)
+	}
+
+	return r.write(preset.ID, preset)
+}
+
+// Update replaces a stored preset
+func (r *FileRepository) Update(preset *model.Preset) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.stored[preset.ID]; !ok {
+		return fmt.Errorf("preset %s: %w", preset.ID, repository.ErrNotFound)
+	}
+
+	return r.write(preset.ID, preset)
+}
+
+// Delete removes a stored preset
+func (r *FileRepository) Delete(id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.stored[id]; !ok {
+		return fmt.Errorf("preset %s: %w", id, repository.ErrNotFound)
+	}
+
+	return r.write(id, nil)
+}
+
+// write stores preset under id, or removes it when preset is nil, and writes
+// the stored presets to disk. The caller must hold the mutex.
+func (r *FileRepository) write(id string, preset *model.Preset) error {
+	presets := make(map[string]*model.Preset, len(r.stored)+1)
+	for storedID, stored := range r.stored {
+		presets[storedID] = stored
+	}
+	if preset == nil {
+		delete(presets, id)
+	} else {
+		presets[id] = preset
+	}
+
+	data, err := json.MarshalIndent(presetsFile{Presets: presets}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode presets: %w", err)
+	}
+
+	// Write a temporary file first so a failed write leaves the old presets intact
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create presets directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".presets-")
+	if err != nil {
+		return fmt.Errorf("failed to write presets: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write presets: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write presets: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("failed to write presets: %w", err)
+	}
+
+	r.stored = presets
+	return nil
+}
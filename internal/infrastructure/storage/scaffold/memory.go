@@ -1,8 +1,10 @@
 package scaffold
 
 import (
-	"errors"
+	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/regiwitanto/go-scaffold/internal/domain/model"
 	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
@@ -11,23 +13,32 @@ import (
 // InMemoryRepository implements the ScaffoldRepository interface
 // using in-memory storage
 type InMemoryRepository struct {
-	scaffolds map[string]*model.GeneratedScaffold
-	mutex     sync.RWMutex
+	scaffolds  map[string]*model.GeneratedScaffold
+	references map[string]int      // Number of scaffolds per archive path
+	expired    map[string]struct{} // IDs of expired scaffolds
+	mutex      sync.RWMutex
 }
 
 // NewInMemoryRepository creates a new in-memory scaffold repository
 func NewInMemoryRepository() repository.ScaffoldRepository {
 	return &InMemoryRepository{
-		scaffolds: make(map[string]*model.GeneratedScaffold),
+		scaffolds:  make(map[string]*model.GeneratedScaffold),
+		references: make(map[string]int),
+		expired:    make(map[string]struct{}),
 	}
 }
 
-// Save stores a generated scaffold
+// Save stores a generated scaffold, adding a reference to its archive
 func (r *InMemoryRepository) Save(scaffold *model.GeneratedScaffold) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
+	if previous, ok := r.scaffolds[scaffold.ID]; ok {
+		r.release(previous)
+	}
+
 	r.scaffolds[scaffold.ID] = scaffold
+	r.references[scaffold.FilePath]++
 	return nil
 }
 
@@ -37,22 +48,110 @@ func (r *InMemoryRepository) GetByID(id string) (*model.GeneratedScaffold, error
 	defer r.mutex.RUnlock()
 
 	scaffold, ok := r.scaffolds[id]
+	if _, expired := r.expired[id]; !ok && expired {
+		return nil, fmt.Errorf("scaffold %s: %w", id, repository.ErrExpired)
+	}
 	if !ok {
-		return nil, errors.New("scaffold not found")
+		return nil, fmt.Errorf("scaffold %s: %w", id, repository.ErrNotFound)
 	}
 
 	return scaffold, nil
 }
 
-// Delete removes a generated scaffold
-func (r *InMemoryRepository) Delete(id string) error {
+// List returns a page of the generated scaffolds matching a filter, newest
+// first, and the number of scaffolds matching it
+func (r *InMemoryRepository) List(filter model.ScaffoldFilter) ([]*model.GeneratedScaffold, int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var matches []*model.GeneratedScaffold
+	for _, scaffold := range r.scaffolds {
+		if filter.Matches(scaffold) {
+			matches = append(matches, scaffold)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return newer(matches[i], matches[j])
+	})
+
+	total := len(matches)
+	start := min(max(filter.Offset, 0), total)
+	end := total
+	if filter.Limit > 0 {
+		end = min(start+filter.Limit, total)
+	}
+
+	return matches[start:end], total, nil
+}
+
+// FindByHash returns the newest scaffold with an options hash
+func (r *InMemoryRepository) FindByHash(hash string) (*model.GeneratedScaffold, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var found *model.GeneratedScaffold
+	for _, scaffold := range r.scaffolds {
+		if scaffold.OptionsHash == hash && (found == nil || newer(scaffold, found)) {
+			found = scaffold
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("scaffold with hash %s: %w", hash, repository.ErrNotFound)
+	}
+
+	return found, nil
+}
+
+// Delete removes a generated scaffold and returns the number of scaffolds
+// still referencing its archive
+func (r *InMemoryRepository) Delete(id string) (int, error) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, ok := r.scaffolds[id]; !ok {
-		return errors.New("scaffold not found")
+	scaffold, ok := r.scaffolds[id]
+	if !ok {
+		return 0, fmt.Errorf("scaffold %s: %w", id, repository.ErrNotFound)
 	}
 
 	delete(r.scaffolds, id)
-	return nil
+	return r.release(scaffold), nil
+}
+
+// Expire removes a generated scaffold like Delete, but remembers it so GetByID
+// reports it as expired rather than not found
+func (r *InMemoryRepository) Expire(id string) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	scaffold, ok := r.scaffolds[id]
+	if !ok {
+		return 0, fmt.Errorf("scaffold %s: %w", id, repository.ErrNotFound)
+	}
+
+	delete(r.scaffolds, id)
+	r.expired[id] = struct{}{}
+	return r.release(scaffold), nil
+}
+
+// release removes the reference of a scaffold to its archive and returns the
+// number of references left. The caller must hold the mutex.
+func (r *InMemoryRepository) release(scaffold *model.GeneratedScaffold) int {
+	r.references[scaffold.FilePath]--
+	left := r.references[scaffold.FilePath]
+	if left <= 0 {
+		delete(r.references, scaffold.FilePath)
+	}
+	return max(left, 0)
+}
+
+// newer reports whether scaffold a was created after b, breaking ties by ID.
+// Creation times are compared as times, since they may have different offsets.
+func newer(a, b *model.GeneratedScaffold) bool {
+	timeA, _ := time.Parse(time.RFC3339, a.CreatedAt)
+	timeB, _ := time.Parse(time.RFC3339, b.CreatedAt)
+	if !timeA.Equal(timeB) {
+		return timeA.After(timeB)
+	}
+	return a.ID < b.ID
 }
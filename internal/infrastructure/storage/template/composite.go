@@ -0,0 +1,235 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+)
+
+const (
+	// OriginBuiltin is the origin of templates shipped with go-scaffold
+	OriginBuiltin = "builtin"
+	// OriginUpload is the origin of templates uploaded through the admin API
+	OriginUpload = "upload"
+	// originDirPrefix prefixes the origin of templates from an extra directory
+	originDirPrefix = "dir:"
+	// originPackPrefix prefixes the origin of templates from a team template pack
+	originPackPrefix = "pack:"
+	// originGitPrefix prefixes the origin of templates from a git repository
+	originGitPrefix = "git:"
+)
+
+// Source is a template repository together with the origin reported for its templates
+type Source struct {
+	Origin     string
+	Repository repository.TemplateRepository
+}
+
+// Duplicate describes a template ID provided by more than one source
+type Duplicate struct {
+	ID       string   `json:"id"`       // Template ID
+	Origin   string   `json:"origin"`   // Origin of the template that is served
+	Shadowed []string `json:"shadowed"` // Origins of the templates it hides, highest priority first
+}
+
+// CompositeRepository implements the VersionedTemplateRepository interface on top of
+// several sources. Sources are ordered by priority: when more than one source
+// provides a template ID, the template of the earliest source is served.
+type CompositeRepository struct {
+	sources []Source
+}
+
+// NewCompositeRepository creates a template repository from sources ordered highest priority first
+func NewCompositeRepository(sources ...Source) *CompositeRepository {
+	return &CompositeRepository{
+		sources: sources,
+	}
+}
+
+// DirectorySource creates a source for an extra template directory
+func DirectorySource(path string) (Source, error) {
+	repo, err := NewFilesystemRepository(path)
+	if err != nil {
+		return Source{}, err
+	}
+
+	return Source{Origin: originDirPrefix + path, Repository: repo}, nil
+}
+
+// PackSources creates a source for every template pack below dir. Each
+// subdirectory is a pack laid out like the built-in template tree; packs are
+// ordered by name.
+func PackSources(dir string) ([]Source, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template pack directory: %w", err)
+	}
+
+	var sources []Source
+	for _, entry := range entries {
+		if !entry.IsDir() || isHiddenDir(entry.Name()) {
+			continue
+		}
+
+		repo, err := NewFilesystemRepository(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, Source{Origin: originPackPrefix + entry.Name(), Repository: repo})
+	}
+
+	return sources, nil
+}
+
+// Refresh refreshes every source that can be, such as git repositories, and
+// returns the errors of those that fail
+func (r *CompositeRepository) Refresh() error {
+	var errs []error
+	for _, source := range r.sources {
+		refresher, ok := source.Repository.(interface{ Refresh() error })
+		if !ok {
+			continue
+		}
+		if err := refresher.Refresh(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.Origin, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetAll returns all available templates, one per ID
+func (r *CompositeRepository) GetAll() ([]*model.Template, error) {
+	return r.merge(func(repo repository.TemplateRepository) ([]*model.Template, error) {
+		return repo.GetAll()
+	})
+}
+
+// GetByID returns a template by ID from the highest-priority source providing it
+func (r *CompositeRepository) GetByID(id string) (*model.Template, error) {
+	for _, source := range r.sources {
+		tmpl, err := source.Repository.GetByID(id)
+		if err != nil || tmpl == nil {
+			continue
+		}
+		return withOrigin(tmpl, source.Origin), nil
+	}
+
+	return nil, fmt.Errorf("template not found: %s", id)
+}
+
+// GetByType returns templates of a specific type, one per ID
+func (r *CompositeRepository) GetByType(templateType string) ([]*model.Template, error) {
+	return r.merge(func(repo repository.TemplateRepository) ([]*model.Template, error) {
+		return repo.GetByType(templateType)
+	})
+}
+
+// GetVersion returns a specific version of a template from the highest-priority
+// source providing it. Sources that are not versioned match on the version of
+// their template.
+func (r *CompositeRepository) GetVersion(id, version string) (*model.Template, error) {
+	for _, source := range r.sources {
+		var tmpl *model.Template
+		var err error
+		if versioned, ok := source.Repository.(repository.VersionedTemplateRepository); ok {
+			tmpl, err = versioned.GetVersion(id, version)
+		} else {
+			tmpl, err = source.Repository.GetByID(id)
+		}
+		if err != nil || tmpl == nil || !model.SameVersion(tmpl.Version, version) {
+			continue
+		}
+		return withOrigin(tmpl, source.Origin), nil
+	}
+
+	return nil, fmt.Errorf("template %s has no version %s", id, version)
+}
+
+// GetVersions returns every version of a template across sources, newest
+// first. A version provided by more than one source is served from the
+// highest-priority one.
+func (r *CompositeRepository) GetVersions(id string) ([]*model.Template, error) {
+	var templates []*model.Template
+	for _, source := range r.sources {
+		var found []*model.Template
+		if versioned, ok := source.Repository.(repository.VersionedTemplateRepository); ok {
+			found, _ = versioned.GetVersions(id)
+		} else if tmpl, err := source.Repository.GetByID(id); err == nil && tmpl != nil {
+			found = []*model.Template{tmpl}
+		}
+
+		for _, tmpl := range found {
+			if !hasVersion(templates, tmpl.Version) {
+				templates = append(templates, withOrigin(tmpl, source.Origin))
+			}
+		}
+	}
+
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("template not found: %s", id)
+	}
+
+	sortVersions(templates)
+	return templates, nil
+}
+
+// Duplicates returns the template IDs provided by more than one source, sorted by ID
+func (r *CompositeRepository) Duplicates() ([]Duplicate, error) {
+	origins := make(map[string][]string)
+	for _, source := range r.sources {
+		templates, err := source.Repository.GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list templates of %s: %w", source.Origin, err)
+		}
+		for _, tmpl := range templates {
+			origins[tmpl.ID] = append(origins[tmpl.ID], source.Origin)
+		}
+	}
+
+	var duplicates []Duplicate
+	for id, found := range origins {
+		if len(found) > 1 {
+			duplicates = append(duplicates, Duplicate{ID: id, Origin: found[0], Shadowed: found[1:]})
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		return duplicates[i].ID < duplicates[j].ID
+	})
+
+	return duplicates, nil
+}
+
+// merge lists templates from every source, keeping the highest-priority template of each ID
+func (r *CompositeRepository) merge(list func(repository.TemplateRepository) ([]*model.Template, error)) ([]*model.Template, error) {
+	var templates []*model.Template
+	seen := make(map[string]bool)
+
+	for _, source := range r.sources {
+		found, err := list(source.Repository)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list templates of %s: %w", source.Origin, err)
+		}
+
+		for _, tmpl := range found {
+			if seen[tmpl.ID] {
+				continue
+			}
+			seen[tmpl.ID] = true
+			templates = append(templates, withOrigin(tmpl, source.Origin))
+		}
+	}
+
+	return templates, nil
+}
+
+// withOrigin returns a copy of a template reporting the given origin
+func withOrigin(tmpl *model.Template, origin string) *model.Template {
+	tagged := *tmpl
+	tagged.Origin = origin
+	return &tagged
+}
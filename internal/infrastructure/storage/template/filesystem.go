@@ -1,31 +1,64 @@
 package template
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
-	"path/filepath"
+	"path"
 	"strings"
 
 	"github.com/regiwitanto/go-scaffold/internal/domain/model"
 	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
 )
 
-// FilesystemRepository implements the TemplateRepository interface
-// using the filesystem as storage
+const (
+	// baseDirName is the directory inside a type directory holding the base tree
+	// every template of that type extends
+	baseDirName = "_base"
+
+	// sharedDirName is the directory holding the partials of every type directory.
+	// Snapshots use the snapshot of it for their version, e.g. "shared@1.0.0".
+	sharedDirName = "shared"
+
+	// manifestFileName is the file inside a type directory declaring its version
+	manifestFileName = "template.json"
+
+	// versionSeparator separates the type from the version in the name of a
+	// snapshot of an older version of a type directory, e.g. "api@1.0.0"
+	versionSeparator = "@"
+)
+
+// manifest is the on-disk layout of a type directory's template.json
+type manifest struct {
+	Version string `json:"version"`
+}
+
+// FilesystemRepository implements the VersionedTemplateRepository interface
+// using a filesystem as storage. The current templates of a type live in the
+// type directory, and older versions are kept side by side as snapshots of the
+// whole type directory named <type>@<version>, composed with the partials of
+// shared@<version>.
 type FilesystemRepository struct {
-	basePath string
+	fsys fs.FS
 }
 
-// NewFilesystemRepository creates a new filesystem-based template repository
-func NewFilesystemRepository(basePath string) (repository.TemplateRepository, error) {
+// NewFilesystemRepository creates a new template repository backed by a directory on disk
+func NewFilesystemRepository(basePath string) (repository.VersionedTemplateRepository, error) {
 	// Ensure the base path exists
 	if _, err := os.Stat(basePath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("template directory does not exist: %s", basePath)
 	}
 
+	return NewFSRepository(os.DirFS(basePath)), nil
+}
+
+// NewFSRepository creates a new template repository backed by fsys, such as an embed.FS
+func NewFSRepository(fsys fs.FS) repository.VersionedTemplateRepository {
 	return &FilesystemRepository{
-		basePath: basePath,
-	}, nil
+		fsys: fsys,
+	}
 }
 
 // GetAll returns all available templates
@@ -42,67 +75,195 @@ func (r *FilesystemRepository) GetAll() ([]*model.Template, error) {
 	return templates, nil
 }
 
-// GetByID returns a template by ID
+// GetByID returns the current version of a template by ID
 func (r *FilesystemRepository) GetByID(id string) (*model.Template, error) {
-	// Parse the ID to get the type and router
-	parts := strings.Split(id, "-")
-	if len(parts) < 2 {
-		return nil, fmt.Errorf("invalid template ID: %s", id)
+	templateType, router, err := parseID(id)
+	if err != nil {
+		return nil, err
 	}
 
-	templateType := parts[0]
-	router := parts[1]
-
-	// Check if the template directory exists
-	templatePath := filepath.Join(r.basePath, templateType, router)
-	if _, err := os.Stat(templatePath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("template not found: %s", id)
+	version, err := r.readVersion(templateType)
+	if err != nil {
+		return nil, err
 	}
 
-	// Create a template object
-	tmpl := &model.Template{
-		ID:          id,
-		Name:        fmt.Sprintf("%s with %s router", strings.Title(templateType), strings.Title(router)),
-		Description: fmt.Sprintf("A %s application using the %s router", templateType, router),
-		Path:        templatePath,
-		Type:        templateType,
+	tmpl := r.loadTemplate(templateType, templateType, router, version)
+	if tmpl == nil {
+		return nil, fmt.Errorf("template not found: %s", id)
 	}
 
 	return tmpl, nil
 }
 
-// GetByType returns templates of a specific type
+// GetByType returns the current version of the templates of a specific type
 func (r *FilesystemRepository) GetByType(templateType string) ([]*model.Template, error) {
 	var templates []*model.Template
 
-	// Check if the type directory exists
-	typeDir := filepath.Join(r.basePath, templateType)
-	if _, err := os.Stat(typeDir); os.IsNotExist(err) {
+	// Read router directories
+	entries, err := fs.ReadDir(r.fsys, templateType)
+	if errors.Is(err, fs.ErrNotExist) {
 		return nil, nil // No templates of this type
 	}
-
-	// Read router directories
-	entries, err := os.ReadDir(typeDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read template directory: %w", err)
 	}
 
+	version, err := r.readVersion(templateType)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		// Directories starting with "_" or "." hold shared layers, not templates
+		if entry.IsDir() && !isHiddenDir(entry.Name()) {
+			templates = append(templates, r.loadTemplate(templateType, templateType, entry.Name(), version))
+		}
+	}
+
+	return templates, nil
+}
+
+// GetVersions returns every version of a template, newest first
+func (r *FilesystemRepository) GetVersions(id string) ([]*model.Template, error) {
+	templateType, router, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var templates []*model.Template
+	if tmpl, err := r.GetByID(id); err == nil {
+		templates = append(templates, tmpl)
+	}
+
+	// Snapshots of older versions
+	entries, err := fs.ReadDir(r.fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template directory: %w", err)
+	}
 	for _, entry := range entries {
-		if entry.IsDir() {
-			router := entry.Name()
-			id := templateType + "-" + router
-
-			tmpl := &model.Template{
-				ID:          id,
-				Name:        fmt.Sprintf("%s with %s router", strings.Title(templateType), strings.Title(router)),
-				Description: fmt.Sprintf("A %s application using the %s router", templateType, router),
-				Path:        filepath.Join(typeDir, router),
-				Type:        templateType,
-			}
+		name := entry.Name()
+		if !entry.IsDir() || !strings.HasPrefix(name, templateType+versionSeparator) {
+			continue
+		}
 
+		version := strings.TrimPrefix(name, templateType+versionSeparator)
+		if model.CanonicalVersion(version) == "" {
+			return nil, fmt.Errorf("invalid template version directory: %s", name)
+		}
+		if tmpl := r.loadTemplate(name, templateType, router, version); tmpl != nil && !hasVersion(templates, version) {
 			templates = append(templates, tmpl)
 		}
 	}
 
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("template not found: %s", id)
+	}
+
+	sortVersions(templates)
 	return templates, nil
 }
+
+// GetVersion returns a specific version of a template
+func (r *FilesystemRepository) GetVersion(id, version string) (*model.Template, error) {
+	templates, err := r.GetVersions(id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tmpl := range templates {
+		if model.SameVersion(tmpl.Version, version) {
+			return tmpl, nil
+		}
+	}
+
+	return nil, fmt.Errorf("template %s has no version %s", id, version)
+}
+
+// loadTemplate returns the template of a router in a type directory, or nil if it does not exist
+func (r *FilesystemRepository) loadTemplate(typeDir, templateType, router, version string) *model.Template {
+	templatePath := path.Join(typeDir, router)
+	if info, err := fs.Stat(r.fsys, templatePath); err != nil || !info.IsDir() {
+		return nil
+	}
+
+	tmpl := newTemplate(templateType, router, templatePath, version)
+	tmpl.FS = r.fsys
+	r.attachSharedLayers(tmpl, typeDir)
+
+	return tmpl
+}
+
+// newTemplate describes the template of a router at a path
+func newTemplate(templateType, router, templatePath, version string) *model.Template {
+	return &model.Template{
+		ID:          model.NewTemplateID(templateType, router),
+		Name:        fmt.Sprintf("%s with %s router", strings.Title(templateType), strings.Title(router)),
+		Description: fmt.Sprintf("A %s application using the %s router", templateType, router),
+		Path:        templatePath,
+		Type:        templateType,
+		Router:      router,
+		Version:     version,
+	}
+}
+
+// readVersion returns the version declared by the manifest of a type directory,
+// or an empty version if it has none
+func (r *FilesystemRepository) readVersion(typeDir string) (string, error) {
+	data, err := fs.ReadFile(r.fsys, path.Join(typeDir, manifestFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read template manifest: %w", err)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return "", fmt.Errorf("failed to parse template manifest %s: %w", path.Join(typeDir, manifestFileName), err)
+	}
+	if m.Version != "" && model.CanonicalVersion(m.Version) == "" {
+		return "", fmt.Errorf("template manifest %s has invalid version %q", path.Join(typeDir, manifestFileName), m.Version)
+	}
+
+	return m.Version, nil
+}
+
+// attachSharedLayers sets the base tree and partials a template is composed
+// with. A snapshot only uses the partials of its own version, so that it keeps
+// rendering alike when the current partials change.
+func (r *FilesystemRepository) attachSharedLayers(tmpl *model.Template, typeDir string) {
+	basePath := path.Join(typeDir, baseDirName)
+	if info, err := fs.Stat(r.fsys, basePath); err == nil && info.IsDir() {
+		tmpl.BasePath = basePath
+	}
+
+	sharedDir := sharedDirName
+	if typeDir != tmpl.Type {
+		sharedDir += versionSeparator + tmpl.Version
+	}
+	partialsPath := path.Join(sharedDir, "partials")
+	if info, err := fs.Stat(r.fsys, partialsPath); err == nil && info.IsDir() {
+		tmpl.PartialsPath = partialsPath
+	}
+}
+
+// parseID splits a template ID into its type and router directory
+func parseID(id string) (string, string, error) {
+	templateType, router, err := model.ParseTemplateID(id)
+	if err != nil {
+		return "", "", err
+	}
+
+	// IDs must not reach snapshots, shared layers or other directories
+	if strings.Contains(templateType, versionSeparator) || isHiddenDir(router) ||
+		strings.Contains(router, versionSeparator) || strings.ContainsAny(router, `/\`) {
+		return "", "", fmt.Errorf("template not found: %s", id)
+	}
+
+	return templateType, router, nil
+}
+
+// isHiddenDir reports whether a directory is a shared layer rather than a template
+func isHiddenDir(name string) bool {
+	return strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".")
+}
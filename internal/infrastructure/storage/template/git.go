@@ -0,0 +1,376 @@
+package template
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+)
+
+// GitRepository implements the VersionedTemplateRepository interface on top
+// of a git repository laid out like the built-in template tree. Every ref is
+// pinned to the commit it resolves to when first used, and each commit is
+// checked out once into the cache, so a version keeps producing the same
+// templates until Refresh is called. Tags that are semantic versions are
+// listed as the versions of a template. The remote is only fetched when the
+// repository is created and by Refresh, never while serving a version, and
+// Refresh removes the checkouts no ref is pinned to anymore.
+type GitRepository struct {
+	url        string
+	defaultRef string
+	cacheDir   string // Cache directory of this repository
+	gitDir     string // Bare clone inside cacheDir
+
+	fetchMutex sync.Mutex // Serializes fetches without blocking pinned versions
+
+	mutex    sync.Mutex
+	versions map[string]*gitVersion // Pinned versions keyed by ref
+	retired  map[string]bool        // Checked out commits unpinned by the last refresh
+}
+
+var (
+	// commitPattern matches a full commit ID
+	commitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+	// branchPattern matches slash-separated branch and tag names made of
+	// letters, digits, ".", "_", "+" and "-", each starting with a letter or digit
+	branchPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+-]*(/[A-Za-z0-9][A-Za-z0-9._+-]*)*$`)
+)
+
+// gitVersion is a ref pinned to a commit checked out in the cache
+type gitVersion struct {
+	ref    string
+	commit string
+	repo   repository.TemplateRepository
+}
+
+// NewGitRepository clones url into cacheDir, or fetches it when already
+// cached, and pins defaultRef, which may be a branch, tag or commit
+func NewGitRepository(url, defaultRef, cacheDir string) (*GitRepository, error) {
+	sum := sha256.Sum256([]byte(url))
+	repoCacheDir := filepath.Join(cacheDir, hex.EncodeToString(sum[:8]))
+
+	r := &GitRepository{
+		url:        url,
+		defaultRef: defaultRef,
+		cacheDir:   repoCacheDir,
+		gitDir:     filepath.Join(repoCacheDir, "repo.git"),
+		versions:   make(map[string]*gitVersion),
+	}
+
+	if _, err := os.Stat(r.gitDir); os.IsNotExist(err) {
+		if err := os.MkdirAll(repoCacheDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create git template cache: %w", err)
+		}
+		if _, err := runGit("", "clone", "--bare", "--quiet", "--", url, r.gitDir); err != nil {
+			return nil, fmt.Errorf("failed to clone template repository %s: %w", url, err)
+		}
+	} else if err := r.fetch(); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.version(defaultRef); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GitSource creates a source for a git template repository
+func GitSource(url, defaultRef, cacheDir string) (Source, error) {
+	repo, err := NewGitRepository(url, defaultRef, cacheDir)
+	if err != nil {
+		return Source{}, err
+	}
+
+	return Source{Origin: originGitPrefix + url, Repository: repo}, nil
+}
+
+// Refresh fetches the remote and re-pins every pinned ref, so that branches
+// move to their latest commit and refs deleted from the remote are dropped.
+// Versions keep being served from their pinned commits while the remote is
+// fetched. Checkouts of commits no ref is pinned to are removed by the refresh
+// after the one that unpinned them, so that scaffolds still rendering from
+// them can finish.
+func (r *GitRepository) Refresh() error {
+	r.fetchMutex.Lock()
+	defer r.fetchMutex.Unlock()
+
+	if err := r.fetch(); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous := r.versions
+	r.versions = make(map[string]*gitVersion)
+	if _, err := r.pin(r.defaultRef); err != nil {
+		return err
+	}
+	for ref := range previous {
+		// Refs deleted from the remote no longer resolve and are dropped
+		if _, ok := r.versions[ref]; !ok {
+			r.pin(ref)
+		}
+	}
+
+	return r.removeStaleCheckouts()
+}
+
+// removeStaleCheckouts removes the checkouts retired by the previous refresh
+// that no ref has been pinned to since, and retires the other checkouts no ref
+// is pinned to. The caller must hold the mutex.
+func (r *GitRepository) removeStaleCheckouts() error {
+	pinned := make(map[string]bool, len(r.versions))
+	for _, v := range r.versions {
+		pinned[v.commit] = true
+	}
+
+	commitsDir := filepath.Join(r.cacheDir, "commits")
+	entries, err := os.ReadDir(commitsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to list template checkouts: %w", err)
+	}
+
+	retired := make(map[string]bool)
+	for _, entry := range entries {
+		commit := entry.Name()
+		switch {
+		case pinned[commit]:
+		case r.retired[commit]:
+			if err := os.RemoveAll(filepath.Join(commitsDir, commit)); err != nil {
+				return fmt.Errorf("failed to remove template checkout %s: %w", commit, err)
+			}
+		default:
+			retired[commit] = true
+		}
+	}
+	r.retired = retired
+
+	// Forget the worktrees whose checkout is gone
+	if _, err := runGit(r.gitDir, "worktree", "prune"); err != nil {
+		return fmt.Errorf("failed to prune template checkouts: %w", err)
+	}
+	return nil
+}
+
+// GetAll returns all templates of the default ref
+func (r *GitRepository) GetAll() ([]*model.Template, error) {
+	v, err := r.version(r.defaultRef)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := v.repo.GetAll()
+	return v.tag(templates), err
+}
+
+// GetByID returns a template of the default ref by ID
+func (r *GitRepository) GetByID(id string) (*model.Template, error) {
+	return r.GetVersion(id, r.defaultRef)
+}
+
+// GetByType returns templates of the default ref of a specific type
+func (r *GitRepository) GetByType(templateType string) ([]*model.Template, error) {
+	v, err := r.version(r.defaultRef)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := v.repo.GetByType(templateType)
+	return v.tag(templates), err
+}
+
+// GetVersion returns a template at the given ref
+func (r *GitRepository) GetVersion(id, version string) (*model.Template, error) {
+	v, err := r.version(version)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := v.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return v.tag([]*model.Template{tmpl})[0], nil
+}
+
+// GetVersions returns the template at every tag that is a semantic version,
+// newest first. Tags are listed without checking them out, so the templates
+// have no FS; GetVersion checks a version out.
+func (r *GitRepository) GetVersions(id string) ([]*model.Template, error) {
+	templateType, router, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Annotated tags report the commit they point to as the peeled object
+	out, err := runGit(r.gitDir, "for-each-ref", "--format=%(refname:strip=2) %(objectname) %(*objectname)", "refs/tags")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list template versions: %w", err)
+	}
+
+	var tags, commits []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || model.CanonicalVersion(fields[0]) == "" {
+			continue
+		}
+		tags = append(tags, fields[0])
+		commits = append(commits, fields[len(fields)-1])
+	}
+
+	// Tags that predate the template do not provide it
+	templatePath := path.Join(templateType, router)
+	var query strings.Builder
+	for _, commit := range commits {
+		fmt.Fprintf(&query, "%s:%s\n", commit, templatePath)
+	}
+	out, err = runGitInput(r.gitDir, query.String(), "cat-file", "--batch-check=%(objecttype)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list template versions: %w", err)
+	}
+
+	var templates []*model.Template
+	for i, objectType := range strings.Split(strings.TrimSpace(out), "\n") {
+		if i >= len(tags) || objectType != "tree" {
+			continue
+		}
+		tmpl := newTemplate(templateType, router, templatePath, tags[i])
+		tmpl.Revision = commits[i]
+		templates = append(templates, tmpl)
+	}
+
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("template %s has no tagged versions", id)
+	}
+
+	sortVersions(templates)
+	return templates, nil
+}
+
+// version returns the pinned version of a ref, pinning it on first use
+func (r *GitRepository) version(ref string) (*gitVersion, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if v, ok := r.versions[ref]; ok {
+		return v, nil
+	}
+	return r.pin(ref)
+}
+
+// pin resolves a ref to a commit in the local clone and checks the commit
+// out into the cache. Refs the clone does not know yet are not found until
+// Refresh fetches them. The caller must hold the mutex.
+func (r *GitRepository) pin(ref string) (*gitVersion, error) {
+	if !validRef(ref) {
+		return nil, fmt.Errorf("invalid template version: %q", ref)
+	}
+
+	commit, err := r.resolve(ref)
+	if err != nil {
+		return nil, fmt.Errorf("template version %s not found in %s", ref, r.url)
+	}
+
+	checkoutDir := filepath.Join(r.cacheDir, "commits", commit)
+	if _, err := os.Stat(checkoutDir); os.IsNotExist(err) {
+		if _, err := runGit(r.gitDir, "worktree", "add", "--detach", "--quiet", checkoutDir, commit); err != nil {
+			return nil, fmt.Errorf("failed to check out template version %s: %w", ref, err)
+		}
+	}
+
+	repo, err := NewFilesystemRepository(checkoutDir)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &gitVersion{ref: ref, commit: commit, repo: repo}
+	r.versions[ref] = v
+	return v, nil
+}
+
+// resolve returns the commit a tag, branch or full commit ID resolves to in the local clone
+func (r *GitRepository) resolve(ref string) (string, error) {
+	candidates := []string{"refs/tags/" + ref, "refs/heads/" + ref}
+	if commitPattern.MatchString(ref) {
+		candidates = []string{ref}
+	}
+
+	var err error
+	for _, candidate := range candidates {
+		var out string
+		if out, err = runGit(r.gitDir, "rev-parse", "--verify", "--quiet", candidate+"^{commit}"); err == nil {
+			return strings.TrimSpace(out), nil
+		}
+	}
+	return "", err
+}
+
+// validRef reports whether a ref is a semantic version tag, a branch name or a full commit ID
+func validRef(ref string) bool {
+	return branchPattern.MatchString(ref) && !strings.Contains(ref, "..") && !strings.HasSuffix(ref, ".lock")
+}
+
+// fetch updates every branch and tag of the local clone from the remote
+func (r *GitRepository) fetch() error {
+	_, err := runGit(r.gitDir, "fetch", "--quiet", "--prune", "--force", "origin",
+		"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*")
+	if err != nil {
+		return fmt.Errorf("failed to fetch template repository %s: %w", r.url, err)
+	}
+	return nil
+}
+
+// tag returns copies of templates reporting the ref as version and the commit as revision
+func (v *gitVersion) tag(templates []*model.Template) []*model.Template {
+	tagged := make([]*model.Template, 0, len(templates))
+	for _, tmpl := range templates {
+		copied := *tmpl
+		copied.Version = v.ref
+		copied.Revision = v.commit
+		tagged = append(tagged, &copied)
+	}
+	return tagged
+}
+
+// runGit runs a git command against gitDir, or outside any repository when gitDir is empty
+func runGit(gitDir string, args ...string) (string, error) {
+	return runGitInput(gitDir, "", args...)
+}
+
+// runGitInput runs a git command like runGit, passing input on stdin
+func runGitInput(gitDir, input string, args ...string) (string, error) {
+	subcommand := args[0]
+	if gitDir != "" {
+		args = append([]string{"--git-dir", gitDir}, args...)
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.Stdin = strings.NewReader(input)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s failed: %s", subcommand, msg)
+		}
+		return "", fmt.Errorf("git %s failed: %w", subcommand, err)
+	}
+
+	return stdout.String(), nil
+}
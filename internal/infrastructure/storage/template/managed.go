@@ -0,0 +1,383 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+)
+
+const (
+	// managedTreeDir is the directory of a stored version holding the template tree
+	managedTreeDir = "tree"
+	// managedMetaFile is the file of a stored version holding its metadata
+	managedMetaFile = "meta.json"
+)
+
+// ManagedRepository implements the ManagedTemplateRepository interface using
+// a directory on disk. Every version is stored as <root>/<id>/<version>/ with
+// the template tree in tree/ and its metadata in meta.json.
+type ManagedRepository struct {
+	root  string
+	mutex sync.RWMutex
+}
+
+// NewManagedRepository creates a managed template repository rooted at dir, creating it if needed
+func NewManagedRepository(dir string) (*ManagedRepository, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create template upload directory: %w", err)
+	}
+
+	return &ManagedRepository{
+		root: dir,
+	}, nil
+}
+
+// GetAll returns the default version of every available template
+func (r *ManagedRepository) GetAll() ([]*model.Template, error) {
+	versions, err := r.available()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(versions))
+	for id := range versions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	templates := make([]*model.Template, 0, len(ids))
+	for _, id := range ids {
+		templates = append(templates, defaultVersion(versions[id]))
+	}
+
+	return templates, nil
+}
+
+// GetByID returns the default version of a template by ID
+func (r *ManagedRepository) GetByID(id string) (*model.Template, error) {
+	versions, err := r.available()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(versions[id]) == 0 {
+		return nil, fmt.Errorf("template %s: %w", id, repository.ErrNotFound)
+	}
+	return defaultVersion(versions[id]), nil
+}
+
+// GetByType returns the default version of the templates of a specific type
+func (r *ManagedRepository) GetByType(templateType string) ([]*model.Template, error) {
+	all, err := r.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var templates []*model.Template
+	for _, tmpl := range all {
+		if tmpl.Type == templateType {
+			templates = append(templates, tmpl)
+		}
+	}
+
+	return templates, nil
+}
+
+// GetVersion returns a specific published or deprecated version of a template
+func (r *ManagedRepository) GetVersion(id, version string) (*model.Template, error) {
+	versions, err := r.available()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tmpl := range versions[id] {
+		if model.SameVersion(tmpl.Version, version) {
+			return tmpl, nil
+		}
+	}
+
+	return nil, fmt.Errorf("template %s version %s: %w", id, version, repository.ErrNotFound)
+}
+
+// GetVersions returns every published or deprecated version of a template, newest first
+func (r *ManagedRepository) GetVersions(id string) ([]*model.Template, error) {
+	versions, err := r.available()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(versions[id]) == 0 {
+		return nil, fmt.Errorf("template %s: %w", id, repository.ErrNotFound)
+	}
+	return versions[id], nil
+}
+
+// List returns every stored template version, including drafts, sorted by ID and newest version first
+func (r *ManagedRepository) List() ([]*model.Template, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.list()
+}
+
+// Save stores a new template version with the files of its tree
+func (r *ManagedRepository) Save(tmpl *model.Template, files fs.FS) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	dir, err := r.versionDir(tmpl.ID, tmpl.Version)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("template %s version %s: %w", tmpl.ID, tmpl.Version, repository.ErrAlreadyExists)
+	}
+
+	// Write into a staging directory first so a failed save leaves nothing behind
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return fmt.Errorf("failed to create template directory: %w", err)
+	}
+	staging, err := os.MkdirTemp(filepath.Dir(dir), ".upload-")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	if err := copyTree(files, filepath.Join(staging, managedTreeDir)); err != nil {
+		return err
+	}
+	if err := writeMeta(staging, tmpl); err != nil {
+		return err
+	}
+
+	if err := os.Rename(staging, dir); err != nil {
+		return fmt.Errorf("failed to store template: %w", err)
+	}
+
+	return nil
+}
+
+// SetStatus changes the status of a stored template version
+func (r *ManagedRepository) SetStatus(id, version, status string) (*model.Template, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	dir, err := r.versionDir(id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := r.load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl.Status = status
+	if err := writeMeta(dir, tmpl); err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+// Delete removes a stored template version
+func (r *ManagedRepository) Delete(id, version string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	dir, err := r.versionDir(id, version)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return fmt.Errorf("template %s version %s: %w", id, version, repository.ErrNotFound)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+
+	// Remove the template directory along with its last version
+	os.Remove(filepath.Dir(dir))
+
+	return nil
+}
+
+// available returns the published and deprecated versions of every template, newest first
+func (r *ManagedRepository) available() (map[string][]*model.Template, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	all, err := r.list()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string][]*model.Template)
+	for _, tmpl := range all {
+		if tmpl.Status != model.TemplateStatusDraft {
+			versions[tmpl.ID] = append(versions[tmpl.ID], tmpl)
+		}
+	}
+
+	return versions, nil
+}
+
+// list returns every stored template version. The caller must hold the mutex.
+func (r *ManagedRepository) list() ([]*model.Template, error) {
+	ids, err := os.ReadDir(r.root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template upload directory: %w", err)
+	}
+
+	var templates []*model.Template
+	for _, id := range ids {
+		if !id.IsDir() || isHiddenDir(id.Name()) {
+			continue
+		}
+
+		versions, err := os.ReadDir(filepath.Join(r.root, id.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template directory: %w", err)
+		}
+
+		var found []*model.Template
+		for _, version := range versions {
+			if !version.IsDir() || isHiddenDir(version.Name()) {
+				continue
+			}
+			tmpl, err := r.load(filepath.Join(r.root, id.Name(), version.Name()))
+			if err != nil {
+				return nil, err
+			}
+			found = append(found, tmpl)
+		}
+
+		sortVersions(found)
+		templates = append(templates, found...)
+	}
+
+	return templates, nil
+}
+
+// load reads a stored template version from its directory
+func (r *ManagedRepository) load(dir string) (*model.Template, error) {
+	data, err := os.ReadFile(filepath.Join(dir, managedMetaFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("template version %s: %w", filepath.Base(dir), repository.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template metadata: %w", err)
+	}
+
+	var tmpl model.Template
+	if err := json.Unmarshal(data, &tmpl); err != nil {
+		return nil, fmt.Errorf("failed to parse template metadata %s: %w", dir, err)
+	}
+
+	// Versions stored before templates recorded their router
+	if tmpl.Router == "" {
+		_, tmpl.Router, _ = model.ParseTemplateID(tmpl.ID)
+	}
+
+	tmpl.FS = os.DirFS(dir)
+	tmpl.Path = managedTreeDir
+	return &tmpl, nil
+}
+
+// versionDir returns the directory of a template version, rejecting IDs and
+// versions that are not safe to use as directory names. A stored version is
+// found by the same comparison as GetVersion, ignoring a "v" prefix on
+// semantic versions. The caller must hold the mutex.
+func (r *ManagedRepository) versionDir(id, version string) (string, error) {
+	for _, name := range []string{id, version} {
+		if name == "" || isHiddenDir(name) || strings.ContainsAny(name, `/\`) || name == ".." {
+			return "", fmt.Errorf("template %s version %s: %w", id, version, repository.ErrNotFound)
+		}
+	}
+
+	idDir := filepath.Join(r.root, id)
+	entries, err := os.ReadDir(idDir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read template directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && !isHiddenDir(entry.Name()) && model.SameVersion(entry.Name(), version) {
+			return filepath.Join(idDir, entry.Name()), nil
+		}
+	}
+
+	return filepath.Join(idDir, version), nil
+}
+
+// defaultVersion returns the version served when no version is requested:
+// the newest published version, or the newest deprecated one if none is published
+func defaultVersion(versions []*model.Template) *model.Template {
+	for _, tmpl := range versions {
+		if tmpl.Status == model.TemplateStatusPublished {
+			return tmpl
+		}
+	}
+	return versions[0]
+}
+
+// writeMeta writes the metadata of a template version into its directory
+func writeMeta(dir string, tmpl *model.Template) error {
+	meta := *tmpl
+	meta.Path = ""
+	meta.Origin = ""
+
+	data, err := json.MarshalIndent(&meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode template metadata: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, managedMetaFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write template metadata: %w", err)
+	}
+
+	return nil
+}
+
+// copyTree copies every regular file of fsys into dir
+func copyTree(fsys fs.FS, dir string) error {
+	return fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		src, err := fsys.Open(name)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		defer src.Close()
+
+		dst, err := os.Create(target)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", target, err)
+		}
+		defer dst.Close()
+
+		if _, err := io.Copy(dst, src); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", name, err)
+		}
+		return nil
+	})
+}
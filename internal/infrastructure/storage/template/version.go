@@ -0,0 +1,34 @@
+package template
+
+import (
+	"sort"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"golang.org/x/mod/semver"
+)
+
+// hasVersion reports whether templates contain the given version
+func hasVersion(templates []*model.Template, version string) bool {
+	for _, tmpl := range templates {
+		if model.SameVersion(tmpl.Version, version) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortVersions sorts templates newest semantic version first. Versions that
+// are not semantic versions, such as branch names, sort last by name.
+func sortVersions(templates []*model.Template) {
+	sort.SliceStable(templates, func(i, j int) bool {
+		ci, cj := model.CanonicalVersion(templates[i].Version), model.CanonicalVersion(templates[j].Version)
+		switch {
+		case ci != "" && cj != "":
+			return semver.Compare("v"+ci, "v"+cj) > 0
+		case ci != "" || cj != "":
+			return ci != ""
+		default:
+			return templates[i].Version < templates[j].Version
+		}
+	})
+}
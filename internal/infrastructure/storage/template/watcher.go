@@ -0,0 +1,128 @@
+package template
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce is how long the watcher waits for further changes
+// before reporting a batch, so that editors saving several files at once
+// trigger a single reload
+const DefaultWatchDebounce = 200 * time.Millisecond
+
+// Watcher watches a template directory tree and reports changed files
+type Watcher struct {
+	watcher  *fsnotify.Watcher
+	debounce time.Duration
+	onChange func(paths []string)
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewWatcher starts watching every directory below root. onChange is called
+// from a background goroutine with the changed paths once changes settle.
+func NewWatcher(root string, debounce time.Duration, onChange func(paths []string)) (*Watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create template watcher: %w", err)
+	}
+
+	w := &Watcher{
+		watcher:  fsWatcher,
+		debounce: debounce,
+		onChange: onChange,
+		done:     make(chan struct{}),
+	}
+
+	if err := w.addTree(root); err != nil {
+		fsWatcher.Close()
+		return nil, err
+	}
+
+	w.wg.Add(1)
+	go w.run()
+
+	return w, nil
+}
+
+// Close stops watching and waits for the background goroutine to exit
+func (w *Watcher) Close() error {
+	close(w.done)
+	err := w.watcher.Close()
+	w.wg.Wait()
+	return err
+}
+
+// addTree watches root and all of its subdirectories, since fsnotify is not recursive
+func (w *Watcher) addTree(root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if err := w.watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch template directory %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// run collects events and reports them in debounced batches
+func (w *Watcher) run() {
+	defer w.wg.Done()
+
+	pending := make(map[string]struct{})
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			timer.Stop()
+			return
+
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+
+			// Watch directories created after startup
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := w.addTree(event.Name); err != nil {
+						log.Printf("Template watcher: %v", err)
+					}
+				}
+			}
+
+			pending[event.Name] = struct{}{}
+			timer.Reset(w.debounce)
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("Template watcher error: %v", err)
+
+		case <-timer.C:
+			paths := make([]string, 0, len(pending))
+			for path := range pending {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			pending = make(map[string]struct{})
+
+			w.onChange(paths)
+		}
+	}
+}
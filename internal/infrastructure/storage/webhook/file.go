@@ -0,0 +1,235 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+)
+
+// MaxDeliveries is the number of deliveries logged per webhook; older ones are forgotten
+const MaxDeliveries = 100
+
+// webhooksFile is the on-disk layout of stored webhooks
+type webhooksFile struct {
+	Webhooks map[string]*model.Webhook `json:"webhooks"`
+}
+
+// FileRepository implements the WebhookRepository interface using a JSON
+// file as storage for webhooks. Their deliveries are logged in memory, so
+// the log starts over when the server restarts.
+type FileRepository struct {
+	path       string
+	webhooks   map[string]*model.Webhook
+	deliveries map[string][]*model.WebhookDelivery // Deliveries by webhook ID, oldest first
+	mutex      sync.RWMutex
+}
+
+// NewFileRepository creates a webhook repository storing webhooks in the
+// file at path, which is created on the first save
+func NewFileRepository(path string) (*FileRepository, error) {
+	r := &FileRepository{
+		path:       path,
+		webhooks:   make(map[string]*model.Webhook),
+		deliveries: make(map[string][]*model.WebhookDelivery),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhooks: %w", err)
+	}
+
+	var file webhooksFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse webhooks: %w", err)
+	}
+	for id, webhook := range file.Webhooks {
+		if webhook == nil {
+			return nil, fmt.Errorf("webhook %s has no definition", id)
+		}
+		webhook.ID = id
+		r.webhooks[id] = webhook
+	}
+
+	return r, nil
+}
+
+// List returns the webhooks of an owner, oldest first
+func (r *FileRepository) List(owner string) ([]*model.Webhook, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	webhooks := []*model.Webhook{}
+	for _, webhook := range r.webhooks {
+		if webhook.Owner == owner {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		return older(webhooks[i], webhooks[j])
+	})
+
+	return webhooks, nil
+}
+
+// GetByID returns a webhook by ID
+func (r *FileRepository) GetByID(id string) (*model.Webhook, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return nil, fmt.Errorf("webhook %s: %w", id, repository.ErrNotFound)
+	}
+
+	return webhook, nil
+}
+
+// Save stores a new webhook
+func (r *FileRepository) Save(webhook *model.Webhook) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.webhooks[webhook.ID]; ok {
+		return fmt.Errorf("webhook %s: %w", webhook.ID, repository.ErrAlreadyExists)
+	}
+
+	return r.write(webhook.ID, webhook)
+}
+
+// Update replaces a stored webhook
+func (r *FileRepository) Update(webhook *model.Webhook) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.webhooks[webhook.ID]; !ok {
+		return fmt.Errorf("webhook %s: %w", webhook.ID, repository.ErrNotFound)
+	}
+
+	return r.write(webhook.ID, webhook)
+}
+
+// Delete removes a webhook and its deliveries
+func (r *FileRepository) Delete(id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.webhooks[id]; !ok {
+		return fmt.Errorf("webhook %s: %w", id, repository.ErrNotFound)
+	}
+
+	if err := r.write(id, nil); err != nil {
+		return err
+	}
+	delete(r.deliveries, id)
+	return nil
+}
+
+// SaveDelivery stores a copy of a delivery, replacing an earlier record with its ID
+func (r *FileRepository) SaveDelivery(delivery *model.WebhookDelivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.webhooks[delivery.WebhookID]; !ok {
+		return fmt.Errorf("webhook %s: %w", delivery.WebhookID, repository.ErrNotFound)
+	}
+
+	record := *delivery
+	deliveries := r.deliveries[delivery.WebhookID]
+	for i, logged := range deliveries {
+		if logged.ID == delivery.ID {
+			deliveries[i] = &record
+			return nil
+		}
+	}
+
+	deliveries = append(deliveries, &record)
+	if len(deliveries) > MaxDeliveries {
+		deliveries = deliveries[len(deliveries)-MaxDeliveries:]
+	}
+	r.deliveries[delivery.WebhookID] = deliveries
+	return nil
+}
+
+// ListDeliveries returns copies of the logged deliveries of a webhook, newest first
+func (r *FileRepository) ListDeliveries(webhookID string) ([]*model.WebhookDelivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if _, ok := r.webhooks[webhookID]; !ok {
+		return nil, fmt.Errorf("webhook %s: %w", webhookID, repository.ErrNotFound)
+	}
+
+	logged := r.deliveries[webhookID]
+	deliveries := make([]*model.WebhookDelivery, 0, len(logged))
+	for i := len(logged) - 1; i >= 0; i-- {
+		record := *logged[i]
+		deliveries = append(deliveries, &record)
+	}
+
+	return deliveries, nil
+}
+
+// write stores webhook under id, or removes it when webhook is nil, and writes
+// the stored webhooks to disk. The caller must hold the mutex.
+func (r *FileRepository) write(id string, webhook *model.Webhook) error {
+	webhooks := make(map[string]*model.Webhook, len(r.webhooks)+1)
+	for storedID, stored := range r.webhooks {
+		webhooks[storedID] = stored
+	}
+	if webhook == nil {
+		delete(webhooks, id)
+	} else {
+		webhooks[id] = webhook
+	}
+
+	data, err := json.MarshalIndent(webhooksFile{Webhooks: webhooks}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode webhooks: %w", err)
+	}
+
+	// Write a temporary file first so a failed write leaves the old webhooks
+	// intact; it is only readable by its owner, since it holds the secrets
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create webhooks directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".webhooks-")
+	if err != nil {
+		return fmt.Errorf("failed to write webhooks: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write webhooks: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write webhooks: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("failed to write webhooks: %w", err)
+	}
+
+	r.webhooks = webhooks
+	return nil
+}
+
+// older reports whether webhook a was created before b, breaking ties by ID
+func older(a, b *model.Webhook) bool {
+	timeA, _ := time.Parse(time.RFC3339, a.CreatedAt)
+	timeB, _ := time.Parse(time.RFC3339, b.CreatedAt)
+	if !timeA.Equal(timeB) {
+		return timeA.Before(timeB)
+	}
+	return a.ID < b.ID
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HeaderAPIKey is the request header carrying an API key
+const HeaderAPIKey = "X-API-Key"
+
+// apiKeyContextKey is the echo context key of the authenticated API key
+const apiKeyContextKey = "apiKey"
+
+// APIKey returns middleware that authenticates requests carrying an API key
+// and records the key for APIKeyFrom. Requests without a key are let through
+// anonymously; requests with an unknown key are rejected.
+func APIKey(keys repository.APIKeyRepository) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			provided := c.Request().Header.Get(HeaderAPIKey)
+			if provided == "" {
+				return next(c)
+			}
+
+			key, err := keys.GetByKey(provided)
+			if errors.Is(err, repository.ErrNotFound) {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "Invalid API key",
+				})
+			}
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "Failed to check API key",
+				})
+			}
+
+			c.Set(apiKeyContextKey, key)
+			return next(c)
+		}
+	}
+}
+
+// APIKeyFrom returns the API key a request was authenticated with, or nil for anonymous requests
+func APIKeyFrom(c echo.Context) *model.APIKey {
+	key, _ := c.Get(apiKeyContextKey).(*model.APIKey)
+	return key
+}
+
+// PlanFrom returns the plan of the API key a request was authenticated with, or nil for anonymous requests
+func PlanFrom(c echo.Context) *model.Plan {
+	if key := APIKeyFrom(c); key != nil {
+		return key.Plan
+	}
+	return nil
+}
@@ -3,295 +3,530 @@ package handler
 import (
 	"net/http"
 
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/auth"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/openapi"
+
 	"github.com/labstack/echo/v4"
 )
 
-// ApiDocsHandler handles API documentation
-type ApiDocsHandler struct{}
+// adminSecurityScheme is the security scheme of the admin endpoints
+const adminSecurityScheme = "adminToken"
 
-// NewApiDocsHandler creates a new API docs handler
-func NewApiDocsHandler() *ApiDocsHandler {
-	return &ApiDocsHandler{}
+// apiKeySecurityScheme is the security scheme of the other endpoints, which also accept anonymous requests
+const apiKeySecurityScheme = "apiKey"
+
+// templateParams describes the path parameters selecting an uploaded template version
+var templateParams = map[string]string{
+	"id":      "Template ID",
+	"version": "Template version",
 }
 
-// HandleApiDocs returns the OpenAPI specification for the API
-func (h *ApiDocsHandler) HandleApiDocs(c echo.Context) error {
-	docs := map[string]interface{}{
-		"openapi": "3.0.0",
-		"info": map[string]interface{}{
-			"title":       "Go Scaffold Generator API",
-			"description": "API for generating Go application scaffolds",
-			"version":     "1.0.0",
-		},
-		"servers": []map[string]string{
-			{
-				"url": "/api",
-			},
+// uploadForm is the multipart form of a template upload
+var uploadForm = &model.JSONSchema{
+	Type:     "object",
+	Required: []string{"file", "id", "version"},
+	Properties: map[string]*model.JSONSchema{
+		"file":        {Type: "string", Format: "binary", Description: "ZIP, tar or tar.gz archive of the template tree"},
+		"id":          {Type: "string", Description: "Template ID, e.g. api-fiber"},
+		"version":     {Type: "string", Description: "Semantic version"},
+		"name":        {Type: "string", Description: "Display name"},
+		"description": {Type: "string", Description: "Short description"},
+	},
+}
+
+// apiRoutes documents every route below /api
+var apiRoutes = []openapi.Route{
+	{
+		Method:  http.MethodGet,
+		Path:    "/health",
+		Summary: "Health check",
+		Tag:     "system",
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Body: HealthResponse{}},
 		},
-		"paths": map[string]interface{}{
-			"/health": map[string]interface{}{
-				"get": map[string]interface{}{
-					"summary":     "Health Check",
-					"description": "Returns the health status of the API",
-					"responses": map[string]interface{}{
-						"200": map[string]interface{}{
-							"description": "OK",
-							"content": map[string]interface{}{
-								"application/json": map[string]interface{}{
-									"schema": map[string]interface{}{
-										"type": "object",
-										"properties": map[string]interface{}{
-											"status": map[string]string{
-												"type":    "string",
-												"example": "OK",
-											},
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-			},
-			"/templates": map[string]interface{}{
-				"get": map[string]interface{}{
-					"summary":     "List Templates",
-					"description": "Returns a list of available templates",
-					"responses": map[string]interface{}{
-						"200": map[string]interface{}{
-							"description": "OK",
-							"content": map[string]interface{}{
-								"application/json": map[string]interface{}{
-									"schema": map[string]interface{}{
-										"type":  "array",
-										"items": templateSchema,
-									},
-								},
-							},
-						},
-					},
-				},
-			},
-			"/features": map[string]interface{}{
-				"get": map[string]interface{}{
-					"summary":     "List Features",
-					"description": "Returns a list of available features",
-					"responses": map[string]interface{}{
-						"200": map[string]interface{}{
-							"description": "OK",
-							"content": map[string]interface{}{
-								"application/json": map[string]interface{}{
-									"schema": map[string]interface{}{
-										"type": "object",
-										"properties": map[string]interface{}{
-											"features": map[string]interface{}{
-												"type":  "array",
-												"items": featureSchema,
-											},
-											"premiumFeatures": map[string]interface{}{
-												"type":  "array",
-												"items": featureSchema,
-											},
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-			},
-			"/generate": map[string]interface{}{
-				"post": map[string]interface{}{
-					"summary":     "Generate Scaffold",
-					"description": "Generates a scaffold based on provided options",
-					"requestBody": map[string]interface{}{
-						"required": true,
-						"content": map[string]interface{}{
-							"application/json": map[string]interface{}{
-								"schema": scaffoldOptionsSchema,
-							},
-						},
-					},
-					"responses": map[string]interface{}{
-						"200": map[string]interface{}{
-							"description": "OK",
-							"content": map[string]interface{}{
-								"application/json": map[string]interface{}{
-									"schema": map[string]interface{}{
-										"type": "object",
-										"properties": map[string]interface{}{
-											"id": map[string]string{
-												"type":    "string",
-												"example": "123",
-											},
-											"message": map[string]string{
-												"type":    "string",
-												"example": "Scaffold generated successfully",
-											},
-										},
-									},
-								},
-							},
-						},
-						"400": map[string]interface{}{
-							"description": "Bad Request",
-							"content": map[string]interface{}{
-								"application/json": map[string]interface{}{
-									"schema": map[string]interface{}{
-										"type": "object",
-										"properties": map[string]interface{}{
-											"error": map[string]string{
-												"type":    "string",
-												"example": "Invalid request",
-											},
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-			},
-			"/download/{id}": map[string]interface{}{
-				"get": map[string]interface{}{
-					"summary":     "Download Scaffold",
-					"description": "Downloads a generated scaffold by ID",
-					"parameters": []map[string]interface{}{
-						{
-							"in":          "path",
-							"name":        "id",
-							"required":    true,
-							"description": "Scaffold ID",
-							"schema": map[string]string{
-								"type": "string",
-							},
-						},
-					},
-					"responses": map[string]interface{}{
-						"200": map[string]interface{}{
-							"description": "OK",
-							"content": map[string]interface{}{
-								"application/zip": map[string]interface{}{
-									"schema": map[string]interface{}{
-										"type":   "string",
-										"format": "binary",
-									},
-								},
-							},
-						},
-						"404": map[string]interface{}{
-							"description": "Not Found",
-							"content": map[string]interface{}{
-								"application/json": map[string]interface{}{
-									"schema": map[string]interface{}{
-										"type": "object",
-										"properties": map[string]interface{}{
-											"error": map[string]string{
-												"type":    "string",
-												"example": "Scaffold not found",
-											},
-										},
-									},
-								},
-							},
-						},
-					},
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    "/docs",
+		Summary: "API documentation",
+		Tag:     "system",
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Description: "This OpenAPI document", Body: &model.JSONSchema{Type: "object"}},
+		},
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    "/features",
+		Summary: "List features",
+		Tag:     "features",
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Description: "Features marked with whether the plan of the caller entitles it to them", Body: FeaturesResponse{}},
+			{Status: http.StatusInternalServerError, Body: ErrorResponse{}},
+		},
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    "/templates",
+		Summary: "List templates",
+		Tag:     "templates",
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Body: []model.Template{}},
+			{Status: http.StatusInternalServerError, Body: ErrorResponse{}},
+		},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/templates/:id/versions",
+		Summary:     "List template versions",
+		Description: "Returns every available version of a template, newest first",
+		Tag:         "templates",
+		Params:      map[string]string{"id": "Template ID"},
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Body: []model.Template{}},
+			{Status: http.StatusNotFound, Body: ErrorResponse{}},
+		},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/schema/options",
+		Summary:     "Scaffold options schema",
+		Description: "Returns the JSON Schema that generation requests are validated against, built from the current template and feature catalogs",
+		Tag:         "generator",
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Body: &model.JSONSchema{Ref: "https://json-schema.org/draft/2020-12/schema"}},
+			{Status: http.StatusInternalServerError, Body: ErrorResponse{}},
+		},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/generate",
+		Summary:     "Generate scaffold",
+		Description: "Generates a scaffold from the options; the allowed values are listed by GET /schema/options. With presetId, the options of the preset are used and the other options override them. Scaffolds with identical options and template share one archive. Premium features require an API key on a plan that includes them.",
+		Tag:         "generator",
+		Query: map[string]string{
+			"fresh": "Render a new archive even if an earlier scaffold with the same options and template can be shared",
+		},
+		Body: model.ScaffoldOptions{},
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Body: GenerateResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid options or query parameters; every problem is listed in problems", Body: ValidationErrorResponse{}},
+			{Status: http.StatusPaymentRequired, Description: "Premium features requested without an API key", Body: ValidationErrorResponse{}},
+			{Status: http.StatusForbidden, Description: "Premium features requested that the plan of the API key does not include", Body: ValidationErrorResponse{}},
+			{Status: http.StatusTooManyRequests, Description: "Rate limit, daily generation quota or storage quota reached; Retry-After tells when to retry, except for the storage quota, which is freed by deleting scaffolds", Body: ErrorResponse{}},
+		},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/download/:id",
+		Summary:     "Download scaffold",
+		Description: "Serves the archive named after the project. Supports If-None-Match against the ETag, and Range with If-Range to resume downloads. When download links are enabled, the query of a signed link from POST /generate or POST /scaffolds/:id/links is required",
+		Tag:         "generator",
+		Params:      map[string]string{"id": "Scaffold ID"},
+		Query: map[string]string{
+			"expires": "Expiry of the download link as a Unix time",
+			"once":    "Nonce of a one-time download link",
+			"sig":     "Signature of the download link",
+		},
+		Replies: []openapi.Reply{
+			{
+				Status:      http.StatusOK,
+				Description: "ZIP archive of the scaffold",
+				ContentType: "application/zip",
+				Headers: map[string]string{
+					HeaderReprDigest:              "SHA-256 of the archive, e.g. sha-256=:base64:",
+					HeaderETag:                    "Quoted hex SHA-256 of the archive",
+					echo.HeaderContentDisposition: "Attachment named after the project, e.g. billing-api.zip",
+					echo.HeaderLastModified:       "When the archive was written",
+					"Accept-Ranges":               "bytes",
 				},
 			},
+			{Status: http.StatusPartialContent, Description: "Requested range of the archive", ContentType: "application/zip"},
+			{Status: http.StatusNotModified, Description: "The archive matches If-None-Match"},
+			{Status: http.StatusForbidden, Description: "Download link missing, invalid, expired or already used", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Body: ErrorResponse{}},
+			{Status: http.StatusGone, Description: "The scaffold has expired or its archive no longer exists", Body: ErrorResponse{}},
+			{Status: http.StatusRequestedRangeNotSatisfiable, Description: "The range is outside the archive", ContentType: "text/plain"},
 		},
-	}
-
-	return c.JSON(http.StatusOK, docs)
-}
-
-var templateSchema = map[string]interface{}{
-	"type": "object",
-	"properties": map[string]interface{}{
-		"id": map[string]string{
-			"type":    "string",
-			"example": "api-echo",
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/download/:id/signature",
+		Summary:     "Download archive signature",
+		Description: "Returns the detached ed25519 signature of the archive, verifiable with a key listed by GET /keys",
+		Tag:         "generator",
+		Params:      map[string]string{"id": "Scaffold ID"},
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Description: "Raw 64-byte ed25519 signature", ContentType: "application/octet-stream"},
+			{Status: http.StatusNotFound, Description: "Scaffold not found or not signed", Body: ErrorResponse{}},
+			{Status: http.StatusGone, Description: "The scaffold has expired", Body: ErrorResponse{}},
 		},
-		"name": map[string]string{
-			"type":    "string",
-			"example": "API with Echo router",
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/keys",
+		Summary:     "List signing keys",
+		Description: "Returns the public keys that verify archive signatures; the list is empty when archives are not signed",
+		Tag:         "generator",
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Body: KeysResponse{}},
 		},
-		"description": map[string]string{
-			"type":    "string",
-			"example": "A RESTful API using the Echo framework",
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/scaffolds",
+		Summary:     "List scaffolds",
+		Description: "Returns a page of the scaffolds generated by the caller, newest first",
+		Tag:         "scaffolds",
+		Query: map[string]string{
+			"routerType":   "Only scaffolds using this router",
+			"databaseType": "Only scaffolds using this database",
+			"feature":      "Only scaffolds including this regular or premium feature",
+			"from":         "Only scaffolds created at or after this RFC 3339 time or date",
+			"to":           "Only scaffolds created before this RFC 3339 time, or on or before this date",
+			"offset":       "Number of matching scaffolds to skip",
+			"limit":        "Maximum number of scaffolds to return, 1 to 100; defaults to 20",
 		},
-		"type": map[string]string{
-			"type":    "string",
-			"example": "api",
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Body: model.ScaffoldPage{}},
+			{Status: http.StatusBadRequest, Description: "Invalid query parameters; every problem is listed in problems", Body: ValidationErrorResponse{}},
+			{Status: http.StatusInternalServerError, Body: ErrorResponse{}},
 		},
-		"path": map[string]string{
-			"type":    "string",
-			"example": "/templates/api/echo",
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/scaffolds/:id",
+		Summary:     "Get scaffold",
+		Description: "Returns the metadata and options of a scaffold generated by the caller",
+		Tag:         "scaffolds",
+		Params:      map[string]string{"id": "Scaffold ID"},
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Body: model.GeneratedScaffold{}},
+			{Status: http.StatusNotFound, Body: ErrorResponse{}},
 		},
 	},
-}
-
-var featureSchema = map[string]interface{}{
-	"type": "object",
-	"properties": map[string]interface{}{
-		"id": map[string]string{
-			"type":    "string",
-			"example": "auth",
+	{
+		Method:      http.MethodDelete,
+		Path:        "/scaffolds/:id",
+		Summary:     "Delete scaffold",
+		Description: "Removes a scaffold generated by the caller and its archive, freeing its storage quota",
+		Tag:         "scaffolds",
+		Params:      map[string]string{"id": "Scaffold ID"},
+		Replies: []openapi.Reply{
+			{Status: http.StatusNoContent},
+			{Status: http.StatusNotFound, Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Body: ErrorResponse{}},
 		},
-		"name": map[string]string{
-			"type":    "string",
-			"example": "Authentication",
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/scaffolds/:id/links",
+		Summary:     "Issue download link",
+		Description: "Issues a signed download link for a scaffold generated by the caller; one-time links stop working after their first download",
+		Tag:         "scaffolds",
+		Params:      map[string]string{"id": "Scaffold ID"},
+		Body:        DownloadLinkRequest{},
+		Replies: []openapi.Reply{
+			{Status: http.StatusCreated, Body: DownloadLinkResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid lifetime", Body: ValidationErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Scaffold not found or download links not enabled", Body: ErrorResponse{}},
 		},
-		"description": map[string]string{
-			"type":    "string",
-			"example": "Basic authentication middleware",
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/usage",
+		Summary:     "Usage",
+		Description: "Returns how much of its rate limit, daily generation quota and storage quota the caller has used",
+		Tag:         "usage",
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Body: model.Usage{}},
+			{Status: http.StatusNotFound, Description: "Usage is not tracked", Body: ErrorResponse{}},
 		},
-		"isPremium": map[string]interface{}{
-			"type":    "boolean",
-			"example": false,
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/presets",
+		Summary:     "List presets",
+		Description: "Returns the built-in presets and those created by clients, sorted by ID",
+		Tag:         "presets",
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Body: []model.Preset{}},
+			{Status: http.StatusInternalServerError, Body: ErrorResponse{}},
 		},
 	},
-}
-
-var scaffoldOptionsSchema = map[string]interface{}{
-	"type": "object",
-	"properties": map[string]interface{}{
-		"appType": map[string]string{
-			"type":    "string",
-			"example": "api",
-		},
-		"routerType": map[string]string{
-			"type":    "string",
-			"example": "echo",
-		},
-		"databaseType": map[string]string{
-			"type":    "string",
-			"example": "postgresql",
-		},
-		"configType": map[string]string{
-			"type":    "string",
-			"example": "env",
-		},
-		"logFormat": map[string]string{
-			"type":    "string",
-			"example": "json",
-		},
-		"modulePath": map[string]string{
-			"type":    "string",
-			"example": "github.com/username/project",
-		},
-		"features": map[string]interface{}{
-			"type": "array",
-			"items": map[string]interface{}{
-				"type":    "string",
-				"example": "auth",
-			},
+	{
+		Method:  http.MethodGet,
+		Path:    "/presets/:id",
+		Summary: "Get preset",
+		Tag:     "presets",
+		Params:  map[string]string{"id": "Preset ID"},
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Body: model.Preset{}},
+			{Status: http.StatusNotFound, Body: ErrorResponse{}},
 		},
-		"premiumFeatures": map[string]interface{}{
-			"type": "array",
-			"items": map[string]interface{}{
-				"type":    "string",
-				"example": "automatic-https",
-			},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/presets",
+		Summary:     "Create preset",
+		Description: "Stores a preset owned by the caller; its options may set any subset of the scaffold options",
+		Tag:         "presets",
+		Body:        PresetRequest{},
+		Replies: []openapi.Reply{
+			{Status: http.StatusCreated, Body: model.Preset{}},
+			{Status: http.StatusUnauthorized, Description: "No API key", Body: ErrorResponse{}},
+			{Status: http.StatusConflict, Description: "A preset with the ID already exists", Body: ErrorResponse{}},
+			{Status: http.StatusUnprocessableEntity, Description: "Invalid preset; every problem is listed in problems", Body: ValidationErrorResponse{}},
+		},
+	},
+	{
+		Method:      http.MethodPut,
+		Path:        "/presets/:id",
+		Summary:     "Replace preset",
+		Description: "Replaces a preset created by the caller; the ID in the body is ignored",
+		Tag:         "presets",
+		Params:      map[string]string{"id": "Preset ID"},
+		Body:        PresetRequest{},
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Body: model.Preset{}},
+			{Status: http.StatusUnauthorized, Description: "No API key", Body: ErrorResponse{}},
+			{Status: http.StatusForbidden, Description: "Built-in preset or preset of another client", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Body: ErrorResponse{}},
+			{Status: http.StatusUnprocessableEntity, Description: "Invalid preset; every problem is listed in problems", Body: ValidationErrorResponse{}},
+		},
+	},
+	{
+		Method:      http.MethodDelete,
+		Path:        "/presets/:id",
+		Summary:     "Delete preset",
+		Description: "Removes a preset created by the caller",
+		Tag:         "presets",
+		Params:      map[string]string{"id": "Preset ID"},
+		Replies: []openapi.Reply{
+			{Status: http.StatusNoContent},
+			{Status: http.StatusUnauthorized, Description: "No API key", Body: ErrorResponse{}},
+			{Status: http.StatusForbidden, Description: "Built-in preset or preset of another client", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Body: ErrorResponse{}},
+		},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/webhooks",
+		Summary:     "List webhooks",
+		Description: "Returns the webhooks of the caller, oldest first, without their secrets",
+		Tag:         "webhooks",
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Body: []model.Webhook{}},
+			{Status: http.StatusUnauthorized, Description: "No API key", Body: ErrorResponse{}},
+		},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/webhooks",
+		Summary:     "Create webhook",
+		Description: "Subscribes a URL of the caller to scaffold.generated, scaffold.failed and scaffold.expired events, or to those listed in events. Events are posted as JSON signed with the returned secret, which is only returned here.",
+		Tag:         "webhooks",
+		Body:        WebhookRequest{},
+		Replies: []openapi.Reply{
+			{Status: http.StatusCreated, Description: "The webhook including its secret", Body: model.Webhook{}},
+			{Status: http.StatusUnauthorized, Description: "No API key", Body: ErrorResponse{}},
+			{Status: http.StatusUnprocessableEntity, Description: "Invalid webhook; every problem is listed in problems", Body: ValidationErrorResponse{}},
+		},
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    "/webhooks/:id",
+		Summary: "Get webhook",
+		Tag:     "webhooks",
+		Params:  map[string]string{"id": "Webhook ID"},
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Body: model.Webhook{}},
+			{Status: http.StatusUnauthorized, Description: "No API key", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "No webhook of the caller with the ID", Body: ErrorResponse{}},
+		},
+	},
+	{
+		Method:      http.MethodPut,
+		Path:        "/webhooks/:id",
+		Summary:     "Replace webhook",
+		Description: "Replaces the URL and events of a webhook of the caller; its secret is kept",
+		Tag:         "webhooks",
+		Params:      map[string]string{"id": "Webhook ID"},
+		Body:        WebhookRequest{},
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Body: model.Webhook{}},
+			{Status: http.StatusUnauthorized, Description: "No API key", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "No webhook of the caller with the ID", Body: ErrorResponse{}},
+			{Status: http.StatusUnprocessableEntity, Description: "Invalid webhook; every problem is listed in problems", Body: ValidationErrorResponse{}},
+		},
+	},
+	{
+		Method:      http.MethodDelete,
+		Path:        "/webhooks/:id",
+		Summary:     "Delete webhook",
+		Description: "Unsubscribes a webhook of the caller and removes its delivery log",
+		Tag:         "webhooks",
+		Params:      map[string]string{"id": "Webhook ID"},
+		Replies: []openapi.Reply{
+			{Status: http.StatusNoContent},
+			{Status: http.StatusUnauthorized, Description: "No API key", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "No webhook of the caller with the ID", Body: ErrorResponse{}},
 		},
 	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/webhooks/:id/deliveries",
+		Summary:     "List webhook deliveries",
+		Description: "Returns the latest deliveries to a webhook of the caller, newest first, with the outcome of their last attempt",
+		Tag:         "webhooks",
+		Params:      map[string]string{"id": "Webhook ID"},
+		Query: map[string]string{
+			"status": "Only deliveries in this state: pending, succeeded or failed",
+		},
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Body: []model.WebhookDelivery{}},
+			{Status: http.StatusUnauthorized, Description: "No API key", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "No webhook of the caller with the ID", Body: ErrorResponse{}},
+			{Status: http.StatusUnprocessableEntity, Description: "Unknown status", Body: ValidationErrorResponse{}},
+		},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/webhooks/:id/ping",
+		Summary:     "Ping webhook",
+		Description: "Queues a webhook.ping delivery to a webhook of the caller, whatever events it subscribes to",
+		Tag:         "webhooks",
+		Params:      map[string]string{"id": "Webhook ID"},
+		Replies: []openapi.Reply{
+			{Status: http.StatusAccepted, Description: "The pending delivery", Body: model.WebhookDelivery{}},
+			{Status: http.StatusUnauthorized, Description: "No API key", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "No webhook of the caller with the ID", Body: ErrorResponse{}},
+		},
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/admin/templates",
+		Summary:  "List uploaded templates",
+		Tag:      "admin",
+		Security: adminSecurityScheme,
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Description: "Every uploaded template version, including drafts", Body: []model.Template{}},
+			{Status: http.StatusUnauthorized, Body: ErrorResponse{}},
+		},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/admin/templates",
+		Summary:     "Upload template",
+		Description: "Uploads a ZIP or tar archive of a template tree; it is validated and stored as a draft",
+		Tag:         "admin",
+		Security:    adminSecurityScheme,
+		Body:        uploadForm,
+		BodyType:    echo.MIMEMultipartForm,
+		Replies: []openapi.Reply{
+			{Status: http.StatusCreated, Body: model.Template{}},
+			{Status: http.StatusBadRequest, Body: ErrorResponse{}},
+			{Status: http.StatusUnauthorized, Body: ErrorResponse{}},
+			{Status: http.StatusConflict, Description: "Template version already exists", Body: ErrorResponse{}},
+			{Status: http.StatusUnprocessableEntity, Description: "Invalid upload, archive or template", Body: ValidationErrorResponse{}},
+		},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/admin/templates/:id/:version/publish",
+		Summary:     "Publish template",
+		Description: "Makes an uploaded template version available for generation",
+		Tag:         "admin",
+		Security:    adminSecurityScheme,
+		Params:      templateParams,
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Body: model.Template{}},
+			{Status: http.StatusUnauthorized, Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Body: ErrorResponse{}},
+		},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/admin/templates/:id/:version/deprecate",
+		Summary:     "Deprecate template",
+		Description: "Keeps a published template version available only when requested by version",
+		Tag:         "admin",
+		Security:    adminSecurityScheme,
+		Params:      templateParams,
+		Replies: []openapi.Reply{
+			{Status: http.StatusOK, Body: model.Template{}},
+			{Status: http.StatusUnauthorized, Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Body: ErrorResponse{}},
+			{Status: http.StatusUnprocessableEntity, Description: "The version is a draft", Body: ValidationErrorResponse{}},
+		},
+	},
+	{
+		Method:   http.MethodDelete,
+		Path:     "/admin/templates/:id/:version",
+		Summary:  "Delete template",
+		Tag:      "admin",
+		Security: adminSecurityScheme,
+		Params:   templateParams,
+		Replies: []openapi.Reply{
+			{Status: http.StatusNoContent},
+			{Status: http.StatusUnauthorized, Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Body: ErrorResponse{}},
+		},
+	},
+}
+
+// ApiDocsHandler handles API documentation
+type ApiDocsHandler struct {
+	docs *openapi.Document
+}
+
+// NewApiDocsHandler creates a new API docs handler
+func NewApiDocsHandler() *ApiDocsHandler {
+	builder := openapi.NewBuilder(openapi.Info{
+		Title:       "Go Scaffold Generator API",
+		Description: "API for generating Go application scaffolds",
+		Version:     "1.0.0",
+	}, openapi.Server{URL: "/api"})
+
+	builder.AddSecurityScheme(adminSecurityScheme, openapi.SecurityScheme{
+		Type:        "http",
+		Scheme:      "bearer",
+		Description: "The ADMIN_TOKEN the server was started with; admin endpoints are only served when it is set",
+	})
+
+	builder.AddSecurityScheme(apiKeySecurityScheme, openapi.SecurityScheme{
+		Type:        "apiKey",
+		Name:        auth.HeaderAPIKey,
+		In:          "header",
+		Description: "API key mapping the caller to a plan; requests without one are anonymous and cannot request premium features",
+	})
+	builder.SetSecurity(apiKeySecurityScheme, true)
+
+	for _, route := range apiRoutes {
+		// Every route outside the admin API checks the API key and rate limit of the caller
+		if route.Security == "" {
+			route.Replies = withReply(route.Replies, openapi.Reply{Status: http.StatusUnauthorized, Description: "Invalid API key", Body: ErrorResponse{}})
+			route.Replies = withReply(route.Replies, openapi.Reply{Status: http.StatusTooManyRequests, Description: "Rate limit reached; Retry-After tells when to retry", Body: ErrorResponse{}})
+		}
+		builder.Add(route)
+	}
+
+	return &ApiDocsHandler{
+		docs: builder.Document(),
+	}
+}
+
+// withReply returns replies with reply added, unless they already have one with its status
+func withReply(replies []openapi.Reply, reply openapi.Reply) []openapi.Reply {
+	for _, r := range replies {
+		if r.Status == reply.Status {
+			return replies
+		}
+	}
+	return append(replies[:len(replies):len(replies)], reply)
+}
+
+// HandleApiDocs returns the OpenAPI specification for the API
+func (h *ApiDocsHandler) HandleApiDocs(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.docs)
 }
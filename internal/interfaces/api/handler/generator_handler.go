@@ -1,19 +1,54 @@
 package handler
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
 	"github.com/regiwitanto/go-scaffold/internal/domain/service"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/auth"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/ratelimit"
 
 	"github.com/labstack/echo/v4"
 )
 
+const (
+	// HeaderReprDigest carries the SHA-256 of a downloaded archive, as defined by RFC 9530
+	HeaderReprDigest = "Repr-Digest"
+	// HeaderETag carries the entity tag of a downloaded archive, its quoted SHA-256
+	HeaderETag = "ETag"
+)
+
+const (
+	// defaultPageSize is the number of scaffolds listed per page unless a limit is requested
+	defaultPageSize = 20
+	// maxPageSize is the largest number of scaffolds listed per page
+	maxPageSize = 100
+)
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// ValidationErrorResponse represents a response listing every validation problem
+type ValidationErrorResponse struct {
+	Error    string            `json:"error"`
+	Problems []service.Problem `json:"problems,omitempty"`
+}
+
 // HealthResponse represents a health check response
 type HealthResponse struct {
 	Status string `json:"status" example:"OK"`
@@ -21,43 +56,97 @@ type HealthResponse struct {
 
 // GenerateResponse represents a successful scaffold generation response
 type GenerateResponse struct {
-	ID      string `json:"id"`
-	Message string `json:"message"`
+	ID              string `json:"id"`
+	Message         string `json:"message"`
+	TemplateVersion string `json:"templateVersion,omitempty"`
+	SHA256          string `json:"sha256"`              // Hex-encoded SHA-256 of the archive
+	Signature       string `json:"signature,omitempty"` // Base64-encoded ed25519 signature of the archive
+
+	// Signed download link, issued when downloads require one
+	DownloadURL       string     `json:"downloadUrl,omitempty"`
+	DownloadExpiresAt *time.Time `json:"downloadExpiresAt,omitempty"`
+}
+
+// DownloadLinkRequest represents a request for a signed download link
+type DownloadLinkRequest struct {
+	OneTime   bool `json:"oneTime"`             // Invalidate the link after its first download
+	ExpiresIn int  `json:"expiresIn,omitempty"` // Lifetime of the link in seconds; the server default when zero
+}
+
+// DownloadLinkResponse represents a signed download link
+type DownloadLinkResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	OneTime   bool      `json:"oneTime"`
+}
+
+// KeysResponse lists the public keys that verify archive signatures
+type KeysResponse struct {
+	Keys []*model.PublicKey `json:"keys"`
+}
+
+// FeatureResponse represents a feature and whether the caller may request it
+type FeatureResponse struct {
+	*model.Feature
+	Entitled bool `json:"entitled"`
+}
+
+// FeaturesResponse represents the available features, split into regular and
+// premium features, and the plan of the caller
+type FeaturesResponse struct {
+	Features        []FeatureResponse `json:"features"`
+	PremiumFeatures []FeatureResponse `json:"premiumFeatures"`
+	Plan            *model.Plan       `json:"plan,omitempty"`
 }
 
 // GeneratorHandler handles API requests related to scaffold generation
 type GeneratorHandler struct {
 	generatorService service.GeneratorService
+
+	// Optional collaborators configured through GeneratorHandlerOption
+	usageService  service.UsageService
+	downloadLinks service.DownloadLinkService
+}
+
+// GeneratorHandlerOption configures optional collaborators of the generator handler
+type GeneratorHandlerOption func(*GeneratorHandler)
+
+// WithUsageService enforces the generation quotas of clients and serves their usage
+func WithUsageService(usageService service.UsageService) GeneratorHandlerOption {
+	return func(h *GeneratorHandler) {
+		h.usageService = usageService
+	}
+}
+
+// WithDownloadLinks requires downloads to present a signed link and issues such links
+func WithDownloadLinks(downloadLinks service.DownloadLinkService) GeneratorHandlerOption {
+	return func(h *GeneratorHandler) {
+		h.downloadLinks = downloadLinks
+	}
 }
 
 // NewGeneratorHandler creates a new generator handler
-func NewGeneratorHandler(generatorService service.GeneratorService) *GeneratorHandler {
-	return &GeneratorHandler{
+func NewGeneratorHandler(generatorService service.GeneratorService, opts ...GeneratorHandlerOption) *GeneratorHandler {
+	h := &GeneratorHandler{
 		generatorService: generatorService,
 	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
 
-// HandleHealthCheck godoc
-// @Summary Health check endpoint
-// @Description Check if the API is running
-// @Tags system
-// @Produce json
-// @Success 200 {object} HealthResponse
-// @Router /health [get]
+// HandleHealthCheck reports that the API is running
 func (h *GeneratorHandler) HandleHealthCheck(c echo.Context) error {
 	return c.JSON(http.StatusOK, HealthResponse{
 		Status: "OK",
 	})
 }
 
-// HandleListFeatures godoc
-// @Summary List features endpoint
-// @Description Get a list of all available features
-// @Tags features
-// @Produce json
-// @Success 200 {object} map[string]interface{}
-// @Failure 500 {object} ErrorResponse
-// @Router /features [get]
+// HandleListFeatures returns the available features, split into regular and
+// premium features, marking the ones the caller is entitled to
 func (h *GeneratorHandler) HandleListFeatures(c echo.Context) error {
 	features, err := h.generatorService.GetAvailableFeatures()
 	if err != nil {
@@ -66,32 +155,28 @@ func (h *GeneratorHandler) HandleListFeatures(c echo.Context) error {
 		})
 	}
 
+	plan := auth.PlanFrom(c)
+
 	// Separate regular and premium features
-	regularFeatures := make([]*model.Feature, 0)
-	premiumFeatures := make([]*model.Feature, 0)
+	regularFeatures := make([]FeatureResponse, 0)
+	premiumFeatures := make([]FeatureResponse, 0)
 
 	for _, feature := range features {
 		if feature.IsPremium {
-			premiumFeatures = append(premiumFeatures, feature)
+			premiumFeatures = append(premiumFeatures, FeatureResponse{Feature: feature, Entitled: plan.Entitles(feature.ID)})
 		} else {
-			regularFeatures = append(regularFeatures, feature)
+			regularFeatures = append(regularFeatures, FeatureResponse{Feature: feature, Entitled: true})
 		}
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"features":        regularFeatures,
-		"premiumFeatures": premiumFeatures,
+	return c.JSON(http.StatusOK, FeaturesResponse{
+		Features:        regularFeatures,
+		PremiumFeatures: premiumFeatures,
+		Plan:            plan,
 	})
 }
 
-// HandleListTemplates godoc
-// @Summary List templates endpoint
-// @Description Get a list of all available templates
-// @Tags templates
-// @Produce json
-// @Success 200 {array} model.Template
-// @Failure 500 {object} ErrorResponse
-// @Router /templates [get]
+// HandleListTemplates returns every available template
 func (h *GeneratorHandler) HandleListTemplates(c echo.Context) error {
 	templates, err := h.generatorService.GetAllTemplates()
 	if err != nil {
@@ -103,16 +188,31 @@ func (h *GeneratorHandler) HandleListTemplates(c echo.Context) error {
 	return c.JSON(http.StatusOK, templates)
 }
 
-// HandleGenerateScaffold godoc
-// @Summary Generate scaffold endpoint
-// @Description Generate a scaffold project based on provided options
-// @Tags generator
-// @Accept json
-// @Produce json
-// @Param options body model.ScaffoldOptions true "Scaffold options"
-// @Success 200 {object} GenerateResponse
-// @Failure 400 {object} ErrorResponse
-// @Router /generate [post]
+// HandleListTemplateVersions returns every available version of a template, newest first
+func (h *GeneratorHandler) HandleListTemplateVersions(c echo.Context) error {
+	versions, err := h.generatorService.GetTemplateVersions(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, versions)
+}
+
+// HandleGetOptionsSchema returns the JSON Schema that generation requests are validated against
+func (h *GeneratorHandler) HandleGetOptionsSchema(c echo.Context) error {
+	schema, err := h.generatorService.GetOptionsSchema()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to build options schema",
+		})
+	}
+
+	return c.JSON(http.StatusOK, schema)
+}
+
+// HandleGenerateScaffold generates a scaffold from the options in the request body
 func (h *GeneratorHandler) HandleGenerateScaffold(c echo.Context) error {
 	// Parse request body
 	options := new(model.ScaffoldOptions)
@@ -122,38 +222,543 @@ func (h *GeneratorHandler) HandleGenerateScaffold(c echo.Context) error {
 		})
 	}
 
+	// Identical requests share an archive unless a fresh one is requested
+	if raw := c.QueryParam("fresh"); raw != "" {
+		fresh, err := strconv.ParseBool(raw)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ValidationErrorResponse{
+				Error:    "invalid generation query",
+				Problems: []service.Problem{{Field: "fresh", Code: service.ProblemInvalidValue, Message: "fresh must be true or false"}},
+			})
+		}
+		options.Fresh = fresh
+	}
+
+	// Premium features need an API key on a plan that includes them, also when
+	// they come from a preset. Options that do not resolve fail to generate below.
+	if resolved, err := h.generatorService.ResolveOptions(*options); err == nil {
+		*options = resolved
+	}
+	key := auth.APIKeyFrom(c)
+	problems, err := entitlementProblems(h.generatorService, key, options.PremiumFeatures, "premiumFeatures")
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to retrieve features",
+		})
+	}
+	if len(problems) > 0 && key == nil {
+		return c.JSON(http.StatusPaymentRequired, ValidationErrorResponse{
+			Error:    "premium features require an API key",
+			Problems: problems,
+		})
+	}
+	if len(problems) > 0 {
+		return c.JSON(http.StatusForbidden, ValidationErrorResponse{
+			Error:    fmt.Sprintf("plan %s does not include the requested premium features", key.Plan.ID),
+			Problems: problems,
+		})
+	}
+
+	// Count the generation against the quotas of the client, who owns the scaffold
+	client := ratelimit.Client(c)
+	options.Owner = client
+	if h.usageService != nil {
+		err := h.usageService.ReserveGeneration(client, auth.PlanFrom(c))
+		var quotaErr *service.QuotaError
+		if errors.As(err, &quotaErr) {
+			return ratelimit.TooManyRequests(c, quotaErr)
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to check generation quota",
+			})
+		}
+	}
+
 	// Generate scaffold
 	scaffold, err := h.generatorService.GenerateScaffold(*options)
+	if h.usageService != nil {
+		var quotaErr *service.QuotaError
+		if err := h.usageService.CompleteGeneration(client, scaffold); errors.As(err, &quotaErr) {
+			// The archive does not fit in the storage quota, so it is not kept
+			if err := h.generatorService.DeleteScaffold(scaffold.ID); err != nil {
+				return c.JSON(http.StatusInternalServerError, ErrorResponse{
+					Error: "Failed to delete scaffold over the storage quota",
+				})
+			}
+			return ratelimit.TooManyRequests(c, quotaErr)
+		}
+	}
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		return c.JSON(http.StatusBadRequest, ValidationErrorResponse{
+			Error:    validationErr.Message,
+			Problems: validationErr.Problems,
+		})
+	}
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error: err.Error(),
 		})
 	}
 
-	return c.JSON(http.StatusOK, GenerateResponse{
-		ID:      scaffold.ID,
-		Message: "Scaffold generated successfully",
+	response := GenerateResponse{
+		ID:              scaffold.ID,
+		Message:         "Scaffold generated successfully",
+		TemplateVersion: scaffold.TemplateVersion,
+		SHA256:          scaffold.SHA256,
+		Signature:       scaffold.Signature,
+	}
+	if h.downloadLinks != nil {
+		link, err := h.downloadLinks.IssueLink(scaffold.ID, 0, false)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to issue download link",
+			})
+		}
+		response.DownloadURL = downloadURL(link)
+		response.DownloadExpiresAt = &link.ExpiresAt
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// HandleListScaffolds returns a page of the scaffolds generated by the caller,
+// newest first, filtered by the query parameters
+func (h *GeneratorHandler) HandleListScaffolds(c echo.Context) error {
+	filter, problems := scaffoldFilter(c)
+	if len(problems) > 0 {
+		return c.JSON(http.StatusBadRequest, ValidationErrorResponse{
+			Error:    "invalid scaffold query",
+			Problems: problems,
+		})
+	}
+	filter.Owner = ratelimit.Client(c)
+
+	page, err := h.generatorService.ListScaffolds(filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to list scaffolds",
+		})
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// HandleGetScaffold returns the metadata and options of a scaffold generated by the caller
+func (h *GeneratorHandler) HandleGetScaffold(c echo.Context) error {
+	scaffold := h.ownScaffold(c)
+	if scaffold == nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Scaffold not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, scaffold)
+}
+
+// HandleDeleteScaffold removes a scaffold generated by the caller and its archive
+func (h *GeneratorHandler) HandleDeleteScaffold(c echo.Context) error {
+	scaffold := h.ownScaffold(c)
+	if scaffold == nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Scaffold not found",
+		})
+	}
+
+	err := h.generatorService.DeleteScaffold(scaffold.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Scaffold not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to delete scaffold",
+		})
+	}
+
+	if h.usageService != nil {
+		h.usageService.ReleaseStorage(scaffold.Owner, scaffold)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// HandleIssueDownloadLink issues a signed download link for a scaffold of the caller
+func (h *GeneratorHandler) HandleIssueDownloadLink(c echo.Context) error {
+	if h.downloadLinks == nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Download links are not enabled",
+		})
+	}
+
+	scaffold := h.ownScaffold(c)
+	if scaffold == nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Scaffold not found",
+		})
+	}
+
+	req := new(DownloadLinkRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+
+	link, err := h.downloadLinks.IssueLink(scaffold.ID, time.Duration(req.ExpiresIn)*time.Second, req.OneTime)
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		return c.JSON(http.StatusBadRequest, ValidationErrorResponse{
+			Error:    validationErr.Message,
+			Problems: validationErr.Problems,
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to issue download link",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, DownloadLinkResponse{
+		URL:       downloadURL(link),
+		ExpiresAt: link.ExpiresAt,
+		OneTime:   link.OneTime(),
 	})
 }
 
-// HandleDownloadScaffold godoc
-// @Summary Download scaffold endpoint
-// @Description Download a generated scaffold project by ID
-// @Tags generator
-// @Produce octet-stream
-// @Param id path string true "Scaffold ID"
-// @Success 200 {file} file
-// @Failure 404 {object} ErrorResponse
-// @Router /download/{id} [get]
+// ownScaffold returns the scaffold of the id parameter if the caller generated
+// it, or nil; scaffolds of other clients are reported as not found
+func (h *GeneratorHandler) ownScaffold(c echo.Context) *model.GeneratedScaffold {
+	scaffold, err := h.generatorService.GetScaffold(c.Param("id"))
+	if err != nil || scaffold.Owner != ratelimit.Client(c) {
+		return nil
+	}
+	return scaffold
+}
+
+// scaffoldFilter parses the query parameters of a scaffold listing, reporting every invalid one
+func scaffoldFilter(c echo.Context) (model.ScaffoldFilter, []service.Problem) {
+	filter := model.ScaffoldFilter{
+		RouterType:   c.QueryParam("routerType"),
+		DatabaseType: c.QueryParam("databaseType"),
+		Feature:      c.QueryParam("feature"),
+		Limit:        defaultPageSize,
+	}
+
+	var problems []service.Problem
+	add := func(field, code, format string, args ...interface{}) {
+		problems = append(problems, service.Problem{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, param := range []struct {
+		name    string
+		target  *time.Time
+		dateEnd bool
+	}{
+		{name: "from", target: &filter.CreatedAfter},
+		{name: "to", target: &filter.CreatedBefore, dateEnd: true},
+	} {
+		raw := c.QueryParam(param.name)
+		if raw == "" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			*param.target = t
+		} else if t, err := time.Parse(time.DateOnly, raw); err == nil {
+			// A date as the end of the range includes the whole day
+			if param.dateEnd {
+				t = t.Add(24 * time.Hour)
+			}
+			*param.target = t
+		} else {
+			add(param.name, service.ProblemInvalidFormat, "%q is not an RFC 3339 time or a date (YYYY-MM-DD)", raw)
+		}
+	}
+
+	if raw := c.QueryParam("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			add("offset", service.ProblemInvalidValue, "offset must be a non-negative integer")
+		}
+		filter.Offset = offset
+	}
+
+	if raw := c.QueryParam("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			add("limit", service.ProblemInvalidValue, "limit must be between 1 and %d", maxPageSize)
+		}
+		filter.Limit = limit
+	}
+
+	return filter, problems
+}
+
+// HandleGetUsage returns how much of its rate limit and quotas the caller has used
+func (h *GeneratorHandler) HandleGetUsage(c echo.Context) error {
+	if h.usageService == nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Usage is not tracked",
+		})
+	}
+
+	return c.JSON(http.StatusOK, h.usageService.GetUsage(ratelimit.Client(c), auth.PlanFrom(c)))
+}
+
+// entitlementProblems returns a problem, at field, for every requested premium
+// feature the plan of key does not include. Unknown features are left to option
+// validation.
+func entitlementProblems(generatorService service.GeneratorService, key *model.APIKey, requested []string, field string) ([]service.Problem, error) {
+	if len(requested) == 0 {
+		return nil, nil
+	}
+
+	features, err := generatorService.GetAvailableFeatures()
+	if err != nil {
+		return nil, err
+	}
+	premium := make(map[string]bool)
+	for _, feature := range features {
+		premium[feature.ID] = feature.IsPremium
+	}
+
+	var plan *model.Plan
+	if key != nil {
+		plan = key.Plan
+	}
+
+	var problems []service.Problem
+	for i, id := range requested {
+		if !premium[id] || plan.Entitles(id) {
+			continue
+		}
+
+		message := fmt.Sprintf("premium feature %q requires an API key on a plan that includes it", id)
+		if plan != nil {
+			message = fmt.Sprintf("premium feature %q is not included in plan %s", id, plan.ID)
+		}
+		problems = append(problems, service.Problem{
+			Field:   fmt.Sprintf("%s[%d]", field, i),
+			Code:    service.ProblemNotEntitled,
+			Message: message,
+		})
+	}
+
+	return problems, nil
+}
+
+// HandleDownloadScaffold serves the ZIP archive of a generated scaffold. It
+// answers conditional and range requests, so downloads can be cached and resumed.
 func (h *GeneratorHandler) HandleDownloadScaffold(c echo.Context) error {
 	id := c.Param("id")
 
+	// With download links enabled, only signed links that are still valid work.
+	// One-time links are only used up once the archive is actually sent.
+	var link *model.DownloadLink
+	if h.downloadLinks != nil {
+		link = downloadLinkFrom(c, id)
+		if err := h.downloadLinks.CheckLink(link); err != nil {
+			return c.JSON(http.StatusForbidden, ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+	}
+
 	scaffold, err := h.generatorService.GetScaffold(id)
+	if status, message := unavailable(scaffold, err); status != 0 {
+		return c.JSON(status, ErrorResponse{
+			Error: message,
+		})
+	}
+
+	archive, err := os.Open(scaffold.FilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c.JSON(http.StatusGone, ErrorResponse{
+			Error: "Scaffold archive is no longer available",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to read scaffold archive",
+		})
+	}
+	defer archive.Close()
+
+	info, err := archive.Stat()
 	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to read scaffold archive",
+		})
+	}
+
+	header := c.Response().Header()
+	if digest := reprDigest(scaffold.SHA256); digest != "" {
+		header.Set(HeaderReprDigest, digest)
+		header.Set(HeaderETag, `"`+scaffold.SHA256+`"`)
+	}
+	name := archiveName(scaffold)
+	header.Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+
+	var w http.ResponseWriter = c.Response()
+	if link != nil && link.OneTime() {
+		w = &redeemingWriter{ResponseWriter: w, redeem: func() error {
+			return h.downloadLinks.RedeemLink(link)
+		}}
+	}
+
+	// ServeContent handles If-None-Match against the ETag, Range and If-Range
+	http.ServeContent(w, c.Request(), name, info.ModTime(), archive)
+	return nil
+}
+
+// redeemingWriter uses up a one-time download link when the archive is about
+// to be sent, so not modified and error responses leave the link usable. If
+// the link is used up in the meantime, it answers forbidden instead.
+type redeemingWriter struct {
+	http.ResponseWriter
+	redeem  func() error
+	refused bool
+}
+
+// WriteHeader redeems the link before a full or partial archive is sent
+func (w *redeemingWriter) WriteHeader(code int) {
+	if code != http.StatusOK && code != http.StatusPartialContent {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	err := w.redeem()
+	if err == nil {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	w.refused = true
+	header := w.Header()
+	for _, name := range []string{echo.HeaderContentLength, "Content-Range", "Accept-Ranges", echo.HeaderLastModified,
+		echo.HeaderContentDisposition, HeaderETag, HeaderReprDigest} {
+		header.Del(name)
+	}
+	header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	w.ResponseWriter.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w.ResponseWriter).Encode(ErrorResponse{Error: err.Error()})
+}
+
+// Write discards the archive once the link has been refused
+func (w *redeemingWriter) Write(b []byte) (int, error) {
+	if w.refused {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// unavailable returns the status and message of a download of a scaffold that
+// cannot be served, or a zero status if it can. Expired scaffolds are gone
+// rather than not found.
+func unavailable(scaffold *model.GeneratedScaffold, err error) (int, string) {
+	switch {
+	case errors.Is(err, repository.ErrExpired), err == nil && scaffold.Expired(time.Now()):
+		return http.StatusGone, "Scaffold has expired"
+	case err != nil:
+		return http.StatusNotFound, "Scaffold not found"
+	}
+	return 0, ""
+}
+
+// serviceError maps an error of a service to a response
+func serviceError(c echo.Context, err error) error {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return c.JSON(http.StatusUnprocessableEntity, ValidationErrorResponse{
+			Error:    validationErr.Message,
+			Problems: validationErr.Problems,
+		})
+	case errors.Is(err, repository.ErrNotFound):
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
+
+// archiveName returns the file name a scaffold archive is downloaded as
+func archiveName(scaffold *model.GeneratedScaffold) string {
+	if scaffold.ArchiveName == "" {
+		return scaffold.ID + ".zip"
+	}
+	return scaffold.ArchiveName
+}
+
+// HandleDownloadSignature serves the detached ed25519 signature of a scaffold archive
+func (h *GeneratorHandler) HandleDownloadSignature(c echo.Context) error {
+	id := c.Param("id")
+
+	scaffold, err := h.generatorService.GetScaffold(id)
+	if status, message := unavailable(scaffold, err); status != 0 {
+		return c.JSON(status, ErrorResponse{
+			Error: message,
+		})
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(scaffold.Signature)
+	if err != nil || len(signature) == 0 {
 		return c.JSON(http.StatusNotFound, ErrorResponse{
-			Error: "Scaffold not found",
+			Error: "Scaffold is not signed",
+		})
+	}
+
+	name := archiveName(scaffold) + ".sig"
+	c.Response().Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	return c.Blob(http.StatusOK, echo.MIMEOctetStream, signature)
+}
+
+// HandleListKeys returns the public keys that verify archive signatures
+func (h *GeneratorHandler) HandleListKeys(c echo.Context) error {
+	keys, err := h.generatorService.GetPublicKeys()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to retrieve keys",
 		})
 	}
 
-	return c.File(scaffold.FilePath)
+	return c.JSON(http.StatusOK, KeysResponse{Keys: keys})
+}
+
+// downloadURL returns the path and query of a signed download link
+func downloadURL(link *model.DownloadLink) string {
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(link.ExpiresAt.Unix(), 10))
+	if link.OneTime() {
+		query.Set("once", link.Nonce)
+	}
+	query.Set("sig", link.Signature)
+	return "/api/download/" + url.PathEscape(link.ScaffoldID) + "?" + query.Encode()
+}
+
+// downloadLinkFrom returns the download link presented in the query of a
+// request; a missing or malformed expiry is left zero and fails the signature
+func downloadLinkFrom(c echo.Context, id string) *model.DownloadLink {
+	link := &model.DownloadLink{
+		ScaffoldID: id,
+		Nonce:      c.QueryParam("once"),
+		Signature:  c.QueryParam("sig"),
+	}
+	if expires, err := strconv.ParseInt(c.QueryParam("expires"), 10, 64); err == nil {
+		link.ExpiresAt = time.Unix(expires, 0)
+	}
+	return link
+}
+
+// reprDigest returns the Repr-Digest header value of a hex-encoded SHA-256, or
+// an empty string if it is not one
+func reprDigest(hexSum string) string {
+	sum, err := hex.DecodeString(hexSum)
+	if err != nil || len(sum) != sha256.Size {
+		return ""
+	}
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(sum) + ":"
 }
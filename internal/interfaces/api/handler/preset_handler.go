@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	"github.com/regiwitanto/go-scaffold/internal/domain/service"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/auth"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/ratelimit"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PresetRequest represents the request body for creating or replacing a preset
+type PresetRequest struct {
+	ID          string                `json:"id"`
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Options     model.ScaffoldOptions `json:"options"`
+}
+
+// PresetHandler handles API requests for managing scaffold presets
+type PresetHandler struct {
+	presetService    service.PresetService
+	generatorService service.GeneratorService
+}
+
+// NewPresetHandler creates a new preset handler, checking the premium features
+// of presets against the features of the generator service
+func NewPresetHandler(presetService service.PresetService, generatorService service.GeneratorService) *PresetHandler {
+	return &PresetHandler{
+		presetService:    presetService,
+		generatorService: generatorService,
+	}
+}
+
+// HandleListPresets returns every preset, built-in ones included
+func (h *PresetHandler) HandleListPresets(c echo.Context) error {
+	presets, err := h.presetService.ListPresets()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to list presets",
+		})
+	}
+
+	return c.JSON(http.StatusOK, presets)
+}
+
+// HandleGetPreset returns a preset by ID
+func (h *PresetHandler) HandleGetPreset(c echo.Context) error {
+	preset, err := h.presetService.GetPreset(c.Param("id"))
+	if err != nil {
+		return serviceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, preset)
+}
+
+// HandleCreatePreset stores a new preset owned by the caller, who needs an API key
+func (h *PresetHandler) HandleCreatePreset(c echo.Context) error {
+	if auth.APIKeyFrom(c) == nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Creating presets requires an API key",
+		})
+	}
+
+	req := new(PresetRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+	if status, response := h.entitlementRefusal(c, req.Options); status != 0 {
+		return c.JSON(status, response)
+	}
+
+	preset, err := h.presetService.CreatePreset(req.preset(ratelimit.Client(c)))
+	if err != nil {
+		return serviceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, preset)
+}
+
+// HandleUpdatePreset replaces a preset created by the caller
+func (h *PresetHandler) HandleUpdatePreset(c echo.Context) error {
+	if status, message := h.presetChangeRefusal(c); status != 0 {
+		return c.JSON(status, ErrorResponse{Error: message})
+	}
+
+	req := new(PresetRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+	req.ID = c.Param("id")
+	if status, response := h.entitlementRefusal(c, req.Options); status != 0 {
+		return c.JSON(status, response)
+	}
+
+	preset, err := h.presetService.UpdatePreset(req.preset(ratelimit.Client(c)))
+	if err != nil {
+		return serviceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, preset)
+}
+
+// HandleDeletePreset removes a preset created by the caller
+func (h *PresetHandler) HandleDeletePreset(c echo.Context) error {
+	if status, message := h.presetChangeRefusal(c); status != 0 {
+		return c.JSON(status, ErrorResponse{Error: message})
+	}
+
+	if err := h.presetService.DeletePreset(c.Param("id")); err != nil {
+		return serviceError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// presetChangeRefusal returns the status and error refusing the caller a change
+// of the preset of the id parameter, or 0 if the caller created the preset
+func (h *PresetHandler) presetChangeRefusal(c echo.Context) (int, string) {
+	if auth.APIKeyFrom(c) == nil {
+		return http.StatusUnauthorized, "Changing presets requires an API key"
+	}
+
+	preset, err := h.presetService.GetPreset(c.Param("id"))
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound, err.Error()
+	case err != nil:
+		return http.StatusInternalServerError, err.Error()
+	case preset.BuiltIn:
+		return http.StatusForbidden, "Built-in presets cannot be changed"
+	case preset.Owner != ratelimit.Client(c):
+		return http.StatusForbidden, "Preset belongs to another client"
+	}
+
+	return 0, ""
+}
+
+// entitlementRefusal returns the status and response refusing the caller a
+// preset with options requesting premium features its plan does not include,
+// or 0. Presets are shared, so they would otherwise grant those features to anyone.
+func (h *PresetHandler) entitlementRefusal(c echo.Context, options model.ScaffoldOptions) (int, interface{}) {
+	key := auth.APIKeyFrom(c)
+	problems, err := entitlementProblems(h.generatorService, key, options.PremiumFeatures, "options.premiumFeatures")
+	if err != nil {
+		return http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve features"}
+	}
+	if len(problems) > 0 {
+		return http.StatusForbidden, ValidationErrorResponse{
+			Error:    fmt.Sprintf("plan %s does not include the requested premium features", key.Plan.ID),
+			Problems: problems,
+		}
+	}
+
+	return 0, nil
+}
+
+// preset returns the preset described by the request, owned by owner
+func (r *PresetRequest) preset(owner string) *model.Preset {
+	return &model.Preset{
+		ID:          r.ID,
+		Name:        r.Name,
+		Description: r.Description,
+		Options:     r.Options,
+		Owner:       owner,
+	}
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TemplateAdminHandler handles admin API requests for managing uploaded templates
+type TemplateAdminHandler struct {
+	adminService service.TemplateAdminService
+}
+
+// NewTemplateAdminHandler creates a new template admin handler
+func NewTemplateAdminHandler(adminService service.TemplateAdminService) *TemplateAdminHandler {
+	return &TemplateAdminHandler{
+		adminService: adminService,
+	}
+}
+
+// HandleUploadTemplate validates an uploaded template archive and stores it as a draft
+func (h *TemplateAdminHandler) HandleUploadTemplate(c echo.Context) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Missing template archive",
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Failed to read template archive",
+		})
+	}
+	defer file.Close()
+
+	upload := model.TemplateUpload{
+		ID:          c.FormValue("id"),
+		Version:     c.FormValue("version"),
+		Name:        c.FormValue("name"),
+		Description: c.FormValue("description"),
+	}
+
+	tmpl, err := h.adminService.UploadTemplate(upload, file)
+	if err != nil {
+		return serviceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, tmpl)
+}
+
+// HandleListTemplates returns every uploaded template version, including drafts
+func (h *TemplateAdminHandler) HandleListTemplates(c echo.Context) error {
+	templates, err := h.adminService.ListTemplates()
+	if err != nil {
+		return serviceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, templates)
+}
+
+// HandlePublishTemplate makes an uploaded template version available for generation
+func (h *TemplateAdminHandler) HandlePublishTemplate(c echo.Context) error {
+	tmpl, err := h.adminService.PublishTemplate(c.Param("id"), c.Param("version"))
+	if err != nil {
+		return serviceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, tmpl)
+}
+
+// HandleDeprecateTemplate keeps a published template version available only when requested by version
+func (h *TemplateAdminHandler) HandleDeprecateTemplate(c echo.Context) error {
+	tmpl, err := h.adminService.DeprecateTemplate(c.Param("id"), c.Param("version"))
+	if err != nil {
+		return serviceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, tmpl)
+}
+
+// HandleDeleteTemplate removes an uploaded template version
+func (h *TemplateAdminHandler) HandleDeleteTemplate(c echo.Context) error {
+	if err := h.adminService.DeleteTemplate(c.Param("id"), c.Param("version")); err != nil {
+		return serviceError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	"github.com/regiwitanto/go-scaffold/internal/domain/service"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/auth"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/ratelimit"
+
+	"github.com/labstack/echo/v4"
+)
+
+// errWebhookKeyRequired refuses webhook requests without an API key
+var errWebhookKeyRequired = errors.New("Webhooks require an API key")
+
+// WebhookRequest represents the request body for creating or replacing a webhook
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// WebhookHandler handles API requests for managing the webhooks of the caller
+type WebhookHandler struct {
+	webhookService service.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookService service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// HandleListWebhooks returns the webhooks of the caller, without their secrets
+func (h *WebhookHandler) HandleListWebhooks(c echo.Context) error {
+	if auth.APIKeyFrom(c) == nil {
+		return webhookError(c, errWebhookKeyRequired)
+	}
+
+	webhooks, err := h.webhookService.ListWebhooks(ratelimit.Client(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to list webhooks",
+		})
+	}
+
+	redacted := make([]*model.Webhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		redacted = append(redacted, webhook.Redacted())
+	}
+	return c.JSON(http.StatusOK, redacted)
+}
+
+// HandleCreateWebhook subscribes a new webhook of the caller, returning its
+// signing secret this once
+func (h *WebhookHandler) HandleCreateWebhook(c echo.Context) error {
+	if auth.APIKeyFrom(c) == nil {
+		return webhookError(c, errWebhookKeyRequired)
+	}
+
+	req := new(WebhookRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(req.webhook("", ratelimit.Client(c)))
+	if err != nil {
+		return serviceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, webhook)
+}
+
+// HandleGetWebhook returns a webhook of the caller, without its secret
+func (h *WebhookHandler) HandleGetWebhook(c echo.Context) error {
+	webhook, err := h.ownWebhook(c)
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, webhook.Redacted())
+}
+
+// HandleUpdateWebhook replaces the URL and events of a webhook of the caller
+func (h *WebhookHandler) HandleUpdateWebhook(c echo.Context) error {
+	webhook, err := h.ownWebhook(c)
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	req := new(WebhookRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+
+	updated, err := h.webhookService.UpdateWebhook(req.webhook(webhook.ID, webhook.Owner))
+	if err != nil {
+		return serviceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, updated.Redacted())
+}
+
+// HandleDeleteWebhook unsubscribes a webhook of the caller
+func (h *WebhookHandler) HandleDeleteWebhook(c echo.Context) error {
+	webhook, err := h.ownWebhook(c)
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	if err := h.webhookService.DeleteWebhook(webhook.ID); err != nil {
+		return serviceError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// HandleListDeliveries returns the delivery log of a webhook of the caller,
+// newest first, optionally only deliveries in the state of the status parameter
+func (h *WebhookHandler) HandleListDeliveries(c echo.Context) error {
+	webhook, err := h.ownWebhook(c)
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(webhook.ID, c.QueryParam("status"))
+	if err != nil {
+		return serviceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+// HandlePingWebhook queues a webhook.ping delivery to a webhook of the caller
+func (h *WebhookHandler) HandlePingWebhook(c echo.Context) error {
+	webhook, err := h.ownWebhook(c)
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	delivery, err := h.webhookService.Ping(webhook.ID)
+	if err != nil {
+		return serviceError(c, err)
+	}
+
+	return c.JSON(http.StatusAccepted, delivery)
+}
+
+// ownWebhook returns the webhook of the id parameter if it belongs to the
+// caller; webhooks of other clients are reported as not found
+func (h *WebhookHandler) ownWebhook(c echo.Context) (*model.Webhook, error) {
+	if auth.APIKeyFrom(c) == nil {
+		return nil, errWebhookKeyRequired
+	}
+
+	webhook, err := h.webhookService.GetWebhook(c.Param("id"))
+	if err != nil {
+		return nil, err
+	}
+	if webhook.Owner != ratelimit.Client(c) {
+		return nil, fmt.Errorf("webhook %s: %w", c.Param("id"), repository.ErrNotFound)
+	}
+
+	return webhook, nil
+}
+
+// webhookError writes the error response of a failed webhook request
+func webhookError(c echo.Context, err error) error {
+	if errors.Is(err, errWebhookKeyRequired) {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+	}
+	return serviceError(c, err)
+}
+
+// webhook returns the webhook described by the request, with an ID and owner
+func (r *WebhookRequest) webhook(id, owner string) *model.Webhook {
+	return &model.Webhook{
+		ID:     id,
+		URL:    r.URL,
+		Events: r.Events,
+		Owner:  owner,
+	}
+}
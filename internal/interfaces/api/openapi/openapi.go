@@ -0,0 +1,362 @@
+// Package openapi builds OpenAPI 3.1 documents from route descriptions,
+// deriving the schemas of request and response bodies from Go types
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+)
+
+// Version is the OpenAPI version of the documents built by this package
+const Version = "3.1.0"
+
+// MIMEApplicationJSON is the default content type of request and response bodies
+const MIMEApplicationJSON = "application/json"
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers,omitempty"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []map[string][]string `json:"security,omitempty"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a base URL of the API
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem holds the operations of a path by lowercase HTTP method
+type PathItem map[string]*Operation
+
+// Operation is a single API operation
+type Operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter is a path or query parameter of an operation
+type Parameter struct {
+	Name        string            `json:"name"`
+	In          string            `json:"in"`
+	Description string            `json:"description,omitempty"`
+	Required    bool              `json:"required"`
+	Schema      *model.JSONSchema `json:"schema"`
+}
+
+// RequestBody is the body of a request
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+// Response is a response of an operation
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// Header is a header of a response
+type Header struct {
+	Description string            `json:"description,omitempty"`
+	Schema      *model.JSONSchema `json:"schema"`
+}
+
+// MediaType holds the schema of a body of one content type
+type MediaType struct {
+	Schema *model.JSONSchema `json:"schema,omitempty"`
+}
+
+// Components holds the schemas and security schemes referenced by operations
+type Components struct {
+	Schemas         map[string]*model.JSONSchema `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme    `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme is a way of authenticating requests
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	Name        string `json:"name,omitempty"` // Name of the header, query parameter or cookie of an apiKey scheme
+	In          string `json:"in,omitempty"`   // Location of the key of an apiKey scheme
+	Description string `json:"description,omitempty"`
+}
+
+// Route describes an operation of the API. Bodies are given as values of the
+// Go type that is encoded, or as a *model.JSONSchema used as is.
+type Route struct {
+	Method      string            // HTTP method, e.g. http.MethodGet
+	Path        string            // Path in echo syntax, e.g. "/templates/:id/versions"
+	Summary     string            // Short summary
+	Description string            // Longer explanation
+	Tag         string            // Group of the operation
+	Security    string            // Security scheme the operation requires, empty for none
+	Params      map[string]string // Descriptions of the path parameters
+	Query       map[string]string // Descriptions of the optional query parameters
+	Body        interface{}       // Request body, nil for none
+	BodyType    string            // Content type of the request body, defaults to JSON
+	Replies     []Reply           // Possible responses
+}
+
+// Reply describes a possible response of a route
+type Reply struct {
+	Status      int         // HTTP status code
+	Description string      // Description, defaults to the status text
+	Body        interface{} // Response body, nil for none
+	ContentType string      // Content type of the body, defaults to JSON
+
+	Headers map[string]string // Descriptions of response headers by name
+}
+
+// Builder builds an OpenAPI document
+type Builder struct {
+	doc *Document
+}
+
+// NewBuilder creates a builder of a document describing an API served below the server URLs
+func NewBuilder(info Info, servers ...Server) *Builder {
+	return &Builder{
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Servers: servers,
+			Paths:   make(map[string]PathItem),
+			Components: Components{
+				Schemas: make(map[string]*model.JSONSchema),
+			},
+		},
+	}
+}
+
+// AddSecurityScheme registers a security scheme that routes can require by name
+func (b *Builder) AddSecurityScheme(name string, scheme SecurityScheme) {
+	if b.doc.Components.SecuritySchemes == nil {
+		b.doc.Components.SecuritySchemes = make(map[string]SecurityScheme)
+	}
+	b.doc.Components.SecuritySchemes[name] = scheme
+}
+
+// SetSecurity makes operations that do not require a security scheme of their
+// own accept the named scheme; when optional, anonymous requests are accepted too
+func (b *Builder) SetSecurity(name string, optional bool) {
+	b.doc.Security = nil
+	if optional {
+		b.doc.Security = append(b.doc.Security, map[string][]string{})
+	}
+	b.doc.Security = append(b.doc.Security, map[string][]string{name: {}})
+}
+
+// Add adds the operation of a route
+func (b *Builder) Add(route Route) {
+	op := &Operation{
+		Summary:     route.Summary,
+		Description: route.Description,
+		Responses:   make(map[string]*Response),
+	}
+	if route.Tag != "" {
+		op.Tags = []string{route.Tag}
+	}
+	if route.Security != "" {
+		op.Security = []map[string][]string{{route.Security: {}}}
+	}
+
+	segments := strings.Split(route.Path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:        name,
+			In:          "path",
+			Description: route.Params[name],
+			Required:    true,
+			Schema:      &model.JSONSchema{Type: "string"},
+		})
+	}
+
+	query := make([]string, 0, len(route.Query))
+	for name := range route.Query {
+		query = append(query, name)
+	}
+	sort.Strings(query)
+	for _, name := range query {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:        name,
+			In:          "query",
+			Description: route.Query[name],
+			Schema:      &model.JSONSchema{Type: "string"},
+		})
+	}
+
+	if route.Body != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  b.content(route.Body, route.BodyType),
+		}
+	}
+
+	for _, reply := range route.Replies {
+		description := reply.Description
+		if description == "" {
+			description = http.StatusText(reply.Status)
+		}
+		response := &Response{
+			Description: description,
+			Content:     b.content(reply.Body, reply.ContentType),
+		}
+		for name, headerDescription := range reply.Headers {
+			if response.Headers == nil {
+				response.Headers = make(map[string]Header)
+			}
+			response.Headers[name] = Header{Description: headerDescription, Schema: &model.JSONSchema{Type: "string"}}
+		}
+		op.Responses[strconv.Itoa(reply.Status)] = response
+	}
+
+	path := strings.Join(segments, "/")
+	if b.doc.Paths[path] == nil {
+		b.doc.Paths[path] = make(PathItem)
+	}
+	b.doc.Paths[path][strings.ToLower(route.Method)] = op
+}
+
+// Document returns the built document
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+// content returns the media types of a body
+func (b *Builder) content(body interface{}, contentType string) map[string]MediaType {
+	if body == nil && contentType == "" {
+		return nil
+	}
+	if contentType == "" {
+		contentType = MIMEApplicationJSON
+	}
+
+	var schema *model.JSONSchema
+	switch v := body.(type) {
+	case nil:
+	case *model.JSONSchema:
+		schema = v
+	default:
+		schema = b.Schema(reflect.TypeOf(body))
+	}
+
+	return map[string]MediaType{contentType: {Schema: schema}}
+}
+
+// Schema returns the schema of the JSON encoding of a Go type. Named struct
+// types are added to the components and referenced.
+func (b *Builder) Schema(t reflect.Type) *model.JSONSchema {
+	if t == reflect.TypeOf(time.Time{}) {
+		return &model.JSONSchema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return b.Schema(t.Elem())
+	case reflect.String:
+		return &model.JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &model.JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &model.JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &model.JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &model.JSONSchema{Type: "array", Items: b.Schema(t.Elem())}
+	case reflect.Map:
+		return &model.JSONSchema{Type: "object", AdditionalProperties: b.Schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		ref := &model.JSONSchema{Ref: "#/components/schemas/" + t.Name()}
+		if _, ok := b.doc.Components.Schemas[t.Name()]; !ok {
+			// Register the name first so that recursive types terminate
+			b.doc.Components.Schemas[t.Name()] = nil
+			b.doc.Components.Schemas[t.Name()] = b.structSchema(t)
+		}
+		return ref
+	}
+
+	// Interfaces and other kinds accept any value
+	return &model.JSONSchema{}
+}
+
+// structSchema returns the schema of the JSON encoding of a struct type
+func (b *Builder) structSchema(t reflect.Type) *model.JSONSchema {
+	schema := &model.JSONSchema{
+		Type:       "object",
+		Properties: make(map[string]*model.JSONSchema),
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// Fields of embedded structs without a name are encoded inline, even when the struct is unexported
+		embeddedType := field.Type
+		if embeddedType.Kind() == reflect.Ptr {
+			embeddedType = embeddedType.Elem()
+		}
+		if field.Anonymous && name == "" && embeddedType.Kind() == reflect.Struct {
+			embedded := b.structSchema(embeddedType)
+			for property, s := range embedded.Properties {
+				schema.Properties[property] = s
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = b.Schema(field.Type)
+	}
+
+	return schema
+}
+
+// Operations returns the method and path of every operation of the document, sorted, e.g. "GET /health"
+func (d *Document) Operations() []string {
+	var operations []string
+	for path, item := range d.Paths {
+		for method := range item {
+			operations = append(operations, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(operations)
+	return operations
+}
@@ -0,0 +1,89 @@
+// Package ratelimit limits the requests of API clients, identified by their
+// API key or, for anonymous requests, their IP address
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/service"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RateLimit returns middleware that rejects the requests of clients over
+// their rate limit. It must run after auth.APIKey.
+func RateLimit(usage service.UsageService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := usage.Allow(Client(c), auth.PlanFrom(c))
+			var quotaErr *service.QuotaError
+			if errors.As(err, &quotaErr) {
+				return TooManyRequests(c, quotaErr)
+			}
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "Failed to check rate limit",
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// GuardAPIKeys returns middleware that throttles the guessing of API keys by
+// IP address. It must run before auth.APIKey: requests carrying an API key are
+// rejected without checking it while their IP address is over its rate limit,
+// and every key that is rejected takes a request from that limit.
+func GuardAPIKeys(usage service.UsageService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Request().Header.Get(auth.HeaderAPIKey) == "" {
+				return next(c)
+			}
+
+			client := "ip:" + c.RealIP()
+			current := usage.GetUsage(client, nil)
+			if perMinute := current.Limits.RequestsPerMinute; perMinute > 0 && current.RequestsRemaining < 1 {
+				return TooManyRequests(c, &service.QuotaError{
+					Message:    fmt.Sprintf("rate limit of %d requests per minute exceeded", perMinute),
+					RetryAfter: time.Minute / time.Duration(perMinute),
+				})
+			}
+
+			err := next(c)
+			if auth.APIKeyFrom(c) == nil && c.Response().Status == http.StatusUnauthorized {
+				usage.Allow(client, nil)
+			}
+			return err
+		}
+	}
+}
+
+// Client returns the client usage of a request is tracked by: the name of its
+// API key, or its IP address for anonymous requests
+func Client(c echo.Context) string {
+	if key := auth.APIKeyFrom(c); key != nil {
+		return "key:" + key.Name
+	}
+	return "ip:" + c.RealIP()
+}
+
+// TooManyRequests rejects a request over a limit of its client, telling it
+// when to retry if that is known
+func TooManyRequests(c echo.Context, err *service.QuotaError) error {
+	if err.RetryAfter > 0 {
+		seconds := int64(math.Ceil(err.RetryAfter.Seconds()))
+		c.Response().Header().Set(echo.HeaderRetryAfter, strconv.FormatInt(seconds, 10))
+	}
+
+	return c.JSON(http.StatusTooManyRequests, map[string]string{
+		"error": err.Message,
+	})
+}
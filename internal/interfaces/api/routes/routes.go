@@ -3,14 +3,21 @@ package routes
 import (
 	"net/http"
 
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	"github.com/regiwitanto/go-scaffold/internal/domain/service"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/auth"
 	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/handler"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/ratelimit"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 
-// SetupRoutes configures all routes for the application
-func SetupRoutes(e *echo.Echo, generatorHandler *handler.GeneratorHandler) {
+// SetupRoutes configures all routes for the application. Callers of the API
+// routes are authenticated by the API keys they carry, if any, and held to
+// their rate limits when usage is not nil. Invalid API keys count against the
+// rate limit of the IP address they come from.
+func SetupRoutes(e *echo.Echo, generatorHandler *handler.GeneratorHandler, presetHandler *handler.PresetHandler, webhookHandler *handler.WebhookHandler, apiKeys repository.APIKeyRepository, usage service.UsageService) {
 	// Basic middleware
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
@@ -23,12 +30,40 @@ func SetupRoutes(e *echo.Echo, generatorHandler *handler.GeneratorHandler) {
 
 	// API routes
 	api := e.Group("/api")
+	if usage != nil {
+		api.Use(ratelimit.GuardAPIKeys(usage))
+	}
+	api.Use(auth.APIKey(apiKeys))
+	if usage != nil {
+		api.Use(ratelimit.RateLimit(usage))
+	}
 	{
 		api.GET("/health", generatorHandler.HandleHealthCheck)
 		api.GET("/features", generatorHandler.HandleListFeatures)
 		api.GET("/templates", generatorHandler.HandleListTemplates)
+		api.GET("/templates/:id/versions", generatorHandler.HandleListTemplateVersions)
+		api.GET("/schema/options", generatorHandler.HandleGetOptionsSchema)
 		api.POST("/generate", generatorHandler.HandleGenerateScaffold)
 		api.GET("/download/:id", generatorHandler.HandleDownloadScaffold)
+		api.GET("/download/:id/signature", generatorHandler.HandleDownloadSignature)
+		api.GET("/keys", generatorHandler.HandleListKeys)
+		api.GET("/scaffolds", generatorHandler.HandleListScaffolds)
+		api.GET("/scaffolds/:id", generatorHandler.HandleGetScaffold)
+		api.DELETE("/scaffolds/:id", generatorHandler.HandleDeleteScaffold)
+		api.POST("/scaffolds/:id/links", generatorHandler.HandleIssueDownloadLink)
+		api.GET("/usage", generatorHandler.HandleGetUsage)
+		api.GET("/presets", presetHandler.HandleListPresets)
+		api.GET("/presets/:id", presetHandler.HandleGetPreset)
+		api.POST("/presets", presetHandler.HandleCreatePreset)
+		api.PUT("/presets/:id", presetHandler.HandleUpdatePreset)
+		api.DELETE("/presets/:id", presetHandler.HandleDeletePreset)
+		api.GET("/webhooks", webhookHandler.HandleListWebhooks)
+		api.POST("/webhooks", webhookHandler.HandleCreateWebhook)
+		api.GET("/webhooks/:id", webhookHandler.HandleGetWebhook)
+		api.PUT("/webhooks/:id", webhookHandler.HandleUpdateWebhook)
+		api.DELETE("/webhooks/:id", webhookHandler.HandleDeleteWebhook)
+		api.GET("/webhooks/:id/deliveries", webhookHandler.HandleListDeliveries)
+		api.POST("/webhooks/:id/ping", webhookHandler.HandlePingWebhook)
 
 		// API documentation
 		apiDocsHandler := handler.NewApiDocsHandler()
@@ -68,3 +103,15 @@ func SetupRoutes(e *echo.Echo, generatorHandler *handler.GeneratorHandler) {
 		`)
 	})
 }
+
+// SetupAdminRoutes configures the admin routes, which require the admin token
+func SetupAdminRoutes(e *echo.Echo, adminHandler *handler.TemplateAdminHandler, adminToken string) {
+	admin := e.Group("/api/admin", auth.RequireAdminToken(adminToken))
+	{
+		admin.GET("/templates", adminHandler.HandleListTemplates)
+		admin.POST("/templates", adminHandler.HandleUploadTemplate, middleware.BodyLimit("40M"))
+		admin.POST("/templates/:id/:version/publish", adminHandler.HandlePublishTemplate)
+		admin.POST("/templates/:id/:version/deprecate", adminHandler.HandleDeprecateTemplate)
+		admin.DELETE("/templates/:id/:version", adminHandler.HandleDeleteTemplate)
+	}
+}
@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/service"
+)
+
+// Exit codes of the lint-templates and verify commands
+const (
+	ExitOK       = 0 // No errors were found
+	ExitFindings = 1 // At least one error was found
+	ExitUsage    = 2 // The command could not run
+)
+
+// lintRuleDescriptions describes every lint rule for SARIF output
+var lintRuleDescriptions = map[string]string{
+	model.LintRuleParseError:      "Template does not parse",
+	model.LintRuleUnknownField:    "Reference to a field that is not part of the template data",
+	model.LintRuleUnknownFeature:  "Feature ID that is not an available feature",
+	model.LintRuleUnreachableFile: "File whose output is behind conditions that are never true",
+}
+
+// LintTemplates runs the lint-templates command: it lints the template tree
+// in the directory given as argument and writes the findings to stdout as
+// text, JSON or SARIF. It returns the exit code of the command.
+func LintTemplates(args []string, linter service.TemplateLinter, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lint-templates", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "text", "output format: text, json or sarif")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: go-scaffold lint-templates [-format text|json|sarif] <dir>")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return ExitUsage
+	}
+
+	dir := flags.Arg(0)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		fmt.Fprintf(stderr, "template directory does not exist: %s\n", dir)
+		return ExitUsage
+	}
+
+	findings, err := linter.LintTemplates(os.DirFS(dir))
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to lint templates: %v\n", err)
+		return ExitUsage
+	}
+
+	switch *format {
+	case "text":
+		err = writeText(stdout, dir, findings)
+	case "json":
+		err = writeJSON(stdout, findings)
+	case "sarif":
+		err = writeSARIF(stdout, dir, findings)
+	default:
+		fmt.Fprintf(stderr, "unknown format %q\n", *format)
+		return ExitUsage
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to write findings: %v\n", err)
+		return ExitUsage
+	}
+
+	for _, finding := range findings {
+		if finding.Severity == model.LintSeverityError {
+			return ExitFindings
+		}
+	}
+	return ExitOK
+}
+
+// writeText writes one line per finding followed by a summary
+func writeText(w io.Writer, dir string, findings []model.LintFinding) error {
+	errs, warnings := 0, 0
+	for _, finding := range findings {
+		location := filepath.Join(dir, filepath.FromSlash(finding.Path))
+		if finding.Line > 0 {
+			location = fmt.Sprintf("%s:%d", location, finding.Line)
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s: %s (%s)\n", location, finding.Severity, finding.Message, finding.Rule); err != nil {
+			return err
+		}
+
+		if finding.Severity == model.LintSeverityError {
+			errs++
+		} else {
+			warnings++
+		}
+	}
+
+	if len(findings) == 0 {
+		_, err := fmt.Fprintln(w, "No problems found")
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d problems (%d errors, %d warnings)\n", len(findings), errs, warnings)
+	return err
+}
+
+// writeJSON writes the findings as a JSON array
+func writeJSON(w io.Writer, findings []model.LintFinding) error {
+	if findings == nil {
+		findings = []model.LintFinding{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(findings)
+}
+
+// SARIF 2.1.0 log, limited to the properties written by the linter
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// writeSARIF writes the findings as a SARIF 2.1.0 log for code scanning tools
+func writeSARIF(w io.Writer, dir string, findings []model.LintFinding) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{Name: "go-scaffold"}},
+		// Results must be an array even when there are none
+		Results: []sarifResult{},
+	}
+
+	for _, rule := range []string{model.LintRuleParseError, model.LintRuleUnknownField, model.LintRuleUnknownFeature, model.LintRuleUnreachableFile} {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:               rule,
+			ShortDescription: sarifMessage{Text: lintRuleDescriptions[rule]},
+		})
+	}
+
+	for _, finding := range findings {
+		location := sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(filepath.Join(dir, filepath.FromSlash(finding.Path)))},
+		}
+		if finding.Line > 0 {
+			location.Region = &sarifRegion{StartLine: finding.Line}
+		}
+
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    finding.Rule,
+			Level:     finding.Severity,
+			Message:   sarifMessage{Text: finding.Message},
+			Locations: []sarifLocation{{PhysicalLocation: location}},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
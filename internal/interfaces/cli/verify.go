@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/service"
+)
+
+// Verify runs the verify command: it checks a downloaded archive against its
+// checksum manifest and, when a public key is given, its detached signature.
+// It returns the exit code of the command.
+func Verify(args []string, verifier service.ArchiveVerifier, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	keyPath := flags.String("key", "", "PEM-encoded public key from GET /api/keys")
+	sigPath := flags.String("sig", "", "detached signature; defaults to the archive path with .sig appended")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: go-scaffold verify [-key public.pem] [-sig archive.zip.sig] <archive.zip>")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return ExitUsage
+	}
+
+	archivePath := flags.Arg(0)
+	archive, err := os.ReadFile(archivePath)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to read archive: %v\n", err)
+		return ExitUsage
+	}
+
+	var signature, publicKey []byte
+	if *keyPath != "" {
+		if publicKey, err = os.ReadFile(*keyPath); err != nil {
+			fmt.Fprintf(stderr, "failed to read public key: %v\n", err)
+			return ExitUsage
+		}
+		if *sigPath == "" {
+			*sigPath = archivePath + ".sig"
+		}
+		if signature, err = os.ReadFile(*sigPath); err != nil {
+			fmt.Fprintf(stderr, "failed to read signature: %v\n", err)
+			return ExitUsage
+		}
+	} else {
+		fmt.Fprintln(stderr, "warning: no public key given, the signature is not checked")
+	}
+
+	if err := verifier.VerifyArchive(archive, signature, publicKey); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(stdout, "%s: %s\n", archivePath, line)
+		}
+		return ExitFindings
+	}
+
+	if publicKey != nil {
+		fmt.Fprintf(stdout, "%s: signature and checksums OK\n", archivePath)
+	} else {
+		fmt.Fprintf(stdout, "%s: checksums OK\n", archivePath)
+	}
+	return ExitOK
+}
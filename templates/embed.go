@@ -0,0 +1,10 @@
+// Package templates embeds the built-in project templates, dependency catalog and presets
+package templates
+
+import "embed"
+
+// FS holds the built-in templates. The all: prefix keeps base trees and
+// dotfiles, whose names start with "_" or ".".
+//
+//go:embed all:api all:shared dependencies.json presets.json
+var FS embed.FS
@@ -76,6 +76,11 @@ func (s *MockGeneratorService) GetTemplatesByType(templateType string) ([]*model
 	return []*model.Template{}, nil
 }
 
+func (s *MockGeneratorService) GetTemplateVersions(id string) ([]*model.Template, error) {
+	// Mock implementation
+	return []*model.Template{}, nil
+}
+
 func (s *MockGeneratorService) GetAvailableFeatures() ([]*model.Feature, error) {
 	// Mock implementation
 	return []*model.Feature{}, nil
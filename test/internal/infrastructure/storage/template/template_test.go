@@ -7,6 +7,7 @@ import (
 	"testing"
 	texttemplate "text/template"
 
+	"github.com/regiwitanto/go-scaffold/internal/application/service"
 	"github.com/regiwitanto/go-scaffold/internal/domain/model"
 )
 
@@ -75,39 +76,19 @@ func TestTemplateRendering(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Setup template data with helper functions
-			templateData := map[string]interface{}{
-				"AppType":      tt.options.AppType,
-				"DatabaseType": tt.options.DatabaseType,
-				"RouterType":   tt.options.RouterType,
-				"ConfigType":   tt.options.ConfigType,
-				"LogFormat":    tt.options.LogFormat,
-				"ModulePath":   tt.options.ModulePath,
-				"Features":     tt.options.Features,
-				"Premium":      tt.options.PremiumFeatures,
-				// Helper functions
-				"HasFeature": func(feature string) bool {
-					for _, f := range tt.options.Features {
-						if f == feature {
-							return true
-						}
-					}
-					for _, f := range tt.options.PremiumFeatures {
-						if f == feature {
-							return true
-						}
-					}
-					return false
-				},
-			}
+			// Setup template data and the function library used by the generator
+			templateData := service.TemplateData(tt.options)
+			funcMap := service.TemplateFuncs(tt.options)
 
 			// Test rendering each specified template
 			for _, tmplPath := range tt.templates {
-				// Construct full template path
-				// The templates are in the root of the project
-				projectRoot := filepath.Join("..", "..", "..", "..", "..")
-				templateDir := filepath.Join(projectRoot, "templates", tt.options.AppType, tt.options.RouterType)
-				fullPath := filepath.Join(templateDir, tmplPath)
+				// Construct full template path, falling back to the shared base tree
+				// for files the router template does not provide itself
+				templatesRoot := filepath.Join("..", "..", "..", "..", "..", "templates")
+				fullPath := filepath.Join(templatesRoot, tt.options.AppType, tt.options.RouterType, tmplPath)
+				if _, err := os.Stat(fullPath); os.IsNotExist(err) {
+					fullPath = filepath.Join(templatesRoot, tt.options.AppType, "_base", tmplPath)
+				}
 
 				// Load template file
 				tmplContent, err := os.ReadFile(fullPath)
@@ -118,8 +99,11 @@ func TestTemplateRendering(t *testing.T) {
 					return
 				}
 
-				// Parse template
-				tmpl, err := texttemplate.New(filepath.Base(fullPath)).Parse(string(tmplContent))
+				// Parse template together with the shared partials
+				tmpl, err := texttemplate.New(filepath.Base(fullPath)).Funcs(funcMap).ParseGlob(filepath.Join(templatesRoot, "shared", "partials", "*.tmpl"))
+				if err == nil {
+					_, err = tmpl.Parse(string(tmplContent))
+				}
 				if err != nil {
 					if !tt.expectErr {
 						t.Fatalf("Failed to parse template %s: %v", fullPath, err)
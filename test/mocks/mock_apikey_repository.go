@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	"fmt"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+)
+
+// MockAPIKeyRepository is a mock implementation of the APIKeyRepository interface
+type MockAPIKeyRepository struct {
+	// Mock behavior functions
+	GetByKeyFunc func(key string) (*model.APIKey, error)
+
+	// Tracking calls
+	GetByKeyCalled bool
+	GetByKeyArg    string
+}
+
+// GetByKey implements the APIKeyRepository interface
+func (m *MockAPIKeyRepository) GetByKey(key string) (*model.APIKey, error) {
+	m.GetByKeyCalled = true
+	m.GetByKeyArg = key
+	if m.GetByKeyFunc != nil {
+		return m.GetByKeyFunc(key)
+	}
+
+	return nil, fmt.Errorf("API key: %w", repository.ErrNotFound)
+}
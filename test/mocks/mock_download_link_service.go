@@ -0,0 +1,63 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+)
+
+// MockDownloadLinkService is a mock implementation of the DownloadLinkService interface
+type MockDownloadLinkService struct {
+	// Mock behavior functions
+	IssueLinkFunc  func(scaffoldID string, ttl time.Duration, oneTime bool) (*model.DownloadLink, error)
+	CheckLinkFunc  func(link *model.DownloadLink) error
+	RedeemLinkFunc func(link *model.DownloadLink) error
+
+	// Tracking calls
+	IssueLinkCalled  bool
+	IssueLinkTTL     time.Duration
+	IssueLinkOneTime bool
+	CheckLinkCalled  bool
+	CheckLinkArg     *model.DownloadLink
+	RedeemLinkCalled bool
+	RedeemLinkArg    *model.DownloadLink
+}
+
+// IssueLink implements the DownloadLinkService interface
+func (m *MockDownloadLinkService) IssueLink(scaffoldID string, ttl time.Duration, oneTime bool) (*model.DownloadLink, error) {
+	m.IssueLinkCalled = true
+	m.IssueLinkTTL = ttl
+	m.IssueLinkOneTime = oneTime
+	if m.IssueLinkFunc != nil {
+		return m.IssueLinkFunc(scaffoldID, ttl, oneTime)
+	}
+	link := &model.DownloadLink{
+		ScaffoldID: scaffoldID,
+		ExpiresAt:  time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		Signature:  "mock-signature",
+	}
+	if oneTime {
+		link.Nonce = "mock-nonce"
+	}
+	return link, nil
+}
+
+// CheckLink implements the DownloadLinkService interface
+func (m *MockDownloadLinkService) CheckLink(link *model.DownloadLink) error {
+	m.CheckLinkCalled = true
+	m.CheckLinkArg = link
+	if m.CheckLinkFunc != nil {
+		return m.CheckLinkFunc(link)
+	}
+	return nil
+}
+
+// RedeemLink implements the DownloadLinkService interface
+func (m *MockDownloadLinkService) RedeemLink(link *model.DownloadLink) error {
+	m.RedeemLinkCalled = true
+	m.RedeemLinkArg = link
+	if m.RedeemLinkFunc != nil {
+		return m.RedeemLinkFunc(link)
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package mocks
 
 import (
+	"time"
+
 	"github.com/regiwitanto/go-scaffold/internal/domain/model"
 )
 
@@ -8,20 +10,37 @@ import (
 type MockGeneratorService struct {
 	// Mock behavior flags and return values
 	GenerateScaffoldFunc     func(options model.ScaffoldOptions) (*model.GeneratedScaffold, error)
+	ResolveOptionsFunc       func(options model.ScaffoldOptions) (model.ScaffoldOptions, error)
 	GetScaffoldFunc          func(id string) (*model.GeneratedScaffold, error)
+	ListScaffoldsFunc        func(filter model.ScaffoldFilter) (*model.ScaffoldPage, error)
+	DeleteScaffoldFunc       func(id string) error
 	GetAllTemplatesFunc      func() ([]*model.Template, error)
 	GetTemplatesByTypeFunc   func(templateType string) ([]*model.Template, error)
+	GetTemplateVersionsFunc  func(id string) ([]*model.Template, error)
 	GetAvailableFeaturesFunc func() ([]*model.Feature, error)
+	GetOptionsSchemaFunc     func() (*model.JSONSchema, error)
+	GetPublicKeysFunc        func() ([]*model.PublicKey, error)
+	ExpireScaffoldsFunc      func(now time.Time) ([]*model.GeneratedScaffold, error)
 
 	// Tracking calls
 	GenerateScaffoldCalled     bool
 	GenerateScaffoldOptions    model.ScaffoldOptions
+	ResolveOptionsCalled       bool
 	GetScaffoldCalled          bool
 	GetScaffoldID              string
+	ListScaffoldsCalled        bool
+	ListScaffoldsFilter        model.ScaffoldFilter
+	DeleteScaffoldCalled       bool
+	DeleteScaffoldID           string
 	GetAllTemplatesCalled      bool
 	GetTemplatesByTypeCalled   bool
 	GetTemplatesByTypeArg      string
+	GetTemplateVersionsCalled  bool
+	GetTemplateVersionsID      string
 	GetAvailableFeaturesCalled bool
+	GetOptionsSchemaCalled     bool
+	GetPublicKeysCalled        bool
+	ExpireScaffoldsCalled      bool
 }
 
 // GenerateScaffold implements the GeneratorService interface
@@ -40,6 +59,15 @@ func (m *MockGeneratorService) GenerateScaffold(options model.ScaffoldOptions) (
 	}, nil
 }
 
+// ResolveOptions implements the GeneratorService interface
+func (m *MockGeneratorService) ResolveOptions(options model.ScaffoldOptions) (model.ScaffoldOptions, error) {
+	m.ResolveOptionsCalled = true
+	if m.ResolveOptionsFunc != nil {
+		return m.ResolveOptionsFunc(options)
+	}
+	return options, nil
+}
+
 // GetScaffold implements the GeneratorService interface
 func (m *MockGeneratorService) GetScaffold(id string) (*model.GeneratedScaffold, error) {
 	m.GetScaffoldCalled = true
@@ -56,6 +84,30 @@ func (m *MockGeneratorService) GetScaffold(id string) (*model.GeneratedScaffold,
 	}, nil
 }
 
+// ListScaffolds implements the GeneratorService interface
+func (m *MockGeneratorService) ListScaffolds(filter model.ScaffoldFilter) (*model.ScaffoldPage, error) {
+	m.ListScaffoldsCalled = true
+	m.ListScaffoldsFilter = filter
+	if m.ListScaffoldsFunc != nil {
+		return m.ListScaffoldsFunc(filter)
+	}
+	return &model.ScaffoldPage{
+		Scaffolds: []*model.GeneratedScaffold{},
+		Offset:    filter.Offset,
+		Limit:     filter.Limit,
+	}, nil
+}
+
+// DeleteScaffold implements the GeneratorService interface
+func (m *MockGeneratorService) DeleteScaffold(id string) error {
+	m.DeleteScaffoldCalled = true
+	m.DeleteScaffoldID = id
+	if m.DeleteScaffoldFunc != nil {
+		return m.DeleteScaffoldFunc(id)
+	}
+	return nil
+}
+
 // GetAllTemplates implements the GeneratorService interface
 func (m *MockGeneratorService) GetAllTemplates() ([]*model.Template, error) {
 	m.GetAllTemplatesCalled = true
@@ -127,6 +179,33 @@ func (m *MockGeneratorService) GetTemplatesByType(templateType string) ([]*model
 	return []*model.Template{}, nil
 }
 
+// GetTemplateVersions implements the GeneratorService interface
+func (m *MockGeneratorService) GetTemplateVersions(id string) ([]*model.Template, error) {
+	m.GetTemplateVersionsCalled = true
+	m.GetTemplateVersionsID = id
+	if m.GetTemplateVersionsFunc != nil {
+		return m.GetTemplateVersionsFunc(id)
+	}
+	return []*model.Template{
+		{
+			ID:          id,
+			Name:        "API with Echo",
+			Description: "API template using Echo framework",
+			Path:        "templates/api/echo",
+			Type:        "api",
+			Version:     "1.1.0",
+		},
+		{
+			ID:          id,
+			Name:        "API with Echo",
+			Description: "API template using Echo framework",
+			Path:        "templates/api@1.0.0/echo",
+			Type:        "api",
+			Version:     "1.0.0",
+		},
+	}, nil
+}
+
 // GetAvailableFeatures implements the GeneratorService interface
 func (m *MockGeneratorService) GetAvailableFeatures() ([]*model.Feature, error) {
 	m.GetAvailableFeaturesCalled = true
@@ -154,3 +233,38 @@ func (m *MockGeneratorService) GetAvailableFeatures() ([]*model.Feature, error)
 		},
 	}, nil
 }
+
+// GetOptionsSchema implements the GeneratorService interface
+func (m *MockGeneratorService) GetOptionsSchema() (*model.JSONSchema, error) {
+	m.GetOptionsSchemaCalled = true
+	if m.GetOptionsSchemaFunc != nil {
+		return m.GetOptionsSchemaFunc()
+	}
+	return &model.JSONSchema{
+		Type:     "object",
+		Required: []string{"appType", "routerType", "modulePath"},
+		Properties: map[string]*model.JSONSchema{
+			"appType":    {Type: "string", Enum: []string{"api"}},
+			"routerType": {Type: "string", Enum: []string{"chi", "echo"}},
+			"modulePath": {Type: "string", Format: "go-module-path"},
+		},
+	}, nil
+}
+
+// GetPublicKeys implements the GeneratorService interface
+func (m *MockGeneratorService) GetPublicKeys() ([]*model.PublicKey, error) {
+	m.GetPublicKeysCalled = true
+	if m.GetPublicKeysFunc != nil {
+		return m.GetPublicKeysFunc()
+	}
+	return []*model.PublicKey{}, nil
+}
+
+// ExpireScaffolds implements the GeneratorService interface
+func (m *MockGeneratorService) ExpireScaffolds(now time.Time) ([]*model.GeneratedScaffold, error) {
+	m.ExpireScaffoldsCalled = true
+	if m.ExpireScaffoldsFunc != nil {
+		return m.ExpireScaffoldsFunc(now)
+	}
+	return nil, nil
+}
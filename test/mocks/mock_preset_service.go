@@ -0,0 +1,78 @@
+package mocks
+
+import (
+	"fmt"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+)
+
+// MockPresetService is a mock implementation of the PresetService interface
+type MockPresetService struct {
+	// Mock behavior functions
+	ListPresetsFunc  func() ([]*model.Preset, error)
+	GetPresetFunc    func(id string) (*model.Preset, error)
+	CreatePresetFunc func(preset *model.Preset) (*model.Preset, error)
+	UpdatePresetFunc func(preset *model.Preset) (*model.Preset, error)
+	DeletePresetFunc func(id string) error
+
+	// Tracking calls
+	ListPresetsCalled  bool
+	GetPresetCalled    bool
+	CreatePresetCalled bool
+	UpdatePresetCalled bool
+	DeletePresetCalled bool
+	IDArg              string
+	PresetArg          *model.Preset
+}
+
+// ListPresets implements the PresetService interface
+func (m *MockPresetService) ListPresets() ([]*model.Preset, error) {
+	m.ListPresetsCalled = true
+	if m.ListPresetsFunc != nil {
+		return m.ListPresetsFunc()
+	}
+	return []*model.Preset{
+		{ID: "chi-minimal", Name: "Minimal chi API", BuiltIn: true},
+	}, nil
+}
+
+// GetPreset implements the PresetService interface
+func (m *MockPresetService) GetPreset(id string) (*model.Preset, error) {
+	m.GetPresetCalled = true
+	m.IDArg = id
+	if m.GetPresetFunc != nil {
+		return m.GetPresetFunc(id)
+	}
+	return nil, fmt.Errorf("preset %s: %w", id, repository.ErrNotFound)
+}
+
+// CreatePreset implements the PresetService interface
+func (m *MockPresetService) CreatePreset(preset *model.Preset) (*model.Preset, error) {
+	m.CreatePresetCalled = true
+	m.PresetArg = preset
+	if m.CreatePresetFunc != nil {
+		return m.CreatePresetFunc(preset)
+	}
+	return preset, nil
+}
+
+// UpdatePreset implements the PresetService interface
+func (m *MockPresetService) UpdatePreset(preset *model.Preset) (*model.Preset, error) {
+	m.UpdatePresetCalled = true
+	m.PresetArg = preset
+	if m.UpdatePresetFunc != nil {
+		return m.UpdatePresetFunc(preset)
+	}
+	return preset, nil
+}
+
+// DeletePreset implements the PresetService interface
+func (m *MockPresetService) DeletePreset(id string) error {
+	m.DeletePresetCalled = true
+	m.IDArg = id
+	if m.DeletePresetFunc != nil {
+		return m.DeletePresetFunc(id)
+	}
+	return nil
+}
@@ -1,23 +1,33 @@
 package mocks
 
 import (
+	"fmt"
+
 	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
 )
 
 // MockScaffoldRepository is a mock implementation of the ScaffoldRepository interface
 type MockScaffoldRepository struct {
 	// Mock behavior functions
-	SaveFunc    func(scaffold *model.GeneratedScaffold) error
-	GetByIDFunc func(id string) (*model.GeneratedScaffold, error)
-	DeleteFunc  func(id string) error
+	SaveFunc       func(scaffold *model.GeneratedScaffold) error
+	GetByIDFunc    func(id string) (*model.GeneratedScaffold, error)
+	ListFunc       func(filter model.ScaffoldFilter) ([]*model.GeneratedScaffold, int, error)
+	FindByHashFunc func(hash string) (*model.GeneratedScaffold, error)
+	DeleteFunc     func(id string) (int, error)
+	ExpireFunc     func(id string) (int, error)
 
 	// Tracking calls
 	SaveCalled    bool
 	SaveArg       *model.GeneratedScaffold
 	GetByIDCalled bool
 	GetByIDArg    string
+	ListCalled    bool
+	ListArg       model.ScaffoldFilter
+	FindByHashArg string
 	DeleteCalled  bool
 	DeleteArg     string
+	ExpireArgs    []string
 }
 
 // Save implements the ScaffoldRepository interface
@@ -53,12 +63,40 @@ func (m *MockScaffoldRepository) GetByID(id string) (*model.GeneratedScaffold, e
 	}, nil
 }
 
+// List implements the ScaffoldRepository interface
+func (m *MockScaffoldRepository) List(filter model.ScaffoldFilter) ([]*model.GeneratedScaffold, int, error) {
+	m.ListCalled = true
+	m.ListArg = filter
+	if m.ListFunc != nil {
+		return m.ListFunc(filter)
+	}
+	return []*model.GeneratedScaffold{}, 0, nil
+}
+
+// FindByHash implements the ScaffoldRepository interface
+func (m *MockScaffoldRepository) FindByHash(hash string) (*model.GeneratedScaffold, error) {
+	m.FindByHashArg = hash
+	if m.FindByHashFunc != nil {
+		return m.FindByHashFunc(hash)
+	}
+	return nil, fmt.Errorf("scaffold with hash %s: %w", hash, repository.ErrNotFound)
+}
+
 // Delete implements the ScaffoldRepository interface
-func (m *MockScaffoldRepository) Delete(id string) error {
+func (m *MockScaffoldRepository) Delete(id string) (int, error) {
 	m.DeleteCalled = true
 	m.DeleteArg = id
 	if m.DeleteFunc != nil {
 		return m.DeleteFunc(id)
 	}
-	return nil
+	return 0, nil
+}
+
+// Expire implements the ScaffoldRepository interface
+func (m *MockScaffoldRepository) Expire(id string) (int, error) {
+	m.ExpireArgs = append(m.ExpireArgs, id)
+	if m.ExpireFunc != nil {
+		return m.ExpireFunc(id)
+	}
+	return 0, nil
 }
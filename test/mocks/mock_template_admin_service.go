@@ -0,0 +1,83 @@
+package mocks
+
+import (
+	"io"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+)
+
+// MockTemplateAdminService is a mock implementation of the TemplateAdminService interface
+type MockTemplateAdminService struct {
+	// Mock behavior functions
+	UploadTemplateFunc    func(upload model.TemplateUpload, archive io.Reader) (*model.Template, error)
+	ListTemplatesFunc     func() ([]*model.Template, error)
+	PublishTemplateFunc   func(id, version string) (*model.Template, error)
+	DeprecateTemplateFunc func(id, version string) (*model.Template, error)
+	DeleteTemplateFunc    func(id, version string) error
+
+	// Tracking calls
+	UploadTemplateCalled    bool
+	UploadTemplateArg       model.TemplateUpload
+	ListTemplatesCalled     bool
+	PublishTemplateCalled   bool
+	DeprecateTemplateCalled bool
+	DeleteTemplateCalled    bool
+	IDArg                   string
+	VersionArg              string
+}
+
+// UploadTemplate implements the TemplateAdminService interface
+func (m *MockTemplateAdminService) UploadTemplate(upload model.TemplateUpload, archive io.Reader) (*model.Template, error) {
+	m.UploadTemplateCalled = true
+	m.UploadTemplateArg = upload
+	if m.UploadTemplateFunc != nil {
+		return m.UploadTemplateFunc(upload, archive)
+	}
+	return &model.Template{
+		ID:      upload.ID,
+		Name:    upload.Name,
+		Version: upload.Version,
+		Status:  model.TemplateStatusDraft,
+	}, nil
+}
+
+// ListTemplates implements the TemplateAdminService interface
+func (m *MockTemplateAdminService) ListTemplates() ([]*model.Template, error) {
+	m.ListTemplatesCalled = true
+	if m.ListTemplatesFunc != nil {
+		return m.ListTemplatesFunc()
+	}
+	return []*model.Template{
+		{ID: "api-fiber", Version: "1.0.0", Status: model.TemplateStatusDraft},
+	}, nil
+}
+
+// PublishTemplate implements the TemplateAdminService interface
+func (m *MockTemplateAdminService) PublishTemplate(id, version string) (*model.Template, error) {
+	m.PublishTemplateCalled = true
+	m.IDArg, m.VersionArg = id, version
+	if m.PublishTemplateFunc != nil {
+		return m.PublishTemplateFunc(id, version)
+	}
+	return &model.Template{ID: id, Version: version, Status: model.TemplateStatusPublished}, nil
+}
+
+// DeprecateTemplate implements the TemplateAdminService interface
+func (m *MockTemplateAdminService) DeprecateTemplate(id, version string) (*model.Template, error) {
+	m.DeprecateTemplateCalled = true
+	m.IDArg, m.VersionArg = id, version
+	if m.DeprecateTemplateFunc != nil {
+		return m.DeprecateTemplateFunc(id, version)
+	}
+	return &model.Template{ID: id, Version: version, Status: model.TemplateStatusDeprecated}, nil
+}
+
+// DeleteTemplate implements the TemplateAdminService interface
+func (m *MockTemplateAdminService) DeleteTemplate(id, version string) error {
+	m.DeleteTemplateCalled = true
+	m.IDArg, m.VersionArg = id, version
+	if m.DeleteTemplateFunc != nil {
+		return m.DeleteTemplateFunc(id, version)
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package mocks
 
 import (
+	"errors"
+
 	"github.com/regiwitanto/go-scaffold/internal/domain/model"
 )
 
@@ -151,3 +153,40 @@ func (m *MockTemplateRepository) GetByType(templateType string) ([]*model.Templa
 
 	return []*model.Template{}, nil
 }
+
+// MockVersionedTemplateRepository is a mock implementation of the VersionedTemplateRepository interface
+type MockVersionedTemplateRepository struct {
+	MockTemplateRepository
+
+	// Mock behavior functions
+	GetVersionFunc  func(id, version string) (*model.Template, error)
+	GetVersionsFunc func(id string) ([]*model.Template, error)
+
+	// Tracking calls
+	GetVersionCalled     bool
+	GetVersionIDArg      string
+	GetVersionVersionArg string
+	GetVersionsCalled    bool
+	GetVersionsIDArg     string
+}
+
+// GetVersion implements the VersionedTemplateRepository interface
+func (m *MockVersionedTemplateRepository) GetVersion(id, version string) (*model.Template, error) {
+	m.GetVersionCalled = true
+	m.GetVersionIDArg = id
+	m.GetVersionVersionArg = version
+	if m.GetVersionFunc != nil {
+		return m.GetVersionFunc(id, version)
+	}
+	return nil, errors.New("template version not found")
+}
+
+// GetVersions implements the VersionedTemplateRepository interface
+func (m *MockVersionedTemplateRepository) GetVersions(id string) ([]*model.Template, error) {
+	m.GetVersionsCalled = true
+	m.GetVersionsIDArg = id
+	if m.GetVersionsFunc != nil {
+		return m.GetVersionsFunc(id)
+	}
+	return nil, errors.New("template not found")
+}
@@ -0,0 +1,76 @@
+package mocks
+
+import (
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+)
+
+// MockUsageService is a mock implementation of the UsageService interface
+type MockUsageService struct {
+	// Mock behavior functions
+	AllowFunc              func(client string, plan *model.Plan) error
+	ReserveGenerationFunc  func(client string, plan *model.Plan) error
+	CompleteGenerationFunc func(client string, scaffold *model.GeneratedScaffold) error
+	ReleaseStorageFunc     func(client string, scaffold *model.GeneratedScaffold)
+	GetUsageFunc           func(client string, plan *model.Plan) *model.Usage
+
+	// Tracking calls
+	AllowCalled              bool
+	AllowArg                 string
+	ReserveGenerationCalled  bool
+	ReserveGenerationArg     string
+	CompleteGenerationCalled bool
+	CompleteGenerationArg    *model.GeneratedScaffold
+	ReleaseStorageCalled     bool
+	ReleaseStorageArg        string
+	GetUsageCalled           bool
+	GetUsageArg              string
+}
+
+// Allow implements the UsageService interface
+func (m *MockUsageService) Allow(client string, plan *model.Plan) error {
+	m.AllowCalled = true
+	m.AllowArg = client
+	if m.AllowFunc != nil {
+		return m.AllowFunc(client, plan)
+	}
+	return nil
+}
+
+// ReserveGeneration implements the UsageService interface
+func (m *MockUsageService) ReserveGeneration(client string, plan *model.Plan) error {
+	m.ReserveGenerationCalled = true
+	m.ReserveGenerationArg = client
+	if m.ReserveGenerationFunc != nil {
+		return m.ReserveGenerationFunc(client, plan)
+	}
+	return nil
+}
+
+// CompleteGeneration implements the UsageService interface
+func (m *MockUsageService) CompleteGeneration(client string, scaffold *model.GeneratedScaffold) error {
+	m.CompleteGenerationCalled = true
+	m.CompleteGenerationArg = scaffold
+	if m.CompleteGenerationFunc != nil {
+		return m.CompleteGenerationFunc(client, scaffold)
+	}
+	return nil
+}
+
+// ReleaseStorage implements the UsageService interface
+func (m *MockUsageService) ReleaseStorage(client string, scaffold *model.GeneratedScaffold) {
+	m.ReleaseStorageCalled = true
+	m.ReleaseStorageArg = client
+	if m.ReleaseStorageFunc != nil {
+		m.ReleaseStorageFunc(client, scaffold)
+	}
+}
+
+// GetUsage implements the UsageService interface
+func (m *MockUsageService) GetUsage(client string, plan *model.Plan) *model.Usage {
+	m.GetUsageCalled = true
+	m.GetUsageArg = client
+	if m.GetUsageFunc != nil {
+		return m.GetUsageFunc(client, plan)
+	}
+	return &model.Usage{Client: client}
+}
@@ -0,0 +1,104 @@
+package mocks
+
+import (
+	"fmt"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+)
+
+// MockWebhookService is a mock implementation of the WebhookService interface
+type MockWebhookService struct {
+	// Mock behavior functions
+	ListWebhooksFunc   func(owner string) ([]*model.Webhook, error)
+	GetWebhookFunc     func(id string) (*model.Webhook, error)
+	CreateWebhookFunc  func(webhook *model.Webhook) (*model.Webhook, error)
+	UpdateWebhookFunc  func(webhook *model.Webhook) (*model.Webhook, error)
+	DeleteWebhookFunc  func(id string) error
+	ListDeliveriesFunc func(webhookID, state string) ([]*model.WebhookDelivery, error)
+	PingFunc           func(id string) (*model.WebhookDelivery, error)
+
+	// Tracking calls
+	ListWebhooksCalled   bool
+	GetWebhookCalled     bool
+	CreateWebhookCalled  bool
+	UpdateWebhookCalled  bool
+	DeleteWebhookCalled  bool
+	ListDeliveriesCalled bool
+	PingCalled           bool
+	OwnerArg             string
+	IDArg                string
+	StateArg             string
+	WebhookArg           *model.Webhook
+}
+
+// ListWebhooks implements the WebhookService interface
+func (m *MockWebhookService) ListWebhooks(owner string) ([]*model.Webhook, error) {
+	m.ListWebhooksCalled = true
+	m.OwnerArg = owner
+	if m.ListWebhooksFunc != nil {
+		return m.ListWebhooksFunc(owner)
+	}
+	return []*model.Webhook{}, nil
+}
+
+// GetWebhook implements the WebhookService interface
+func (m *MockWebhookService) GetWebhook(id string) (*model.Webhook, error) {
+	m.GetWebhookCalled = true
+	m.IDArg = id
+	if m.GetWebhookFunc != nil {
+		return m.GetWebhookFunc(id)
+	}
+	return nil, fmt.Errorf("webhook %s: %w", id, repository.ErrNotFound)
+}
+
+// CreateWebhook implements the WebhookService interface
+func (m *MockWebhookService) CreateWebhook(webhook *model.Webhook) (*model.Webhook, error) {
+	m.CreateWebhookCalled = true
+	m.WebhookArg = webhook
+	if m.CreateWebhookFunc != nil {
+		return m.CreateWebhookFunc(webhook)
+	}
+	return webhook, nil
+}
+
+// UpdateWebhook implements the WebhookService interface
+func (m *MockWebhookService) UpdateWebhook(webhook *model.Webhook) (*model.Webhook, error) {
+	m.UpdateWebhookCalled = true
+	m.WebhookArg = webhook
+	if m.UpdateWebhookFunc != nil {
+		return m.UpdateWebhookFunc(webhook)
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook implements the WebhookService interface
+func (m *MockWebhookService) DeleteWebhook(id string) error {
+	m.DeleteWebhookCalled = true
+	m.IDArg = id
+	if m.DeleteWebhookFunc != nil {
+		return m.DeleteWebhookFunc(id)
+	}
+	return nil
+}
+
+// ListDeliveries implements the WebhookService interface
+func (m *MockWebhookService) ListDeliveries(webhookID, state string) ([]*model.WebhookDelivery, error) {
+	m.ListDeliveriesCalled = true
+	m.IDArg = webhookID
+	m.StateArg = state
+	if m.ListDeliveriesFunc != nil {
+		return m.ListDeliveriesFunc(webhookID, state)
+	}
+	return []*model.WebhookDelivery{}, nil
+}
+
+// Ping implements the WebhookService interface
+func (m *MockWebhookService) Ping(id string) (*model.WebhookDelivery, error) {
+	m.PingCalled = true
+	m.IDArg = id
+	if m.PingFunc != nil {
+		return m.PingFunc(id)
+	}
+	return &model.WebhookDelivery{ID: "delivery-1", WebhookID: id, EventType: model.EventWebhookPing, Status: model.DeliveryPending}, nil
+}
@@ -86,14 +86,16 @@ func RenderTemplate(t *testing.T, templatePath string, data interface{}) (string
 	}
 
 	// Define template functions
+	hasFeature := func(feature string) bool {
+		// If data is TemplateData, use its HasFeature method
+		if td, ok := data.(TemplateData); ok {
+			return HasFeature(td.Features, feature)
+		}
+		return false
+	}
 	funcMap := template.FuncMap{
-		"HasFeature": func(feature string) bool {
-			// If data is TemplateData, use its HasFeature method
-			if td, ok := data.(TemplateData); ok {
-				return HasFeature(td.Features, feature)
-			}
-			return false
-		},
+		"HasFeature": hasFeature,
+		"hasFeature": hasFeature,
 	}
 
 	// Parse template with functions
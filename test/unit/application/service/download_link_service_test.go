@@ -0,0 +1,98 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/regiwitanto/go-scaffold/internal/application/service"
+	domainservice "github.com/regiwitanto/go-scaffold/internal/domain/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDownloadLinkExpiry ensures links work until they expire, with the default or a requested lifetime
+func TestDownloadLinkExpiry(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	links := service.NewDownloadLinkService([]byte("secret"), service.WithLinkTTL(10*time.Minute), service.WithLinkClock(clock.Now))
+
+	link, err := links.IssueLink("abc123", 0, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "abc123", link.ScaffoldID)
+	assert.Equal(t, clock.now.Add(10*time.Minute), link.ExpiresAt)
+	assert.False(t, link.OneTime())
+
+	// Reusable links work any number of times until they expire
+	assert.NoError(t, links.RedeemLink(link))
+	clock.now = clock.now.Add(10*time.Minute - time.Second)
+	assert.NoError(t, links.RedeemLink(link))
+	clock.now = clock.now.Add(time.Second)
+	assert.ErrorIs(t, links.RedeemLink(link), domainservice.ErrLinkExpired)
+
+	long, err := links.IssueLink("abc123", 24*time.Hour, false)
+	if assert.NoError(t, err) {
+		assert.Equal(t, clock.now.Add(24*time.Hour), long.ExpiresAt)
+	}
+
+	for _, ttl := range []time.Duration{-time.Second, time.Millisecond, service.MaxLinkTTL + time.Second} {
+		_, err := links.IssueLink("abc123", ttl, false)
+		var validationErr *domainservice.ValidationError
+		if assert.True(t, errors.As(err, &validationErr), ttl.String()) {
+			assert.Equal(t, "expiresIn", validationErr.Problems[0].Field)
+		}
+	}
+}
+
+// TestDownloadLinkTampering ensures links only work for the scaffold, expiry and nonce they were signed for
+func TestDownloadLinkTampering(t *testing.T) {
+	links := service.NewDownloadLinkService([]byte("secret"))
+
+	link, err := links.IssueLink("abc123", 0, true)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	other := *link
+	other.ScaffoldID = "def456"
+	assert.ErrorIs(t, links.RedeemLink(&other), domainservice.ErrLinkInvalid)
+
+	other = *link
+	other.ExpiresAt = other.ExpiresAt.Add(time.Hour)
+	assert.ErrorIs(t, links.RedeemLink(&other), domainservice.ErrLinkInvalid)
+
+	other = *link
+	other.Nonce = ""
+	assert.ErrorIs(t, links.RedeemLink(&other), domainservice.ErrLinkInvalid)
+
+	// Links signed with another secret are rejected
+	assert.ErrorIs(t, service.NewDownloadLinkService([]byte("other")).RedeemLink(link), domainservice.ErrLinkInvalid)
+
+	assert.NoError(t, links.RedeemLink(link))
+}
+
+// TestDownloadLinkOneTime ensures one-time links are invalidated by their first download
+func TestDownloadLinkOneTime(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	links := service.NewDownloadLinkService([]byte("secret"), service.WithLinkClock(clock.Now))
+
+	first, err := links.IssueLink("abc123", time.Minute, true)
+	assert.NoError(t, err)
+	second, err := links.IssueLink("abc123", time.Hour, true)
+	assert.NoError(t, err)
+	assert.True(t, first.OneTime())
+	assert.NotEqual(t, first.Nonce, second.Nonce)
+
+	// Checking a link does not use it up
+	assert.NoError(t, links.CheckLink(first))
+	assert.NoError(t, links.CheckLink(first))
+	assert.NoError(t, links.RedeemLink(first))
+	assert.ErrorIs(t, links.CheckLink(first), domainservice.ErrLinkUsed)
+	assert.ErrorIs(t, links.RedeemLink(first), domainservice.ErrLinkUsed)
+
+	// Forgetting expired links does not revive used ones that are still valid
+	assert.NoError(t, links.RedeemLink(second))
+	clock.now = clock.now.Add(2 * time.Minute)
+	assert.ErrorIs(t, links.RedeemLink(first), domainservice.ErrLinkExpired)
+	assert.ErrorIs(t, links.RedeemLink(second), domainservice.ErrLinkUsed)
+}
@@ -1,13 +1,27 @@
 package service_test
 
 import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/regiwitanto/go-scaffold/internal/application/service"
 	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	domainservice "github.com/regiwitanto/go-scaffold/internal/domain/service"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/dependency"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/preset"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/scaffold"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/template"
 	"github.com/regiwitanto/go-scaffold/test/mocks"
+	"github.com/regiwitanto/go-scaffold/test/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -142,3 +156,805 @@ func TestGetScaffold(t *testing.T) {
 	// Verify the method was called
 	assert.True(t, mockScaffoldRepo.GetByIDCalled)
 }
+
+// TestDeleteScaffold ensures deleting a scaffold removes its record and archive
+func TestDeleteScaffold(t *testing.T) {
+	tempDir := t.TempDir()
+	zipPath := filepath.Join(tempDir, "123.zip")
+	assert.NoError(t, os.WriteFile(zipPath, []byte("zip"), 0644))
+
+	scaffoldRepo := scaffold.NewInMemoryRepository()
+	assert.NoError(t, scaffoldRepo.Save(&model.GeneratedScaffold{ID: "123", FilePath: zipPath}))
+	generatorService := service.NewGeneratorService(&mocks.MockTemplateRepository{}, scaffoldRepo, tempDir)
+
+	assert.NoError(t, generatorService.DeleteScaffold("123"))
+	assert.NoFileExists(t, zipPath)
+	_, err := generatorService.GetScaffold("123")
+	assert.True(t, errors.Is(err, repository.ErrNotFound))
+
+	err = generatorService.DeleteScaffold("123")
+	assert.True(t, errors.Is(err, repository.ErrNotFound))
+}
+
+// TestListScaffolds ensures the page of scaffolds records the filter's pagination
+func TestListScaffolds(t *testing.T) {
+	mockScaffoldRepo := &mocks.MockScaffoldRepository{
+		ListFunc: func(filter model.ScaffoldFilter) ([]*model.GeneratedScaffold, int, error) {
+			return []*model.GeneratedScaffold{{ID: "b"}}, 3, nil
+		},
+	}
+	generatorService := service.NewGeneratorService(&mocks.MockTemplateRepository{}, mockScaffoldRepo, t.TempDir())
+
+	filter := model.ScaffoldFilter{Owner: "ip:192.0.2.1", Offset: 1, Limit: 1}
+	page, err := generatorService.ListScaffolds(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, filter, mockScaffoldRepo.ListArg)
+	assert.Equal(t, &model.ScaffoldPage{Scaffolds: []*model.GeneratedScaffold{{ID: "b"}}, Total: 3, Offset: 1, Limit: 1}, page)
+}
+
+// Test that generation resolves {{dep}} through the catalog and writes matching go.sum entries
+func TestGenerateScaffoldWritesGoSum(t *testing.T) {
+	rootDir, err := testutil.FindProjectRoot()
+	assert.NoError(t, err)
+
+	dependencyRepo, err := dependency.NewCatalogRepository(filepath.Join(rootDir, "templates", "dependencies.json"))
+	assert.NoError(t, err)
+
+	mockTemplateRepo := &mocks.MockTemplateRepository{
+		GetByTypeFunc: func(templateType string) ([]*model.Template, error) {
+			return []*model.Template{
+				{
+					ID:   "api-echo",
+					Path: filepath.Join(rootDir, "templates", "api", "echo"),
+					Type: "api",
+				},
+			}, nil
+		},
+	}
+	mockScaffoldRepo := &mocks.MockScaffoldRepository{}
+
+	generatorService := service.NewGeneratorService(
+		mockTemplateRepo,
+		mockScaffoldRepo,
+		t.TempDir(),
+		service.WithDependencyRepository(dependencyRepo),
+	)
+
+	scaffold, err := generatorService.GenerateScaffold(model.ScaffoldOptions{
+		AppType:      "api",
+		RouterType:   "echo",
+		DatabaseType: "postgresql",
+		ConfigType:   "env",
+		LogFormat:    "json",
+		ModulePath:   "github.com/example/api",
+		Features:     []string{"basic-auth"},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	files := readZipFiles(t, scaffold.FilePath)
+	assert.Contains(t, files["codebase/go.mod"], "github.com/labstack/echo/v4 v4.11.4")
+	assert.Contains(t, files["codebase/go.mod"], "github.com/lib/pq v1.10.9")
+
+	goSum := files["codebase/go.sum"]
+	assert.Contains(t, goSum, "github.com/labstack/echo/v4 v4.11.4 h1:")
+	assert.Contains(t, goSum, "github.com/labstack/echo/v4 v4.11.4/go.mod h1:")
+	assert.Contains(t, goSum, "github.com/lib/pq v1.10.9 h1:")
+	assert.Contains(t, goSum, "golang.org/x/crypto v0.17.0 h1:")
+	assert.NotContains(t, goSum, "github.com/go-sql-driver/mysql")
+
+	// Modules echo needs to build are required and checksummed too, so the project builds offline
+	assert.Contains(t, files["codebase/go.mod"], "github.com/joho/godotenv v1.5.1")
+	assert.Contains(t, files["codebase/go.mod"], "\tgithub.com/labstack/gommon v0.4.2 // indirect\n")
+	assert.Contains(t, files["codebase/go.mod"], "\tgolang.org/x/net v0.19.0 // indirect\n")
+	assert.NotContains(t, files["codebase/go.mod"], "golang.org/x/crypto v0.17.0 // indirect", "direct requirements are not repeated")
+	assert.Contains(t, goSum, "github.com/labstack/gommon v0.4.2 h1:")
+	assert.Contains(t, goSum, "golang.org/x/net v0.19.0 h1:")
+	assert.Equal(t, 1, strings.Count(goSum, "golang.org/x/crypto v0.17.0 h1:"), "shared checksums are written once")
+}
+
+// Test that modules required by several dependencies are required once, at the highest version
+func TestGenerateScaffoldIndirectRequires(t *testing.T) {
+	catalog := filepath.Join(t.TempDir(), "dependencies.json")
+	assert.NoError(t, os.WriteFile(catalog, []byte(`{"dependencies": {
+		"web": {"path": "example.com/web", "version": "v1.0.0", "sum": "h1:web=", "goModSum": "h1:webmod=",
+			"requires": ["golang.org/x/sys v0.8.0", "example.com/log v1.0.0"],
+			"sums": ["golang.org/x/sys v0.8.0/go.mod h1:old=", "example.com/log v1.0.0 h1:log="]},
+		"db": {"path": "example.com/db", "version": "v2.0.0", "sum": "h1:db=", "goModSum": "h1:dbmod=",
+			"requires": ["golang.org/x/sys v0.15.0", "example.com/web v0.9.0"],
+			"sums": ["golang.org/x/sys v0.15.0 h1:new="]}
+	}}`), 0644))
+	dependencyRepo, err := dependency.NewCatalogRepository(catalog)
+	assert.NoError(t, err)
+
+	templateDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(templateDir, "go.mod.tmpl"), []byte("module {{.ModulePath}}\n\nrequire (\n\t{{dep \"web\"}}\n\t{{dep \"db\"}}\n)\n"), 0644))
+	mockTemplateRepo := &mocks.MockTemplateRepository{
+		GetByTypeFunc: func(templateType string) ([]*model.Template, error) {
+			return []*model.Template{{ID: "api-echo", Path: templateDir, Type: "api", Router: "echo"}}, nil
+		},
+	}
+	generatorService := service.NewGeneratorService(mockTemplateRepo, &mocks.MockScaffoldRepository{}, t.TempDir(), service.WithDependencyRepository(dependencyRepo))
+
+	scaffold, err := generatorService.GenerateScaffold(model.ScaffoldOptions{AppType: "api", RouterType: "echo", ModulePath: "github.com/example/api"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	files := readZipFiles(t, scaffold.FilePath)
+	assert.Equal(t, "module github.com/example/api\n\nrequire (\n\texample.com/web v1.0.0\n\texample.com/db v2.0.0\n)\n"+
+		"\nrequire (\n\texample.com/log v1.0.0 // indirect\n\tgolang.org/x/sys v0.15.0 // indirect\n)\n", files["codebase/go.mod"])
+	assert.Equal(t, "example.com/db v2.0.0 h1:db=\n"+
+		"example.com/db v2.0.0/go.mod h1:dbmod=\n"+
+		"example.com/log v1.0.0 h1:log=\n"+
+		"example.com/web v1.0.0 h1:web=\n"+
+		"example.com/web v1.0.0/go.mod h1:webmod=\n"+
+		"golang.org/x/sys v0.15.0 h1:new=\n"+
+		"golang.org/x/sys v0.8.0/go.mod h1:old=\n", files["codebase/go.sum"])
+}
+
+// Test that templates using {{dep}} fail clearly when no catalog is configured
+func TestGenerateScaffoldWithoutCatalog(t *testing.T) {
+	templateDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(templateDir, "go.mod.tmpl"), []byte(`require {{dep "echo"}}`), 0644))
+
+	mockTemplateRepo := &mocks.MockTemplateRepository{
+		GetByTypeFunc: func(templateType string) ([]*model.Template, error) {
+			return []*model.Template{{ID: "api-echo", Path: templateDir, Type: "api"}}, nil
+		},
+	}
+
+	generatorService := service.NewGeneratorService(mockTemplateRepo, &mocks.MockScaffoldRepository{}, t.TempDir())
+
+	_, err := generatorService.GenerateScaffold(model.ScaffoldOptions{
+		AppType:    "api",
+		RouterType: "echo",
+		ModulePath: "github.com/example/api",
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "dependency catalog is not configured")
+	}
+}
+
+// Test that an unknown router type is rejected with the valid choices instead of falling back to another template
+func TestGenerateScaffoldUnknownRouter(t *testing.T) {
+	templateDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(templateDir, "main.go.tmpl"), []byte(`package main`), 0644))
+
+	mockTemplateRepo := &mocks.MockTemplateRepository{
+		GetByTypeFunc: func(templateType string) ([]*model.Template, error) {
+			return []*model.Template{
+				{ID: "api-echo", Path: templateDir, Type: "api", Router: "echo"},
+				{ID: "api-go-kit", Path: templateDir, Type: "api", Router: "go-kit"},
+				{ID: "api-chi", Path: templateDir, Type: "api", Router: "chi"},
+			}, nil
+		},
+	}
+
+	generatorService := service.NewGeneratorService(mockTemplateRepo, &mocks.MockScaffoldRepository{}, t.TempDir())
+
+	scaffold, err := generatorService.GenerateScaffold(model.ScaffoldOptions{
+		AppType:    "api",
+		RouterType: "go-kit",
+		ModulePath: "github.com/example/api",
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "api-go-kit", scaffold.TemplateID)
+	}
+
+	_, err = generatorService.GenerateScaffold(model.ScaffoldOptions{
+		AppType:    "api",
+		RouterType: "fiber",
+		ModulePath: "github.com/example/api",
+	})
+	var validationErr *domainservice.ValidationError
+	if assert.ErrorAs(t, err, &validationErr) {
+		assert.Equal(t, []domainservice.Problem{{
+			Field:   "routerType",
+			Code:    domainservice.ProblemInvalidValue,
+			Message: `invalid router type "fiber"; valid router types: chi, echo, go-kit`,
+		}}, validationErr.Problems)
+	}
+}
+
+// Test that every invalid option is reported at once with its field and code
+func TestGenerateScaffoldValidatesOptions(t *testing.T) {
+	mockTemplateRepo := &mocks.MockTemplateRepository{
+		GetByTypeFunc: func(templateType string) ([]*model.Template, error) {
+			return []*model.Template{{ID: "api-echo", Type: "api", Router: "echo"}}, nil
+		},
+	}
+	generatorService := service.NewGeneratorService(mockTemplateRepo, &mocks.MockScaffoldRepository{}, t.TempDir())
+
+	_, err := generatorService.GenerateScaffold(model.ScaffoldOptions{
+		AppType:         "api",
+		RouterType:      "echo",
+		DatabaseType:    "sqlite",
+		ConfigType:      "yaml",
+		LogFormat:       "xml",
+		ModulePath:      "github.com/example/my app",
+		Features:        []string{"gitignore", "automatic-https", "gitignore", "unknown"},
+		PremiumFeatures: []string{"gitignore", "user-accounts"},
+	})
+
+	var validationErr *domainservice.ValidationError
+	if !assert.ErrorAs(t, err, &validationErr) {
+		return
+	}
+	assert.Equal(t, "invalid scaffold options", validationErr.Message)
+
+	type fieldCode struct{ Field, Code string }
+	var found []fieldCode
+	for _, problem := range validationErr.Problems {
+		assert.NotEmpty(t, problem.Message)
+		found = append(found, fieldCode{problem.Field, problem.Code})
+	}
+	assert.Equal(t, []fieldCode{
+		{"configType", domainservice.ProblemInvalidValue},
+		{"databaseType", domainservice.ProblemInvalidValue},
+		{"features[1]", domainservice.ProblemInvalidValue},
+		{"features[2]", domainservice.ProblemDuplicate},
+		{"features[3]", domainservice.ProblemInvalidValue},
+		{"logFormat", domainservice.ProblemInvalidValue},
+		{"modulePath", domainservice.ProblemInvalidFormat},
+		{"premiumFeatures[0]", domainservice.ProblemInvalidValue},
+		// user-accounts requires a database
+		{"databaseType", domainservice.ProblemInvalidValue},
+	}, found)
+
+	_, err = generatorService.GenerateScaffold(model.ScaffoldOptions{})
+	if assert.ErrorAs(t, err, &validationErr) {
+		found = nil
+		for _, problem := range validationErr.Problems {
+			found = append(found, fieldCode{problem.Field, problem.Code})
+		}
+		assert.Equal(t, []fieldCode{
+			{"appType", domainservice.ProblemRequired},
+			{"modulePath", domainservice.ProblemRequired},
+			{"routerType", domainservice.ProblemRequired},
+		}, found)
+	}
+
+	for _, modulePath := range []string{"myapp", "github.com/Example/API/", "example.com/../app", "-example.com/app"} {
+		_, err = generatorService.GenerateScaffold(model.ScaffoldOptions{AppType: "api", RouterType: "echo", ModulePath: modulePath})
+		if assert.ErrorAs(t, err, &validationErr, modulePath) && assert.Len(t, validationErr.Problems, 1, modulePath) {
+			assert.Equal(t, "modulePath", validationErr.Problems[0].Field)
+			assert.Equal(t, domainservice.ProblemInvalidFormat, validationErr.Problems[0].Code)
+		}
+	}
+}
+
+// Test that the options schema is built from the template and feature catalogs
+func TestGetOptionsSchema(t *testing.T) {
+	mockTemplateRepo := &mocks.MockTemplateRepository{
+		GetByTypeFunc: func(templateType string) ([]*model.Template, error) {
+			return []*model.Template{{ID: "api-echo"}, {ID: "api-chi"}, {ID: "api-go-kit"}}, nil
+		},
+	}
+	generatorService := service.NewGeneratorService(mockTemplateRepo, &mocks.MockScaffoldRepository{}, t.TempDir())
+
+	schema, err := generatorService.GetOptionsSchema()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Options are only required when no preset provides them
+	if assert.NotEmpty(t, schema.AllOf) {
+		rule := schema.AllOf[len(schema.AllOf)-1]
+		assert.Equal(t, []string{"presetId"}, rule.If.Required)
+		assert.Equal(t, []string{"appType", "routerType", "modulePath"}, rule.Else.Required)
+	}
+	assert.Equal(t, []string{"none", "postgresql", "mysql"}, schema.Properties["databaseType"].Enum)
+	assert.Equal(t, []string{"env", "flags"}, schema.Properties["configType"].Enum)
+	assert.Equal(t, []string{"json", "text"}, schema.Properties["logFormat"].Enum)
+	assert.Contains(t, schema.Properties["features"].Items.Enum, "sql-migrations")
+	assert.NotContains(t, schema.Properties["features"].Items.Enum, "user-accounts")
+	assert.Equal(t, []string{"automatic-https", "custom-error-pages", "user-accounts"}, schema.Properties["premiumFeatures"].Items.Enum)
+	assert.True(t, schema.Properties["features"].UniqueItems)
+	if assert.NotEmpty(t, schema.AllOf) {
+		assert.Equal(t, []string{"chi", "echo", "go-kit"}, schema.AllOf[0].Else.Properties["routerType"].Enum)
+	}
+
+	// Features that need a database are rejected without one
+	_, err = generatorService.GenerateScaffold(model.ScaffoldOptions{
+		AppType:      "api",
+		RouterType:   "echo",
+		DatabaseType: "none",
+		ModulePath:   "github.com/example/api",
+		Features:     []string{"sql-migrations"},
+	})
+	var validationErr *domainservice.ValidationError
+	if assert.ErrorAs(t, err, &validationErr) {
+		assert.Equal(t, []domainservice.Problem{{
+			Field:   "databaseType",
+			Code:    domainservice.ProblemInvalidValue,
+			Message: `feature "sql-migrations" requires a database: postgresql or mysql`,
+		}}, validationErr.Problems)
+	}
+
+	// Requested versions may use routers the catalog no longer has
+	_, err = generatorService.GenerateScaffold(model.ScaffoldOptions{
+		AppType:         "api",
+		RouterType:      "gin",
+		ModulePath:      "github.com/example/api",
+		TemplateVersion: "v1.0.0",
+	})
+	assert.False(t, errors.As(err, &validationErr), "unexpected validation error: %v", err)
+}
+
+// Test that a requested template version is fetched from a versioned repository
+func TestGenerateScaffoldWithTemplateVersion(t *testing.T) {
+	v1Dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(v1Dir, "VERSION.tmpl"), []byte(`v1`), 0644))
+
+	mockTemplateRepo := &mocks.MockVersionedTemplateRepository{
+		GetVersionFunc: func(id, version string) (*model.Template, error) {
+			return &model.Template{ID: id, Path: v1Dir, Type: "api", Version: version, Revision: "abc123"}, nil
+		},
+	}
+
+	generatorService := service.NewGeneratorService(mockTemplateRepo, &mocks.MockScaffoldRepository{}, t.TempDir())
+
+	options := model.ScaffoldOptions{
+		AppType:         "api",
+		RouterType:      "echo",
+		ModulePath:      "github.com/example/api",
+		TemplateVersion: "v1.0.0",
+	}
+	scaffold, err := generatorService.GenerateScaffold(options)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "v1", readZipFiles(t, scaffold.FilePath)["codebase/VERSION"])
+		assert.Equal(t, "api-echo", scaffold.TemplateID)
+		assert.Equal(t, "v1.0.0", scaffold.TemplateVersion)
+		assert.Equal(t, "abc123", scaffold.TemplateRevision)
+	}
+	assert.Equal(t, "api-echo", mockTemplateRepo.GetVersionIDArg)
+	assert.Equal(t, "v1.0.0", mockTemplateRepo.GetVersionVersionArg)
+
+	// Repositories without versions reject the option
+	unversioned := service.NewGeneratorService(&mocks.MockTemplateRepository{}, &mocks.MockScaffoldRepository{}, t.TempDir())
+	_, err = unversioned.GenerateScaffold(options)
+	assert.Error(t, err)
+}
+
+// Test that older template versions keep rendering alike when the current partials change
+func TestGenerateScaffoldSnapshotPartials(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"api/template.json":               `{"version": "2.0.0"}`,
+		"api/echo/NOTE.tmpl":              `{{template "note" .}}`,
+		"api@1.0.0/echo/NOTE.tmpl":        `{{template "note" .}}`,
+		"shared/partials/note.tmpl":       `{{define "note"}}current{{end}}`,
+		"shared@1.0.0/partials/note.tmpl": `{{define "note"}}released{{end}}`,
+	} {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0644))
+	}
+
+	generate := func(version string) string {
+		repo, err := template.NewFilesystemRepository(root)
+		if err != nil {
+			t.Fatalf("Failed to open templates: %v", err)
+		}
+		generatorService := service.NewGeneratorService(repo, &mocks.MockScaffoldRepository{}, t.TempDir())
+		scaffold, err := generatorService.GenerateScaffold(model.ScaffoldOptions{
+			AppType:         "api",
+			RouterType:      "echo",
+			ModulePath:      "github.com/example/api",
+			TemplateVersion: version,
+		})
+		if err != nil {
+			t.Fatalf("Failed to generate version %s: %v", version, err)
+		}
+		return readZipFiles(t, scaffold.FilePath)["codebase/NOTE"]
+	}
+
+	assert.Equal(t, "released", generate("1.0.0"))
+	assert.Equal(t, "current", generate("2.0.0"))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "shared/partials/note.tmpl"), []byte(`{{define "note"}}edited{{end}}`), 0644))
+	assert.Equal(t, "released", generate("1.0.0"), "editing the current partials leaves older versions unchanged")
+	assert.Equal(t, "edited", generate("2.0.0"))
+}
+
+// Test that options left out of a request without a preset take their documented defaults
+func TestGenerateScaffoldAppliesDefaults(t *testing.T) {
+	templateDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(templateDir, "options.txt.tmpl"), []byte(`{{.DatabaseType}} {{.ConfigType}} {{.LogFormat}}`), 0644))
+
+	mockTemplateRepo := &mocks.MockTemplateRepository{
+		GetByTypeFunc: func(templateType string) ([]*model.Template, error) {
+			return []*model.Template{{ID: "api-echo", Path: templateDir, Type: "api", Router: "echo"}}, nil
+		},
+	}
+	generatorService := service.NewGeneratorService(mockTemplateRepo, &mocks.MockScaffoldRepository{}, t.TempDir())
+
+	schema, err := generatorService.GetOptionsSchema()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tests := []struct {
+		field           string
+		options         model.ScaffoldOptions
+		expectedDefault string
+		expectedOptions string
+	}{
+		{field: "databaseType", options: model.ScaffoldOptions{ConfigType: "flags", LogFormat: "text"},
+			expectedDefault: "none", expectedOptions: "none flags text"},
+		{field: "configType", options: model.ScaffoldOptions{DatabaseType: "mysql", LogFormat: "text"},
+			expectedDefault: "env", expectedOptions: "mysql env text"},
+		{field: "logFormat", options: model.ScaffoldOptions{DatabaseType: "mysql", ConfigType: "flags"},
+			expectedDefault: "json", expectedOptions: "mysql flags json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			options := tt.options
+			options.AppType, options.RouterType, options.ModulePath = "api", "echo", "github.com/example/api"
+
+			scaffold, err := generatorService.GenerateScaffold(options)
+			if !assert.NoError(t, err) {
+				return
+			}
+			recorded := scaffold.Options
+			assert.Equal(t, tt.expectedOptions, recorded.DatabaseType+" "+recorded.ConfigType+" "+recorded.LogFormat)
+			assert.Equal(t, tt.expectedOptions, readZipFiles(t, scaffold.FilePath)["codebase/options.txt"])
+
+			// The schema documents the default
+			if assert.Contains(t, schema.Properties, tt.field) {
+				assert.Equal(t, tt.expectedDefault, schema.Properties[tt.field].Default)
+			}
+		})
+	}
+}
+
+// Test that options requesting a preset start from its options, overridden field by field
+func TestGenerateScaffoldFromPreset(t *testing.T) {
+	templateDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(templateDir, "go.mod.tmpl"), []byte(`module {{.ModulePath}}`), 0644))
+
+	mockTemplateRepo := &mocks.MockTemplateRepository{
+		GetByTypeFunc: func(templateType string) ([]*model.Template, error) {
+			return []*model.Template{
+				{ID: "api-echo", Path: templateDir, Type: "api", Router: "echo"},
+				{ID: "api-chi", Path: templateDir, Type: "api", Router: "chi"},
+			}, nil
+		},
+	}
+	presetRepo, err := preset.NewFileRepository(filepath.Join(t.TempDir(), "presets.json"), []*model.Preset{{
+		ID:   "echo-env",
+		Name: "Echo",
+		Options: model.ScaffoldOptions{
+			AppType:    "api",
+			RouterType: "echo",
+			ConfigType: "env",
+			Features:   []string{"gitignore"},
+		},
+		BuiltIn: true,
+	}})
+	assert.NoError(t, err)
+
+	generatorService := service.NewGeneratorService(
+		mockTemplateRepo,
+		&mocks.MockScaffoldRepository{},
+		t.TempDir(),
+		service.WithPresetRepository(presetRepo),
+	)
+
+	request := model.ScaffoldOptions{
+		PresetID:   "echo-env",
+		RouterType: "chi",
+		ModulePath: "github.com/example/api",
+	}
+	resolved, err := generatorService.ResolveOptions(request)
+	assert.NoError(t, err)
+	scaffold, err := generatorService.GenerateScaffold(request)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "api-chi", scaffold.TemplateID)
+		// Options neither the request nor the preset set take their defaults
+		assert.Equal(t, model.ScaffoldOptions{
+			AppType:         "api",
+			RouterType:      "chi",
+			DatabaseType:    "none",
+			ConfigType:      "env",
+			LogFormat:       "json",
+			ModulePath:      "github.com/example/api",
+			Features:        []string{"gitignore"},
+			PremiumFeatures: []string{},
+			PresetID:        "echo-env",
+		}, scaffold.Options)
+		assert.Equal(t, resolved, scaffold.Options, "options are generated as resolved")
+		assert.Equal(t, "module github.com/example/api", readZipFiles(t, scaffold.FilePath)["codebase/go.mod"])
+	}
+
+	// Presets do not provide a module path, so it is still required
+	_, err = generatorService.GenerateScaffold(model.ScaffoldOptions{PresetID: "echo-env"})
+	var validationErr *domainservice.ValidationError
+	if assert.ErrorAs(t, err, &validationErr) && assert.Len(t, validationErr.Problems, 1) {
+		assert.Equal(t, "modulePath", validationErr.Problems[0].Field)
+	}
+
+	_, err = generatorService.GenerateScaffold(model.ScaffoldOptions{PresetID: "fiber", ModulePath: "github.com/example/api"})
+	if assert.ErrorAs(t, err, &validationErr) {
+		assert.Equal(t, []domainservice.Problem{{
+			Field:   "presetId",
+			Code:    domainservice.ProblemInvalidValue,
+			Message: `preset "fiber" does not exist`,
+		}}, validationErr.Problems)
+	}
+}
+
+// Test that identical options share one archive until every scaffold referencing it is deleted
+func TestGenerateScaffoldSharesArchives(t *testing.T) {
+	templateDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(templateDir, "go.mod.tmpl"), []byte(`module {{.ModulePath}}`), 0644))
+
+	templates := func() ([]*model.Template, error) {
+		return []*model.Template{{ID: "api-echo", Path: templateDir, Type: "api", Router: "echo"}}, nil
+	}
+	mockTemplateRepo := &mocks.MockTemplateRepository{
+		GetAllFunc:    templates,
+		GetByTypeFunc: func(templateType string) ([]*model.Template, error) { return templates() },
+	}
+	generatorService := service.NewGeneratorService(mockTemplateRepo, scaffold.NewInMemoryRepository(), t.TempDir())
+
+	options := model.ScaffoldOptions{
+		AppType:    "api",
+		RouterType: "echo",
+		ModulePath: "github.com/example/api",
+		Features:   []string{"gitignore", "access-logging"},
+		Owner:      "key:acme",
+	}
+	first, err := generatorService.GenerateScaffold(options)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotEmpty(t, first.OptionsHash)
+
+	// Another client asking for the same options gets its own scaffold sharing the archive
+	options.Owner = "ip:192.0.2.1"
+	shared, err := generatorService.GenerateScaffold(options)
+	if assert.NoError(t, err) {
+		assert.NotEqual(t, first.ID, shared.ID)
+		assert.Equal(t, "ip:192.0.2.1", shared.Owner)
+		assert.Equal(t, first.OptionsHash, shared.OptionsHash)
+		assert.Equal(t, first.FilePath, shared.FilePath)
+		assert.Equal(t, first.Size, shared.Size)
+	}
+
+	// Features are rendered in order, so a different order is a different scaffold
+	reordered := options
+	reordered.Features = []string{"access-logging", "gitignore"}
+	other, err := generatorService.GenerateScaffold(reordered)
+	if assert.NoError(t, err) {
+		assert.NotEqual(t, first.OptionsHash, other.OptionsHash)
+		assert.NotEqual(t, first.FilePath, other.FilePath)
+	}
+
+	// A fresh archive is rendered on request and shared from then on
+	options.Fresh = true
+	fresh, err := generatorService.GenerateScaffold(options)
+	if assert.NoError(t, err) {
+		assert.Equal(t, first.OptionsHash, fresh.OptionsHash)
+		assert.NotEqual(t, first.FilePath, fresh.FilePath)
+	}
+
+	// The archive is removed with the last scaffold referencing it
+	assert.NoError(t, generatorService.DeleteScaffold(first.ID))
+	assert.FileExists(t, first.FilePath)
+	assert.NoError(t, generatorService.DeleteScaffold(shared.ID))
+	assert.NoFileExists(t, first.FilePath)
+	assert.FileExists(t, fresh.FilePath)
+
+	// Changed templates render a new archive
+	assert.NoError(t, os.WriteFile(filepath.Join(templateDir, "go.mod.tmpl"), []byte(`module {{.ModulePath}} // changed`), 0644))
+	assert.NoError(t, generatorService.CompileTemplates())
+	options.Fresh = false
+	changed, err := generatorService.GenerateScaffold(options)
+	if assert.NoError(t, err) {
+		assert.NotEqual(t, fresh.OptionsHash, changed.OptionsHash)
+		assert.Equal(t, "module github.com/example/api // changed", readZipFiles(t, changed.FilePath)["codebase/go.mod"])
+	}
+}
+
+// Test that the same options give byte-identical archives with sorted entries, a fixed timestamp and normalized permissions
+func TestGenerateScaffoldReproducibleArchive(t *testing.T) {
+	templateDir := t.TempDir()
+	for name, content := range map[string]string{
+		"main.go.tmpl":         `package main`,
+		"internal/app.go.tmpl": `package internal`,
+		"README.md":            `# App`,
+	} {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(templateDir, name)), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(templateDir, name), []byte(content), 0600))
+	}
+
+	mockTemplateRepo := &mocks.MockTemplateRepository{
+		GetByTypeFunc: func(templateType string) ([]*model.Template, error) {
+			return []*model.Template{{ID: "api-echo", Path: templateDir, Type: "api", Router: "echo"}}, nil
+		},
+	}
+	generatorService := service.NewGeneratorService(mockTemplateRepo, &mocks.MockScaffoldRepository{}, t.TempDir())
+
+	options := model.ScaffoldOptions{AppType: "api", RouterType: "echo", ModulePath: "github.com/example/api", Fresh: true}
+	first, err := generatorService.GenerateScaffold(options)
+	if !assert.NoError(t, err) {
+		return
+	}
+	time.Sleep(1100 * time.Millisecond) // Files of the second scaffold get later modification times
+	second, err := generatorService.GenerateScaffold(options)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotEqual(t, first.FilePath, second.FilePath)
+
+	firstBytes, err := os.ReadFile(first.FilePath)
+	assert.NoError(t, err)
+	secondBytes, err := os.ReadFile(second.FilePath)
+	assert.NoError(t, err)
+	assert.Equal(t, firstBytes, secondBytes)
+
+	sum := sha256.Sum256(firstBytes)
+	assert.Equal(t, hex.EncodeToString(sum[:]), first.SHA256)
+	assert.Equal(t, first.SHA256, second.SHA256)
+
+	r, err := zip.OpenReader(first.FilePath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer r.Close()
+
+	var names []string
+	modes := make(map[string]os.FileMode)
+	for _, f := range r.File {
+		names = append(names, f.Name)
+		modes[f.Name] = f.Mode()
+		assert.True(t, f.Modified.Equal(time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)), f.Name)
+	}
+	assert.Equal(t, []string{"SHA256SUMS", "codebase/", "codebase/README.md", "codebase/internal/", "codebase/internal/app.go", "codebase/main.go"}, names)
+	assert.Equal(t, os.ModeDir|0755, modes["codebase/internal/"])
+	assert.Equal(t, os.FileMode(0644), modes["codebase/README.md"])
+	assert.Equal(t, os.FileMode(0644), modes["codebase/main.go"])
+}
+
+// readZipFiles returns the contents of every file in a ZIP archive keyed by name
+func readZipFiles(t *testing.T, path string) map[string]string {
+	t.Helper()
+
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	defer r.Close()
+
+	files := make(map[string]string)
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", f.Name, err)
+		}
+		files[f.Name] = string(data)
+	}
+
+	return files
+}
+
+// Test that scaffolds expire after the retention, removing their archive with the last scaffold sharing it
+func TestExpireScaffolds(t *testing.T) {
+	templateDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(templateDir, "go.mod.tmpl"), []byte(`module {{.ModulePath}}`), 0644))
+
+	mockTemplateRepo := &mocks.MockTemplateRepository{
+		GetByTypeFunc: func(templateType string) ([]*model.Template, error) {
+			return []*model.Template{{ID: "api-echo", Path: templateDir, Type: "api", Router: "echo"}}, nil
+		},
+	}
+	generatorService := service.NewGeneratorService(mockTemplateRepo, scaffold.NewInMemoryRepository(), t.TempDir(), service.WithRetention(time.Hour))
+
+	options := model.ScaffoldOptions{AppType: "api", RouterType: "echo", ModulePath: "github.com/example/billing-api/v2"}
+	first, err := generatorService.GenerateScaffold(options)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "billing-api.zip", first.ArchiveName)
+	created, err := time.Parse(time.RFC3339, first.CreatedAt)
+	assert.NoError(t, err)
+	assert.Equal(t, created.Add(time.Hour).Format(time.RFC3339), first.ExpiresAt)
+
+	shared, err := generatorService.GenerateScaffold(options)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, first.FilePath, shared.FilePath)
+
+	// Nothing expires before the retention has passed
+	expired, err := generatorService.ExpireScaffolds(created.Add(time.Hour - time.Second))
+	assert.NoError(t, err)
+	assert.Empty(t, expired)
+
+	// Expiring the first scaffold keeps the archive the second still shares
+	first.ExpiresAt = created.Format(time.RFC3339)
+	expired, err = generatorService.ExpireScaffolds(created)
+	assert.NoError(t, err)
+	assert.Len(t, expired, 1)
+	_, err = generatorService.GetScaffold(first.ID)
+	assert.True(t, errors.Is(err, repository.ErrExpired))
+	assert.FileExists(t, first.FilePath)
+
+	expired, err = generatorService.ExpireScaffolds(created.Add(2 * time.Hour))
+	assert.NoError(t, err)
+	if assert.Len(t, expired, 1) {
+		assert.Equal(t, shared.ID, expired[0].ID)
+	}
+	assert.NoFileExists(t, first.FilePath)
+}
+
+// publishedEvent is an event announced to a recordingPublisher
+type publishedEvent struct {
+	owner     string
+	eventType string
+	data      interface{}
+}
+
+// recordingPublisher records the events it is asked to publish
+type recordingPublisher struct {
+	events []publishedEvent
+}
+
+// Publish implements the EventPublisher interface
+func (p *recordingPublisher) Publish(owner, eventType string, data interface{}) {
+	p.events = append(p.events, publishedEvent{owner: owner, eventType: eventType, data: data})
+}
+
+// TestGenerateScaffoldPublishesEvents ensures generated, failed and expired scaffolds are announced to their owner
+func TestGenerateScaffoldPublishesEvents(t *testing.T) {
+	templateDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(templateDir, "go.mod.tmpl"), []byte(`module {{.ModulePath}}`), 0644))
+
+	mockTemplateRepo := &mocks.MockTemplateRepository{
+		GetByTypeFunc: func(templateType string) ([]*model.Template, error) {
+			return []*model.Template{{ID: "api-echo", Path: templateDir, Type: "api", Router: "echo"}}, nil
+		},
+	}
+	publisher := &recordingPublisher{}
+	generatorService := service.NewGeneratorService(mockTemplateRepo, scaffold.NewInMemoryRepository(), t.TempDir(),
+		service.WithRetention(time.Hour), service.WithEventPublisher(publisher))
+
+	generated, err := generatorService.GenerateScaffold(model.ScaffoldOptions{
+		AppType: "api", RouterType: "echo", ModulePath: "github.com/example/api", Owner: "key:acme",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, generateErr := generatorService.GenerateScaffold(model.ScaffoldOptions{
+		AppType: "api", RouterType: "fiber", ModulePath: "github.com/example/api", Owner: "key:acme",
+	})
+	assert.Error(t, generateErr)
+	_, err = generatorService.ExpireScaffolds(time.Now().Add(2 * time.Hour))
+	assert.NoError(t, err)
+
+	if assert.Len(t, publisher.events, 3) {
+		assert.Equal(t, publishedEvent{owner: "key:acme", eventType: model.EventScaffoldGenerated, data: generated}, publisher.events[0])
+
+		assert.Equal(t, "key:acme", publisher.events[1].owner)
+		assert.Equal(t, model.EventScaffoldFailed, publisher.events[1].eventType)
+		if failure, ok := publisher.events[1].data.(model.ScaffoldFailure); assert.True(t, ok) {
+			assert.Equal(t, "fiber", failure.Options.RouterType)
+			assert.Equal(t, generateErr.Error(), failure.Error)
+		}
+
+		assert.Equal(t, "key:acme", publisher.events[2].owner)
+		assert.Equal(t, model.EventScaffoldExpired, publisher.events[2].eventType)
+		assert.Equal(t, generated.ID, publisher.events[2].data.(*model.GeneratedScaffold).ID)
+	}
+}
@@ -0,0 +1,124 @@
+package service_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/regiwitanto/go-scaffold/internal/application/service"
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	domainservice "github.com/regiwitanto/go-scaffold/internal/domain/service"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/preset"
+	"github.com/regiwitanto/go-scaffold/test/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// newPresetService returns a preset service storing presets in a temporary
+// file, next to a built-in chi-minimal preset
+func newPresetService(t *testing.T) *service.PresetServiceImpl {
+	presetRepo, err := preset.NewFileRepository(filepath.Join(t.TempDir(), "presets.json"), []*model.Preset{{
+		ID:      "chi-minimal",
+		Name:    "Chi, minimal",
+		Options: model.ScaffoldOptions{AppType: "api", RouterType: "chi"},
+		BuiltIn: true,
+	}})
+	assert.NoError(t, err)
+
+	mockTemplateRepo := &mocks.MockTemplateRepository{
+		GetByTypeFunc: func(templateType string) ([]*model.Template, error) {
+			return []*model.Template{
+				{ID: "api-echo", Type: "api", Router: "echo"},
+				{ID: "api-chi", Type: "api", Router: "chi"},
+			}, nil
+		},
+	}
+	generatorService := service.NewGeneratorService(mockTemplateRepo, &mocks.MockScaffoldRepository{}, t.TempDir())
+
+	return service.NewPresetService(presetRepo, generatorService)
+}
+
+// Test that presets are created, replaced and deleted, leaving built-in presets alone
+func TestPresetService(t *testing.T) {
+	presetService := newPresetService(t)
+
+	created, err := presetService.CreatePreset(&model.Preset{
+		ID:      "team-echo",
+		Name:    "Team Echo",
+		Options: model.ScaffoldOptions{RouterType: "echo", LogFormat: "json"},
+		Owner:   "key:acme",
+		BuiltIn: true,
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "team-echo", created.ID)
+		assert.Equal(t, "key:acme", created.Owner)
+		assert.False(t, created.BuiltIn, "created presets are never built in")
+	}
+
+	_, err = presetService.CreatePreset(&model.Preset{ID: "chi-minimal", Name: "Mine"})
+	assert.True(t, errors.Is(err, repository.ErrAlreadyExists))
+
+	updated, err := presetService.UpdatePreset(&model.Preset{
+		ID:      "team-echo",
+		Name:    "Team Echo",
+		Options: model.ScaffoldOptions{RouterType: "echo", LogFormat: "text"},
+		Owner:   "key:acme",
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "text", updated.Options.LogFormat)
+	}
+
+	_, err = presetService.UpdatePreset(&model.Preset{ID: "chi-minimal", Name: "Mine"})
+	assert.True(t, errors.Is(err, repository.ErrNotFound), "built-in presets cannot be replaced")
+
+	presets, err := presetService.ListPresets()
+	if assert.NoError(t, err) && assert.Len(t, presets, 2) {
+		assert.Equal(t, "chi-minimal", presets[0].ID)
+		assert.Equal(t, "team-echo", presets[1].ID)
+	}
+
+	assert.NoError(t, presetService.DeletePreset("team-echo"))
+	_, err = presetService.GetPreset("team-echo")
+	assert.True(t, errors.Is(err, repository.ErrNotFound))
+	assert.True(t, errors.Is(presetService.DeletePreset("chi-minimal"), repository.ErrNotFound))
+}
+
+// Test that every problem of a preset is reported at once, with options validated against the options schema
+func TestPresetServiceValidation(t *testing.T) {
+	presetService := newPresetService(t)
+
+	_, err := presetService.CreatePreset(&model.Preset{
+		ID: "Team Echo",
+		Options: model.ScaffoldOptions{
+			RouterType: "fiber",
+			ModulePath: "github.com/example/my app",
+			Features:   []string{"gitignore", "gitignore"},
+			PresetID:   "chi-minimal",
+		},
+	})
+
+	var validationErr *domainservice.ValidationError
+	if !assert.ErrorAs(t, err, &validationErr) {
+		return
+	}
+	assert.Equal(t, "invalid preset", validationErr.Message)
+
+	type fieldCode struct{ Field, Code string }
+	var found []fieldCode
+	for _, problem := range validationErr.Problems {
+		assert.NotEmpty(t, problem.Message)
+		found = append(found, fieldCode{problem.Field, problem.Code})
+	}
+	assert.Equal(t, []fieldCode{
+		{"id", domainservice.ProblemInvalidFormat},
+		{"name", domainservice.ProblemRequired},
+		{"options.presetId", domainservice.ProblemInvalidValue},
+		{"options.features[1]", domainservice.ProblemDuplicate},
+		{"options.modulePath", domainservice.ProblemInvalidFormat},
+		{"options.routerType", domainservice.ProblemInvalidValue},
+	}, found)
+
+	// Options may leave out what generation requires
+	_, err = presetService.CreatePreset(&model.Preset{ID: "empty", Name: "Empty"})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,151 @@
+package service_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/regiwitanto/go-scaffold/internal/application/service"
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/test/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// newSigningService returns a generator service rendering a two-file template,
+// signing archives with a new key
+func newSigningService(t *testing.T) (*service.GeneratorServiceImpl, ed25519.PrivateKey) {
+	t.Helper()
+
+	templateDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(templateDir, "main.go.tmpl"), []byte(`package main`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(templateDir, "README.md"), []byte(`# App`), 0644))
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	mockTemplateRepo := &mocks.MockTemplateRepository{
+		GetByTypeFunc: func(templateType string) ([]*model.Template, error) {
+			return []*model.Template{{ID: "api-echo", Path: templateDir, Type: "api", Router: "echo"}}, nil
+		},
+	}
+	generatorService := service.NewGeneratorService(mockTemplateRepo, &mocks.MockScaffoldRepository{}, t.TempDir(), service.WithSigningKey(key))
+	return generatorService, key
+}
+
+// Test that archives carry a checksum manifest and a signature that verifies with the published key
+func TestGenerateScaffoldSignsArchive(t *testing.T) {
+	generatorService, key := newSigningService(t)
+
+	scaffold, err := generatorService.GenerateScaffold(model.ScaffoldOptions{AppType: "api", RouterType: "echo", ModulePath: "github.com/example/api"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	files := readZipFiles(t, scaffold.FilePath)
+	mainSum := sha256.Sum256([]byte("package main"))
+	readmeSum := sha256.Sum256([]byte("# App"))
+	assert.Equal(t, hex.EncodeToString(readmeSum[:])+"  codebase/README.md\n"+hex.EncodeToString(mainSum[:])+"  codebase/main.go\n", files[service.ManifestName])
+
+	archive, err := os.ReadFile(scaffold.FilePath)
+	assert.NoError(t, err)
+	signature, err := base64.StdEncoding.DecodeString(scaffold.Signature)
+	assert.NoError(t, err)
+	assert.True(t, ed25519.Verify(key.Public().(ed25519.PublicKey), archive, signature))
+
+	keys, err := generatorService.GetPublicKeys()
+	if assert.NoError(t, err) && assert.Len(t, keys, 1) {
+		assert.Equal(t, scaffold.SigningKeyID, keys[0].ID)
+		assert.Equal(t, "ed25519", keys[0].Algorithm)
+		assert.Equal(t, base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)), keys[0].PublicKey)
+		assert.NoError(t, generatorService.VerifyArchive(archive, signature, []byte(keys[0].PEM)))
+
+		// A tampered archive fails its signature
+		tampered := bytes.Clone(archive)
+		tampered[len(tampered)-1] ^= 0xff
+		err = generatorService.VerifyArchive(tampered, signature, []byte(keys[0].PEM))
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "signature was not made by key "+keys[0].ID)
+		}
+	}
+}
+
+// Test that archives are not signed without a signing key
+func TestGetPublicKeysWithoutSigningKey(t *testing.T) {
+	generatorService := service.NewGeneratorService(&mocks.MockTemplateRepository{}, &mocks.MockScaffoldRepository{}, t.TempDir())
+
+	keys, err := generatorService.GetPublicKeys()
+
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+// Test that every file missing from, changed since or absent in the manifest is reported
+func TestVerifyArchiveManifest(t *testing.T) {
+	generatorService := service.NewGeneratorService(nil, nil, t.TempDir())
+	sum := func(content string) string {
+		s := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(s[:])
+	}
+
+	archive := zipArchive(t, map[string]string{
+		service.ManifestName:  sum("package main") + "  codebase/main.go\n" + sum("# App") + "  codebase/README.md\n" + sum("x") + "  codebase/gone.go\n",
+		"codebase/main.go":    "package main",
+		"codebase/README.md":  "# Changed",
+		"codebase/extra.go":   "package extra",
+		"codebase/internal/":  "",
+		"codebase/Makefile":   "",
+		"codebase/.gitignore": "",
+	}).Bytes()
+	err := generatorService.VerifyArchive(archive, nil, nil)
+
+	if assert.Error(t, err) {
+		lines := strings.Split(err.Error(), "\n")
+		assert.Equal(t, []string{
+			"codebase/README.md: checksum does not match SHA256SUMS",
+			"codebase/gone.go: listed in SHA256SUMS but missing from the archive",
+			"codebase/.gitignore: not listed in SHA256SUMS",
+			"codebase/Makefile: not listed in SHA256SUMS",
+			"codebase/extra.go: not listed in SHA256SUMS",
+		}, lines)
+	}
+
+	// Archives without a manifest cannot be verified
+	err = generatorService.VerifyArchive(zipArchive(t, map[string]string{"codebase/main.go": "package main"}).Bytes(), nil, nil)
+	assert.EqualError(t, err, "archive has no SHA256SUMS manifest")
+}
+
+// Test that only PEM-encoded ed25519 keys are accepted
+func TestParseSigningKeys(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	assert.NoError(t, err)
+	parsed, err := service.ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if assert.NoError(t, err) {
+		assert.Equal(t, privateKey, parsed)
+	}
+
+	der, err = x509.MarshalPKIXPublicKey(publicKey)
+	assert.NoError(t, err)
+	parsedPublic, err := service.ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if assert.NoError(t, err) {
+		assert.Equal(t, publicKey, parsedPublic)
+	}
+
+	_, err = service.ParsePrivateKey([]byte("not a key"))
+	assert.Error(t, err)
+	_, err = service.ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	assert.Error(t, err)
+}
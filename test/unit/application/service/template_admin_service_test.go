@@ -0,0 +1,244 @@
+package service_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/fs"
+	"path"
+	"testing"
+
+	"github.com/regiwitanto/go-scaffold/internal/application/service"
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	domainservice "github.com/regiwitanto/go-scaffold/internal/domain/service"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/template"
+	"github.com/regiwitanto/go-scaffold/test/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// zipArchive returns a ZIP archive of the given files
+func zipArchive(t *testing.T, files map[string]string) *bytes.Buffer {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to write zip: %v", err)
+	}
+	return buf
+}
+
+// tarGzArchive returns a gzipped tar archive of the given headers and contents
+func tarGzArchive(t *testing.T, headers []*tar.Header, contents []string) *bytes.Buffer {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for i, header := range headers {
+		header.Size = int64(len(contents[i]))
+		if header.Mode == 0 {
+			header.Mode = 0644
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("Failed to add %s: %v", header.Name, err)
+		}
+		tw.Write([]byte(contents[i]))
+	}
+	tw.Close()
+	gz.Close()
+	return buf
+}
+
+// readTemplateFS reads a file of a template tree
+func readTemplateFS(t *testing.T, tmpl *model.Template, name string) string {
+	t.Helper()
+
+	content, err := fs.ReadFile(tmpl.FS, path.Join(tmpl.Path, name))
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", name, err)
+	}
+	return string(content)
+}
+
+// newAdminService returns an admin service storing uploads in a temporary directory
+func newAdminService(t *testing.T) (*service.TemplateAdminServiceImpl, *template.ManagedRepository) {
+	t.Helper()
+
+	store, err := template.NewManagedRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	generatorService := service.NewGeneratorService(&mocks.MockTemplateRepository{}, &mocks.MockScaffoldRepository{}, t.TempDir())
+	return service.NewTemplateAdminService(store, generatorService, t.TempDir()), store
+}
+
+// problems returns the problems of a validation error as text
+func problems(t *testing.T, err error) []string {
+	t.Helper()
+
+	var validationErr *domainservice.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected a validation error, got %v", err)
+	}
+
+	found := make([]string, len(validationErr.Problems))
+	for i, problem := range validationErr.Problems {
+		found[i] = problem.String()
+	}
+	return found
+}
+
+// TestUploadTemplate ensures valid archives are stored as drafts and can be published
+func TestUploadTemplate(t *testing.T) {
+	adminService, store := newAdminService(t)
+
+	archive := zipArchive(t, map[string]string{
+		"fiber/main.go.tmpl":   `package main // {{.ModulePath}} {{if routerIs "fiber"}}fiber{{end}}`,
+		"fiber/static/app.css": `body {}`,
+	})
+	upload := model.TemplateUpload{ID: "api-fiber", Version: "1.0.0", Name: "API with Fiber"}
+
+	tmpl, err := adminService.UploadTemplate(upload, archive)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, model.TemplateStatusDraft, tmpl.Status)
+	assert.Equal(t, "api", tmpl.Type)
+	assert.Len(t, tmpl.Revision, 64)
+
+	// The single top-level directory is stripped
+	_, err = store.GetByID("api-fiber")
+	assert.ErrorIs(t, err, repository.ErrNotFound, "drafts are not served")
+	_, err = adminService.PublishTemplate("api-fiber", "1.0.0")
+	assert.NoError(t, err)
+	published, err := store.GetByID("api-fiber")
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.TemplateStatusPublished, published.Status)
+		assert.Equal(t, "body {}", readTemplateFS(t, published, "static/app.css"))
+	}
+
+	// The same version cannot be uploaded twice, however it is written
+	for _, version := range []string{"1.0.0", "v1.0.0", "1.0"} {
+		duplicate := model.TemplateUpload{ID: "api-fiber", Version: version}
+		_, err = adminService.UploadTemplate(duplicate, zipArchive(t, map[string]string{"main.go.tmpl": "x"}))
+		assert.ErrorIs(t, err, repository.ErrAlreadyExists, version)
+	}
+
+	// Gzipped tar archives are accepted too
+	archive = tarGzArchive(t,
+		[]*tar.Header{{Name: "./", Typeflag: tar.TypeDir, Mode: 0755}, {Name: "./main.go.tmpl", Typeflag: tar.TypeReg}},
+		[]string{"", "package main"})
+	tmpl, err = adminService.UploadTemplate(model.TemplateUpload{ID: "api-fiber", Version: "v1.1.0"}, archive)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "api-fiber", tmpl.Name)
+		assert.Equal(t, "1.1.0", tmpl.Version, "versions are stored without a v prefix")
+	}
+
+	// Drafts cannot be deprecated
+	_, err = adminService.DeprecateTemplate("api-fiber", "1.1.0")
+	assert.Error(t, err)
+	deprecated, err := adminService.DeprecateTemplate("api-fiber", "v1.0.0")
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.TemplateStatusDeprecated, deprecated.Status)
+	}
+
+	listed, err := adminService.ListTemplates()
+	assert.NoError(t, err)
+	assert.Len(t, listed, 2)
+
+	assert.NoError(t, adminService.DeleteTemplate("api-fiber", "1.1.0"))
+	assert.ErrorIs(t, adminService.DeleteTemplate("api-fiber", "1.1.0"), repository.ErrNotFound)
+}
+
+// TestUploadTemplateRejectsInvalidUploads ensures invalid metadata, archives and templates are reported
+func TestUploadTemplateRejectsInvalidUploads(t *testing.T) {
+	valid := model.TemplateUpload{ID: "api-fiber", Version: "1.0.0"}
+
+	tests := []struct {
+		name     string
+		upload   model.TemplateUpload
+		archive  func(t *testing.T) *bytes.Buffer
+		problems []string
+	}{
+		{
+			name:     "Invalid ID and version",
+			upload:   model.TemplateUpload{ID: "../api", Version: "latest"},
+			archive:  func(t *testing.T) *bytes.Buffer { return zipArchive(t, map[string]string{"main.go.tmpl": "x"}) },
+			problems: []string{`id: "../api" must be`, `version: "latest" is not a semantic version`},
+		},
+		{
+			name:     "Zip slip",
+			upload:   valid,
+			archive:  func(t *testing.T) *bytes.Buffer { return zipArchive(t, map[string]string{"../evil.txt": "x"}) },
+			problems: []string{"../evil.txt: invalid path"},
+		},
+		{
+			name:   "Absolute tar path",
+			upload: valid,
+			archive: func(t *testing.T) *bytes.Buffer {
+				return tarGzArchive(t, []*tar.Header{{Name: "/etc/evil", Typeflag: tar.TypeReg}}, []string{"x"})
+			},
+			problems: []string{"/etc/evil: invalid path"},
+		},
+		{
+			name:   "Symlink",
+			upload: valid,
+			archive: func(t *testing.T) *bytes.Buffer {
+				return tarGzArchive(t, []*tar.Header{{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}}, []string{""})
+			},
+			problems: []string{"link: links and special files are not allowed"},
+		},
+		{
+			name:     "Empty archive",
+			upload:   valid,
+			archive:  func(t *testing.T) *bytes.Buffer { return zipArchive(t, map[string]string{}) },
+			problems: []string{"archive contains no files"},
+		},
+		{
+			name:   "Parse errors in every file",
+			upload: valid,
+			archive: func(t *testing.T) *bytes.Buffer {
+				return zipArchive(t, map[string]string{"main.go.tmpl": "{{if}}", "README.md.tmpl": "line\n{{.Broken"})
+			},
+			problems: []string{"README.md.tmpl:2", "main.go.tmpl:1"},
+		},
+		{
+			name:   "Render error",
+			upload: valid,
+			archive: func(t *testing.T) *bytes.Buffer {
+				return zipArchive(t, map[string]string{"main.go.tmpl": `{{template "missing" .}}`})
+			},
+			problems: []string{`template "missing" not defined`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adminService, store := newAdminService(t)
+
+			_, err := adminService.UploadTemplate(tt.upload, tt.archive(t))
+			found := problems(t, err)
+			assert.Len(t, found, len(tt.problems))
+			for i, problem := range tt.problems {
+				if i < len(found) {
+					assert.Contains(t, found[i], problem)
+				}
+			}
+
+			stored, err := store.List()
+			assert.NoError(t, err)
+			assert.Empty(t, stored, "invalid uploads must not be stored")
+		})
+	}
+}
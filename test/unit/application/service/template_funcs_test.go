@@ -0,0 +1,77 @@
+package service_test
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+
+	"github.com/regiwitanto/go-scaffold/internal/application/service"
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// renderString renders an inline template with the generator's data and function library
+func renderString(t *testing.T, options model.ScaffoldOptions, text string) string {
+	t.Helper()
+
+	tmpl, err := template.New("test").Funcs(service.TemplateFuncs(options)).Parse(text)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, service.TemplateData(options)); err != nil {
+		t.Fatalf("Failed to execute template: %v", err)
+	}
+
+	return buf.String()
+}
+
+// Test the template function library
+func TestTemplateFuncs(t *testing.T) {
+	options := model.ScaffoldOptions{
+		AppType:         "api",
+		RouterType:      "echo",
+		DatabaseType:    "postgresql",
+		LogFormat:       "",
+		ModulePath:      "github.com/acme/billing-api/v2",
+		Features:        []string{"basic-auth", "sql-migrations"},
+		PremiumFeatures: []string{"user-accounts"},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"hasFeature", `{{hasFeature "basic-auth"}} {{hasFeature "email"}} {{hasFeature "user-accounts"}}`, "true false true"},
+		{"legacy HasFeature", `{{call .HasFeature "sql-migrations"}}`, "true"},
+		{"hasAny", `{{hasAny "email" "basic-auth"}} {{hasAny "email" "live-reload"}}`, "true false"},
+		{"hasAll", `{{hasAll "basic-auth" "sql-migrations"}} {{hasAll "basic-auth" "email"}}`, "true false"},
+		{"dbIs", `{{dbIs "mysql" "postgresql"}} {{dbIs "none"}}`, "true false"},
+		{"routerIs", `{{routerIs "echo"}} {{routerIs "chi" "gin"}}`, "true false"},
+		{"projectName", `{{projectName}}`, "billing-api"},
+		{"camel", `{{camel "user-accounts"}} {{camel "HTTPServer"}}`, "userAccounts httpServer"},
+		{"pascal", `{{pascal "user_accounts"}} {{pascal "billing-api"}}`, "UserAccounts BillingApi"},
+		{"snake", `{{snake "UserAccounts"}} {{snake "billing-api"}}`, "user_accounts billing_api"},
+		{"kebab", `{{kebab "UserAccounts"}} {{kebab "billing_api"}}`, "user-accounts billing-api"},
+		{"plural", `{{plural "user"}} {{plural "category"}} {{plural "box"}} {{plural "key"}}`, "users categories boxes keys"},
+		{"indent", `{{indent 2 "a\nb"}}`, "  a\n  b"},
+		{"quote", `{{quote "say \"hi\""}}`, `"say \"hi\""`},
+		{"toJSON", `{{toJSON .Features}}`, `["basic-auth","sql-migrations"]`},
+		{"default", `{{.LogFormat | default "text"}} {{.RouterType | default "standard"}}`, "text echo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, renderString(t, options, tt.template))
+		})
+	}
+}
+
+// Test projectName for module paths without a major version suffix
+func TestTemplateFuncsProjectName(t *testing.T) {
+	assert.Equal(t, "project", renderString(t, model.ScaffoldOptions{ModulePath: "github.com/username/project"}, `{{projectName}}`))
+	assert.Equal(t, "myapp", renderString(t, model.ScaffoldOptions{ModulePath: "myapp"}, `{{projectName}}`))
+	assert.Equal(t, "app", renderString(t, model.ScaffoldOptions{}, `{{projectName}}`))
+}
@@ -0,0 +1,59 @@
+package service_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/regiwitanto/go-scaffold/internal/application/service"
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/test/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLintTemplates ensures the linter reports unknown fields, unknown features and unreachable files
+func TestLintTemplates(t *testing.T) {
+	fsys := fstest.MapFS{
+		// Known fields, fields of range and with elements, and define-only files are fine
+		"api/echo/main.go.tmpl": {Data: []byte("package main // {{.ModulePath}}\n" +
+			"{{range .Features}}{{.Name}}{{end}}{{with .DatabaseType}}{{.Driver}}{{end}}\n" +
+			"{{if hasFeature \"email\"}}email{{end}}{{if call .HasFeature \"basic-auth\"}}auth{{end}}")},
+		"api/echo/README.md.tmpl": {Data: []byte(`{{define "readme-framework"}}{{.Framework}}{{end}}`)},
+
+		// Unknown fields, also through $ and inside define blocks
+		"api/_base/Makefile.tmpl": {Data: []byte("BIN={{.Binary}} {{.Binary}}\n{{range .Features}}{{$.Output}}{{end}}")},
+
+		// Unknown features in every form
+		"shared/partials/config.tmpl": {Data: []byte("{{define \"config-env\"}}\n" +
+			"{{if hasFeature \"env-godotenv\"}}a{{end}}{{if hasAny \"email\" \"smtp\"}}b{{end}}\n" +
+			"{{if call .HasFeature \"dotenv\"}}c{{end}}{{end}}")},
+
+		// Files whose whole output is behind conditions that are never true
+		"api/echo/godotenv.go.tmpl": {Data: []byte(`{{if (hasFeature "env-godotenv")}}package config{{end}}`)},
+		"api/echo/gin.go.tmpl":      {Data: []byte("{{if routerIs \"gin\" \"chi\"}}package gin{{end}}\n")},
+		"api/_base/gin.go.tmpl":     {Data: []byte(`{{if routerIs "gin"}}package gin{{end}}`)},
+		"api/echo/other.go.tmpl":    {Data: []byte(`{{if not (routerIs "echo")}}package other{{else}}package echo{{end}}`)},
+
+		// Parse errors
+		"api/echo/broken.go.tmpl": {Data: []byte("package main\n{{if}}")},
+		"api/echo/static.txt":     {Data: []byte(`{{.NotLinted}}`)},
+	}
+
+	generatorService := service.NewGeneratorService(&mocks.MockTemplateRepository{}, &mocks.MockScaffoldRepository{}, t.TempDir())
+	findings, err := generatorService.LintTemplates(fsys)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []model.LintFinding{
+		{Rule: model.LintRuleUnknownField, Severity: model.LintSeverityError, Path: "api/_base/Makefile.tmpl", Line: 1, Message: "unknown field .Binary"},
+		{Rule: model.LintRuleUnknownField, Severity: model.LintSeverityError, Path: "api/_base/Makefile.tmpl", Line: 2, Message: "unknown field $.Output"},
+		{Rule: model.LintRuleUnknownField, Severity: model.LintSeverityError, Path: "api/echo/README.md.tmpl", Line: 1, Message: "unknown field .Framework"},
+		{Rule: model.LintRuleParseError, Severity: model.LintSeverityError, Path: "api/echo/broken.go.tmpl", Line: 2, Message: "missing value for if"},
+		{Rule: model.LintRuleUnreachableFile, Severity: model.LintSeverityWarning, Path: "api/echo/gin.go.tmpl", Message: "every output of the file is behind conditions that are never true, so it always renders empty"},
+		{Rule: model.LintRuleUnreachableFile, Severity: model.LintSeverityWarning, Path: "api/echo/godotenv.go.tmpl", Message: "every output of the file is behind conditions that are never true, so it always renders empty"},
+		{Rule: model.LintRuleUnknownFeature, Severity: model.LintSeverityError, Path: "api/echo/godotenv.go.tmpl", Line: 1, Message: `unknown feature "env-godotenv"`},
+		{Rule: model.LintRuleUnknownFeature, Severity: model.LintSeverityError, Path: "shared/partials/config.tmpl", Line: 2, Message: `unknown feature "env-godotenv"`},
+		{Rule: model.LintRuleUnknownFeature, Severity: model.LintSeverityError, Path: "shared/partials/config.tmpl", Line: 2, Message: `unknown feature "smtp"`},
+		{Rule: model.LintRuleUnknownFeature, Severity: model.LintSeverityError, Path: "shared/partials/config.tmpl", Line: 3, Message: `unknown feature "dotenv"`},
+	}, findings)
+}
@@ -0,0 +1,225 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/regiwitanto/go-scaffold/internal/application/service"
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/test/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTree creates the given files below root
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+// Test that router templates are composed with the base tree and shared partials
+func TestGenerateScaffoldComposesBaseAndPartials(t *testing.T) {
+	root := t.TempDir()
+	partialsPath := filepath.Join(root, "shared", "partials")
+	basePath := filepath.Join(root, "api", "_base")
+	routerPath := filepath.Join(root, "api", "echo")
+
+	writeTree(t, partialsPath, map[string]string{
+		"greeting.tmpl": `{{define "greeting"}}hello from {{.RouterType}}{{end}}`,
+	})
+	writeTree(t, basePath, map[string]string{
+		"README.md.tmpl":      `# {{projectName}} built with {{block "readme-framework" .}}Go{{end}}`,
+		"Makefile.tmpl":       `base makefile`,
+		"inherited.txt.tmpl":  `{{template "greeting" .}}`,
+		"assets/logo.txt":     `base logo`,
+		"assets/untouched.md": `untouched`,
+	})
+	writeTree(t, routerPath, map[string]string{
+		"README.md.tmpl":  "{{define \"readme-framework\"}}Echo{{end}}\n",
+		"Makefile.tmpl":   `router makefile`,
+		"assets/logo.txt": `router logo`,
+		"main.go.tmpl":    `package main // {{template "greeting" .}}`,
+	})
+
+	mockTemplateRepo := &mocks.MockTemplateRepository{
+		GetByTypeFunc: func(templateType string) ([]*model.Template, error) {
+			return []*model.Template{
+				{
+					ID:           "api-echo",
+					Path:         routerPath,
+					Type:         "api",
+					BasePath:     basePath,
+					PartialsPath: partialsPath,
+				},
+			}, nil
+		},
+	}
+
+	generatorService := service.NewGeneratorService(mockTemplateRepo, &mocks.MockScaffoldRepository{}, t.TempDir())
+
+	scaffold, err := generatorService.GenerateScaffold(model.ScaffoldOptions{
+		AppType:    "api",
+		RouterType: "echo",
+		ModulePath: "github.com/example/shop",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	files := readZipFiles(t, scaffold.FilePath)
+	assert.Equal(t, "# shop built with Echo", files["codebase/README.md"], "block override should extend the base file")
+	assert.Equal(t, "router makefile", files["codebase/Makefile"], "a full router template should replace the base file")
+	assert.Equal(t, "hello from echo", files["codebase/inherited.txt"], "base files should be inherited")
+	assert.Equal(t, "package main // hello from echo", files["codebase/main.go"])
+	assert.Equal(t, "router logo", files["codebase/assets/logo.txt"], "router files should override base files")
+	assert.Equal(t, "untouched", files["codebase/assets/untouched.md"])
+	assert.NotContains(t, files, "codebase/greeting")
+}
+
+// Test that template trees are read from the template's filesystem when it has one
+func TestGenerateScaffoldFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"shared/partials/name.tmpl": {Data: []byte(`{{define "name"}}{{projectName}}{{end}}`)},
+		"api/_base/README.md.tmpl":  {Data: []byte(`# {{template "name" .}}`)},
+		"api/_base/assets/logo.txt": {Data: []byte(`logo`)},
+		"api/echo/main.go.tmpl":     {Data: []byte(`package main // {{.RouterType}}`)},
+		"api/echo/.gitignore":       {Data: []byte(`bin/`)},
+	}
+
+	mockTemplateRepo := &mocks.MockTemplateRepository{
+		GetByTypeFunc: func(templateType string) ([]*model.Template, error) {
+			return []*model.Template{{
+				ID:           "api-echo",
+				Path:         "api/echo",
+				Type:         "api",
+				FS:           fsys,
+				BasePath:     "api/_base",
+				PartialsPath: "shared/partials",
+			}}, nil
+		},
+	}
+
+	generatorService := service.NewGeneratorService(mockTemplateRepo, &mocks.MockScaffoldRepository{}, t.TempDir())
+	scaffold, err := generatorService.GenerateScaffold(model.ScaffoldOptions{
+		AppType:    "api",
+		RouterType: "echo",
+		ModulePath: "github.com/example/app",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	files := readZipFiles(t, scaffold.FilePath)
+	assert.Equal(t, "# app", files["codebase/README.md"])
+	assert.Equal(t, "logo", files["codebase/assets/logo.txt"])
+	assert.Equal(t, "package main // echo", files["codebase/main.go"])
+	assert.Equal(t, "bin/", files["codebase/.gitignore"])
+}
+
+// Test that template trees are parsed once and executed with each request's options
+func TestGenerateScaffoldCachesParsedTemplates(t *testing.T) {
+	routerPath := t.TempDir()
+	writeTree(t, routerPath, map[string]string{
+		"features.txt.tmpl": `{{if hasFeature "email"}}email{{else}}no email{{end}}`,
+	})
+
+	mockTemplateRepo := &mocks.MockTemplateRepository{
+		GetByTypeFunc: func(templateType string) ([]*model.Template, error) {
+			return []*model.Template{{ID: "api-echo", Path: routerPath, Type: "api", Version: "1.0.0"}}, nil
+		},
+	}
+	mockTemplateRepo.GetAllFunc = func() ([]*model.Template, error) {
+		return mockTemplateRepo.GetByTypeFunc("api")
+	}
+
+	generatorService := service.NewGeneratorService(mockTemplateRepo, &mocks.MockScaffoldRepository{}, t.TempDir())
+	assert.NoError(t, generatorService.CompileTemplates())
+
+	// Changes on disk are not picked up once the tree is compiled
+	writeTree(t, routerPath, map[string]string{
+		"features.txt.tmpl": `changed`,
+	})
+
+	options := model.ScaffoldOptions{AppType: "api", RouterType: "echo", ModulePath: "github.com/example/app"}
+	withEmail := options
+	withEmail.Features = []string{"email"}
+
+	for _, tc := range []struct {
+		options  model.ScaffoldOptions
+		expected string
+	}{
+		{withEmail, "email"},
+		{options, "no email"},
+	} {
+		scaffold, err := generatorService.GenerateScaffold(tc.options)
+		if assert.NoError(t, err) {
+			assert.Equal(t, tc.expected, readZipFiles(t, scaffold.FilePath)["codebase/features.txt"])
+		}
+	}
+}
+
+// Test that recompiling picks up valid changes and keeps the last valid tree on errors
+func TestCompileTemplatesKeepsLastValidVersion(t *testing.T) {
+	routerPath := t.TempDir()
+	writeTree(t, routerPath, map[string]string{
+		"version.txt.tmpl": `v1`,
+	})
+
+	mockTemplateRepo := &mocks.MockTemplateRepository{
+		GetAllFunc: func() ([]*model.Template, error) {
+			return []*model.Template{{ID: "api-echo", Path: routerPath, Type: "api"}}, nil
+		},
+		GetByTypeFunc: func(templateType string) ([]*model.Template, error) {
+			return []*model.Template{{ID: "api-echo", Path: routerPath, Type: "api"}}, nil
+		},
+	}
+
+	generatorService := service.NewGeneratorService(mockTemplateRepo, &mocks.MockScaffoldRepository{}, t.TempDir())
+	assert.NoError(t, generatorService.CompileTemplates())
+
+	options := model.ScaffoldOptions{AppType: "api", RouterType: "echo", ModulePath: "github.com/example/app"}
+	generatedVersion := func() string {
+		scaffold, err := generatorService.GenerateScaffold(options)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		return readZipFiles(t, scaffold.FilePath)["codebase/version.txt"]
+	}
+
+	// A valid change replaces the compiled tree
+	writeTree(t, routerPath, map[string]string{"version.txt.tmpl": `v2`})
+	assert.NoError(t, generatorService.CompileTemplates())
+	assert.Equal(t, "v2", generatedVersion())
+
+	// A parse error is reported with file and line, and v2 keeps being served
+	brokenPath := filepath.Join(routerPath, "version.txt.tmpl")
+	writeTree(t, routerPath, map[string]string{"version.txt.tmpl": "v3\n{{if}}"})
+	err := generatorService.CompileTemplates()
+	var tmplErr *service.TemplateError
+	if assert.ErrorAs(t, err, &tmplErr) {
+		assert.Equal(t, "parse", tmplErr.Op)
+		assert.Equal(t, brokenPath, tmplErr.Path)
+		assert.Equal(t, 2, tmplErr.Line)
+		assert.Contains(t, err.Error(), brokenPath+":2")
+	}
+	assert.Equal(t, "v2", generatedVersion())
+
+	// Errors that only surface during execution are caught as well
+	writeTree(t, routerPath, map[string]string{"version.txt.tmpl": `{{indent "two" "x"}}`})
+	err = generatorService.CompileTemplates()
+	if assert.ErrorAs(t, err, &tmplErr) {
+		assert.Equal(t, "execute", tmplErr.Op)
+		assert.Equal(t, 1, tmplErr.Line)
+	}
+	assert.Equal(t, "v2", generatedVersion())
+}
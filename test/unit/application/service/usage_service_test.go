@@ -0,0 +1,166 @@
+package service_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/regiwitanto/go-scaffold/internal/application/service"
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	domainservice "github.com/regiwitanto/go-scaffold/internal/domain/service"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/scaffold"
+	"github.com/regiwitanto/go-scaffold/test/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a clock that only moves when told to
+type fakeClock struct {
+	now time.Time
+}
+
+// Now returns the current time of the clock
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// quotaError returns err as a *QuotaError, failing the test if it is not one
+func quotaError(t *testing.T, err error) *domainservice.QuotaError {
+	t.Helper()
+
+	var quotaErr *domainservice.QuotaError
+	if !errors.As(err, &quotaErr) {
+		t.Fatalf("expected a quota error, got %v", err)
+	}
+	return quotaErr
+}
+
+// TestUsageServiceRateLimit ensures each client gets a token bucket that refills at the configured rate
+func TestUsageServiceRateLimit(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	usage := service.NewUsageService(model.Limits{RequestsPerMinute: 60, Burst: 2}, service.WithClock(clock.Now))
+
+	assert.NoError(t, usage.Allow("ip:192.0.2.1", nil))
+	assert.NoError(t, usage.Allow("ip:192.0.2.1", nil))
+
+	quotaErr := quotaError(t, usage.Allow("ip:192.0.2.1", nil))
+	assert.Equal(t, time.Second, quotaErr.RetryAfter)
+	assert.NoError(t, usage.Allow("ip:192.0.2.2", nil), "clients should not share a bucket")
+
+	clock.now = clock.now.Add(time.Second)
+	assert.NoError(t, usage.Allow("ip:192.0.2.1", nil))
+	assert.Error(t, usage.Allow("ip:192.0.2.1", nil))
+
+	// A plan with limits of its own replaces the defaults
+	unlimited := &model.Plan{ID: "unlimited", Limits: &model.Limits{}}
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, usage.Allow("key:acme", unlimited))
+	}
+}
+
+// TestUsageServiceGenerationQuotas ensures the daily generation and storage quotas are enforced and reported
+func TestUsageServiceGenerationQuotas(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 18, 0, 0, 0, time.UTC)}
+	usage := service.NewUsageService(model.Limits{DailyGenerations: 2, StorageBytes: 1000}, service.WithClock(clock.Now))
+
+	// Failed generations do not count
+	assert.NoError(t, usage.ReserveGeneration("ip:192.0.2.1", nil))
+	assert.NoError(t, usage.CompleteGeneration("ip:192.0.2.1", nil))
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, usage.ReserveGeneration("ip:192.0.2.1", nil))
+		assert.NoError(t, usage.CompleteGeneration("ip:192.0.2.1", &model.GeneratedScaffold{Size: 300}))
+	}
+
+	quotaErr := quotaError(t, usage.ReserveGeneration("ip:192.0.2.1", nil))
+	assert.Equal(t, 6*time.Hour, quotaErr.RetryAfter, "the daily quota should reset at midnight UTC")
+
+	report := usage.GetUsage("ip:192.0.2.1", nil)
+	assert.Equal(t, "ip:192.0.2.1", report.Client)
+	assert.Equal(t, 2, report.GenerationsToday)
+	assert.Equal(t, int64(600), report.StorageBytes)
+	assert.Equal(t, time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), report.GenerationsResetAt)
+
+	// The next day only the storage quota is left to reach
+	clock.now = clock.now.Add(6 * time.Hour)
+	assert.NoError(t, usage.ReserveGeneration("ip:192.0.2.1", nil))
+	assert.NoError(t, usage.CompleteGeneration("ip:192.0.2.1", &model.GeneratedScaffold{Size: 400}))
+
+	quotaErr = quotaError(t, usage.ReserveGeneration("ip:192.0.2.1", nil))
+	assert.Contains(t, quotaErr.Message, "storage quota")
+	assert.Zero(t, quotaErr.RetryAfter, "stored scaffolds are not freed over time")
+}
+
+// TestUsageServiceStorageQuotaCountsArchive ensures the archive that would cross the storage quota is rejected
+func TestUsageServiceStorageQuotaCountsArchive(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 18, 0, 0, 0, time.UTC)}
+	usage := service.NewUsageService(model.Limits{DailyGenerations: 10, StorageBytes: 1000}, service.WithClock(clock.Now))
+
+	assert.NoError(t, usage.ReserveGeneration("ip:192.0.2.1", nil))
+	assert.NoError(t, usage.CompleteGeneration("ip:192.0.2.1", &model.GeneratedScaffold{Size: 600}))
+
+	// Below the quota a generation is reserved, but its archive does not fit
+	assert.NoError(t, usage.ReserveGeneration("ip:192.0.2.1", nil))
+	quotaErr := quotaError(t, usage.CompleteGeneration("ip:192.0.2.1", &model.GeneratedScaffold{Size: 500}))
+	assert.Contains(t, quotaErr.Message, "storage quota of 1000 bytes")
+
+	report := usage.GetUsage("ip:192.0.2.1", nil)
+	assert.Equal(t, int64(600), report.StorageBytes, "the rejected archive should not be counted")
+	assert.Equal(t, 1, report.GenerationsToday, "the rejected generation should be released")
+
+	// An archive that fills the quota exactly fits
+	assert.NoError(t, usage.ReserveGeneration("ip:192.0.2.1", nil))
+	assert.NoError(t, usage.CompleteGeneration("ip:192.0.2.1", &model.GeneratedScaffold{Size: 400}))
+	assert.Equal(t, int64(1000), usage.GetUsage("ip:192.0.2.1", nil).StorageBytes)
+}
+
+// TestUsageServiceStorageCountsSharedArchivesOnce ensures deduplicated generations
+// sharing one archive are charged for it once, until the last of them is deleted
+func TestUsageServiceStorageCountsSharedArchivesOnce(t *testing.T) {
+	templateDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(templateDir, "go.mod.tmpl"), []byte(`module {{.ModulePath}}`), 0644))
+
+	templates := func() ([]*model.Template, error) {
+		return []*model.Template{{ID: "api-echo", Path: templateDir, Type: "api", Router: "echo"}}, nil
+	}
+	mockTemplateRepo := &mocks.MockTemplateRepository{
+		GetAllFunc:    templates,
+		GetByTypeFunc: func(templateType string) ([]*model.Template, error) { return templates() },
+	}
+	generatorService := service.NewGeneratorService(mockTemplateRepo, scaffold.NewInMemoryRepository(), t.TempDir())
+	usage := service.NewUsageService(model.Limits{DailyGenerations: 10, StorageBytes: 1 << 20})
+
+	options := model.ScaffoldOptions{AppType: "api", RouterType: "echo", ModulePath: "github.com/example/api", Owner: "key:acme"}
+	generate := func() *model.GeneratedScaffold {
+		t.Helper()
+		if err := usage.ReserveGeneration("key:acme", nil); err != nil {
+			t.Fatalf("failed to reserve generation: %v", err)
+		}
+		generated, err := generatorService.GenerateScaffold(options)
+		if err != nil {
+			t.Fatalf("failed to generate scaffold: %v", err)
+		}
+		if err := usage.CompleteGeneration("key:acme", generated); err != nil {
+			t.Fatalf("failed to complete generation: %v", err)
+		}
+		return generated
+	}
+
+	first := generate()
+	shared := generate()
+	assert.Equal(t, first.FilePath, shared.FilePath, "identical options should share an archive")
+	assert.Equal(t, first.Size, usage.GetUsage("key:acme", nil).StorageBytes, "a shared archive should be counted once")
+	assert.Equal(t, 2, usage.GetUsage("key:acme", nil).GenerationsToday)
+
+	// Deleting one scaffold keeps the archive on disk, and in the quota
+	assert.NoError(t, generatorService.DeleteScaffold(first.ID))
+	usage.ReleaseStorage("key:acme", first)
+	assert.FileExists(t, shared.FilePath)
+	assert.Equal(t, shared.Size, usage.GetUsage("key:acme", nil).StorageBytes)
+
+	assert.NoError(t, generatorService.DeleteScaffold(shared.ID))
+	usage.ReleaseStorage("key:acme", shared)
+	assert.NoFileExists(t, shared.FilePath)
+	assert.Zero(t, usage.GetUsage("key:acme", nil).StorageBytes)
+}
@@ -0,0 +1,362 @@
+package service_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/regiwitanto/go-scaffold/internal/application/service"
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	domainservice "github.com/regiwitanto/go-scaffold/internal/domain/service"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+// webhookReceiver is a local stand-in for the endpoint of a webhook, which
+// answers with the queued statuses and then 200
+type webhookReceiver struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	statuses []int
+	requests []receivedRequest
+}
+
+// receivedRequest is a request received by a webhookReceiver
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// newWebhookReceiver starts a webhook receiver answering with statuses before succeeding
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	r := &webhookReceiver{statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		r.mutex.Lock()
+		r.requests = append(r.requests, receivedRequest{header: req.Header.Clone(), body: body})
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		r.mutex.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// received returns the requests received so far
+func (r *webhookReceiver) received() []receivedRequest {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]receivedRequest(nil), r.requests...)
+}
+
+// newTestWebhookRepository returns a webhook repository in a temporary directory
+func newTestWebhookRepository(t *testing.T) *webhook.FileRepository {
+	repo, err := webhook.NewFileRepository(filepath.Join(t.TempDir(), "webhooks.json"))
+	if err != nil {
+		t.Fatalf("Failed to open webhook repository: %v", err)
+	}
+	return repo
+}
+
+// newTestWebhookService returns a webhook service retrying quickly, storing
+// webhooks in a temporary directory. It delivers to local receivers.
+func newTestWebhookService(t *testing.T, attempts int) *service.WebhookServiceImpl {
+	return service.NewWebhookService(newTestWebhookRepository(t),
+		service.WithWebhookRetries(attempts, time.Millisecond), service.WithPrivateWebhookTargets())
+}
+
+// TestWebhookDelivery ensures events are posted as signed JSON and logged as succeeded
+func TestWebhookDelivery(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+	webhookService := newTestWebhookService(t, 3)
+
+	created, err := webhookService.CreateWebhook(&model.Webhook{URL: receiver.URL, Owner: "key:acme"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotEmpty(t, created.ID)
+	assert.Regexp(t, `^whsec_[0-9a-f]{64}$`, created.Secret)
+
+	scaffold := &model.GeneratedScaffold{ID: "scaffold-1", Owner: "key:acme"}
+	webhookService.Publish("key:acme", model.EventScaffoldGenerated, scaffold)
+	webhookService.Publish("key:other", model.EventScaffoldGenerated, scaffold)
+	webhookService.Wait()
+
+	requests := receiver.received()
+	if !assert.Len(t, requests, 1, "events are only delivered to webhooks of their owner") {
+		return
+	}
+	header := requests[0].header
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Equal(t, model.EventScaffoldGenerated, header.Get(service.HeaderWebhookEvent))
+	assert.Equal(t,
+		service.SignWebhookPayload(created.Secret, header.Get(service.HeaderWebhookTimestamp), requests[0].body),
+		header.Get(service.HeaderWebhookSignature))
+	assert.NotEqual(t,
+		service.SignWebhookPayload("whsec_wrong", header.Get(service.HeaderWebhookTimestamp), requests[0].body),
+		header.Get(service.HeaderWebhookSignature))
+
+	var event model.WebhookEvent
+	assert.NoError(t, json.Unmarshal(requests[0].body, &event))
+	assert.Equal(t, model.EventScaffoldGenerated, event.Type)
+	assert.Equal(t, "scaffold-1", event.Data.(map[string]interface{})["id"])
+
+	deliveries, err := webhookService.ListDeliveries(created.ID, "")
+	assert.NoError(t, err)
+	if assert.Len(t, deliveries, 1) {
+		assert.Equal(t, header.Get(service.HeaderWebhookDelivery), deliveries[0].ID)
+		assert.Equal(t, event.ID, deliveries[0].EventID)
+		assert.Equal(t, model.DeliverySucceeded, deliveries[0].Status)
+		assert.Equal(t, 1, deliveries[0].Attempts)
+		assert.Equal(t, http.StatusOK, deliveries[0].ResponseStatus)
+	}
+}
+
+// TestWebhookRetries ensures failed attempts are retried with the same delivery ID until they succeed or run out
+func TestWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name             string
+		statuses         []int
+		expectedStatus   string
+		expectedAttempts int
+		expectedResponse int
+	}{
+		{name: "Recovers", statuses: []int{500, 503}, expectedStatus: model.DeliverySucceeded, expectedAttempts: 3, expectedResponse: 200},
+		{name: "Gives up", statuses: []int{500, 500, 410, 200}, expectedStatus: model.DeliveryFailed, expectedAttempts: 3, expectedResponse: 410},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := newWebhookReceiver(t, tt.statuses...)
+			webhookService := newTestWebhookService(t, 3)
+			created, err := webhookService.CreateWebhook(&model.Webhook{URL: receiver.URL, Owner: "key:acme"})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			webhookService.Publish("key:acme", model.EventScaffoldExpired, &model.GeneratedScaffold{ID: "scaffold-1"})
+			webhookService.Wait()
+
+			requests := receiver.received()
+			if !assert.Len(t, requests, tt.expectedAttempts) {
+				return
+			}
+			for _, r := range requests {
+				assert.Equal(t, requests[0].header.Get(service.HeaderWebhookDelivery), r.header.Get(service.HeaderWebhookDelivery))
+				assert.Equal(t, requests[0].body, r.body, "every attempt delivers the same event")
+			}
+
+			deliveries, err := webhookService.ListDeliveries(created.ID, tt.expectedStatus)
+			assert.NoError(t, err)
+			if assert.Len(t, deliveries, 1) {
+				assert.Equal(t, tt.expectedAttempts, deliveries[0].Attempts)
+				assert.Equal(t, tt.expectedResponse, deliveries[0].ResponseStatus)
+				assert.Empty(t, deliveries[0].NextAttemptAt)
+				assert.Equal(t, tt.expectedStatus == model.DeliveryFailed, deliveries[0].Error != "")
+			}
+		})
+	}
+}
+
+// TestWebhookClose ensures closing the service gives up deliveries in flight or
+// waiting for a retry, logging them as failed, and refuses further deliveries
+func TestWebhookClose(t *testing.T) {
+	tests := []struct {
+		name     string
+		inFlight bool
+	}{
+		{name: "Waiting for a retry"},
+		{name: "In flight", inFlight: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arrived := make(chan struct{}, 1)
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				// The request is only cancelled once its body is read
+				io.ReadAll(req.Body)
+				if tt.inFlight {
+					arrived <- struct{}{}
+					<-req.Context().Done()
+					return
+				}
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			t.Cleanup(receiver.Close)
+
+			// Retries are far enough apart that only Close can end the delivery
+			webhookService := service.NewWebhookService(newTestWebhookRepository(t),
+				service.WithWebhookRetries(5, time.Hour), service.WithPrivateWebhookTargets())
+			created, err := webhookService.CreateWebhook(&model.Webhook{URL: receiver.URL, Owner: "key:acme"})
+			if !assert.NoError(t, err) {
+				return
+			}
+			ping, err := webhookService.Ping(created.ID)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			if tt.inFlight {
+				<-arrived
+			} else if !assert.Eventually(t, func() bool {
+				deliveries, _ := webhookService.ListDeliveries(created.ID, "")
+				return len(deliveries) == 1 && deliveries[0].NextAttemptAt != ""
+			}, 5*time.Second, time.Millisecond, "the first attempt fails and a retry is scheduled") {
+				return
+			}
+			webhookService.Close()
+
+			deliveries, err := webhookService.ListDeliveries(created.ID, model.DeliveryFailed)
+			assert.NoError(t, err)
+			if assert.Len(t, deliveries, 1) {
+				assert.Equal(t, ping.ID, deliveries[0].ID)
+				assert.Equal(t, 1, deliveries[0].Attempts)
+				assert.Contains(t, deliveries[0].Error, "shutdown")
+				assert.Empty(t, deliveries[0].NextAttemptAt)
+			}
+
+			_, err = webhookService.Ping(created.ID)
+			assert.Error(t, err, "nothing is delivered once closed")
+		})
+	}
+}
+
+// TestWebhookSubscriptions ensures webhooks only receive the events they subscribe to, and pings regardless
+func TestWebhookSubscriptions(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+	webhookService := newTestWebhookService(t, 1)
+	created, err := webhookService.CreateWebhook(&model.Webhook{
+		URL:    receiver.URL,
+		Events: []string{model.EventScaffoldFailed},
+		Owner:  "key:acme",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	webhookService.Publish("key:acme", model.EventScaffoldGenerated, &model.GeneratedScaffold{})
+	webhookService.Publish("key:acme", model.EventScaffoldFailed, model.ScaffoldFailure{Error: "unknown template"})
+	ping, err := webhookService.Ping(created.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, model.DeliveryPending, ping.Status)
+	webhookService.Wait()
+
+	var types []string
+	for _, r := range receiver.received() {
+		types = append(types, r.header.Get(service.HeaderWebhookEvent))
+	}
+	assert.ElementsMatch(t, []string{model.EventScaffoldFailed, model.EventWebhookPing}, types)
+
+	_, err = webhookService.ListDeliveries(created.ID, "delivered")
+	var validationErr *domainservice.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+}
+
+// TestWebhookValidation ensures invalid webhooks are rejected with every problem and updates keep the secret
+func TestWebhookValidation(t *testing.T) {
+	webhookService := newTestWebhookService(t, 1)
+
+	_, err := webhookService.CreateWebhook(&model.Webhook{
+		URL:    "ftp://ci.acme.test",
+		Events: []string{model.EventScaffoldGenerated, "scaffold.deleted", model.EventScaffoldGenerated},
+	})
+	var validationErr *domainservice.ValidationError
+	if assert.True(t, errors.As(err, &validationErr)) {
+		assert.Equal(t, []domainservice.Problem{
+			{Field: "url", Code: domainservice.ProblemInvalidFormat, Message: `"ftp://ci.acme.test" must be an absolute http or https URL`},
+			{Field: "events[1]", Code: domainservice.ProblemInvalidValue, Message: `"scaffold.deleted" is not an event type`},
+			{Field: "events[2]", Code: domainservice.ProblemDuplicate, Message: `"scaffold.generated" is listed more than once`},
+		}, validationErr.Problems)
+	}
+
+	created, err := webhookService.CreateWebhook(&model.Webhook{URL: "http://localhost:9000/hook", Owner: "key:acme"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	updated, err := webhookService.UpdateWebhook(&model.Webhook{
+		ID:     created.ID,
+		URL:    "https://ci.acme.test/hook",
+		Events: []string{model.EventScaffoldExpired},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "https://ci.acme.test/hook", updated.URL)
+	assert.Equal(t, []string{model.EventScaffoldExpired}, updated.Events)
+	assert.Equal(t, created.Secret, updated.Secret)
+	assert.Equal(t, "key:acme", updated.Owner)
+}
+
+// TestWebhookPrivateTargets ensures webhooks cannot target loopback, private or link-local addresses unless allowed
+func TestWebhookPrivateTargets(t *testing.T) {
+	repo := newTestWebhookRepository(t)
+	webhookService := service.NewWebhookService(repo, service.WithWebhookRetries(1, time.Millisecond))
+
+	for _, target := range []string{
+		"http://127.0.0.1:9000/hook",
+		"http://localhost:9000/hook",
+		"http://[::1]/hook",
+		"http://10.0.0.8/hook",
+		"https://192.168.1.1/hook",
+		"http://172.16.0.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[fe80::1]/hook",
+		"http://0.0.0.0/hook",
+		"http://[::]/hook",
+		"http://100.64.0.1/hook",
+		"http://100.127.255.254/hook",
+		"http://[::ffff:100.64.0.1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+	} {
+		_, err := webhookService.CreateWebhook(&model.Webhook{URL: target, Owner: "key:acme"})
+		var validationErr *domainservice.ValidationError
+		if assert.True(t, errors.As(err, &validationErr), target) && assert.Len(t, validationErr.Problems, 1, target) {
+			assert.Equal(t, "url", validationErr.Problems[0].Field, target)
+			assert.Equal(t, domainservice.ProblemInvalidValue, validationErr.Problems[0].Code, target)
+		}
+	}
+
+	created, err := webhookService.CreateWebhook(&model.Webhook{URL: "https://93.184.216.34/hook", Owner: "key:acme"})
+	if assert.NoError(t, err) {
+		_, err = webhookService.UpdateWebhook(&model.Webhook{ID: created.ID, URL: "http://127.0.0.1/hook"})
+		assert.Error(t, err, "updates are checked too")
+	}
+
+	// A host that turns private after it was registered is refused when dialed
+	receiver := newWebhookReceiver(t)
+	rebound := &model.Webhook{ID: "rebound", URL: receiver.URL, Owner: "key:acme", Secret: "whsec_test", Events: []string{}}
+	if !assert.NoError(t, repo.Save(rebound)) {
+		return
+	}
+	_, err = webhookService.Ping(rebound.ID)
+	assert.NoError(t, err)
+	webhookService.Wait()
+
+	assert.Empty(t, receiver.received())
+	deliveries, err := webhookService.ListDeliveries(rebound.ID, model.DeliveryFailed)
+	if assert.NoError(t, err) && assert.Len(t, deliveries, 1) {
+		assert.Contains(t, deliveries[0].Error, "loopback, private or link-local")
+	}
+
+	// Allowing private targets lets a local stand-in receive deliveries
+	allowed := service.NewWebhookService(repo, service.WithWebhookRetries(1, time.Millisecond), service.WithPrivateWebhookTargets())
+	_, err = allowed.CreateWebhook(&model.Webhook{URL: receiver.URL, Owner: "key:acme"})
+	assert.NoError(t, err)
+	_, err = allowed.Ping(rebound.ID)
+	assert.NoError(t, err)
+	allowed.Wait()
+	assert.Len(t, receiver.received(), 1)
+}
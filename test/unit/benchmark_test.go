@@ -9,12 +9,22 @@ import (
 
 	"github.com/regiwitanto/go-scaffold/internal/application/service"
 	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/dependency"
+	templatestorage "github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/template"
 	"github.com/regiwitanto/go-scaffold/test/mocks"
 	"github.com/regiwitanto/go-scaffold/test/testutil"
 )
 
-// BenchmarkGenerateScaffold benchmarks the scaffold generation process
+// BenchmarkGenerateScaffold benchmarks the scaffold generation process.
+// "cached" reuses one service so template trees are parsed once, while
+// "uncached" creates a new service per iteration and parses on every request.
 func BenchmarkGenerateScaffold(b *testing.B) {
+	// Find project root
+	rootDir, err := testutil.FindProjectRoot()
+	if err != nil {
+		b.Fatalf("Failed to find project root: %v", err)
+	}
+
 	// Create temporary directory for test
 	tmpDir, err := os.MkdirTemp("", "scaffold-bench")
 	if err != nil {
@@ -22,29 +32,22 @@ func BenchmarkGenerateScaffold(b *testing.B) {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	// Create mock repositories
-	mockTemplateRepo := &mocks.MockTemplateRepository{}
+	// Create repositories backed by the built-in templates
+	templateRepo, err := templatestorage.NewFilesystemRepository(filepath.Join(rootDir, "templates"))
+	if err != nil {
+		b.Fatalf("Failed to create template repository: %v", err)
+	}
+	dependencyRepo, err := dependency.NewCatalogRepository(filepath.Join(rootDir, "templates", "dependencies.json"))
+	if err != nil {
+		b.Fatalf("Failed to load dependency catalog: %v", err)
+	}
 	mockScaffoldRepo := &mocks.MockScaffoldRepository{}
 
-	// Setup specific behavior for GetByType
-	mockTemplateRepo.GetByTypeFunc = func(templateType string) ([]*model.Template, error) {
-		if templateType == "api" {
-			return []*model.Template{
-				{
-					ID:          "api-echo",
-					Name:        "API with Echo",
-					Description: "API template using Echo framework",
-					Path:        "templates/api/echo",
-					Type:        "api",
-				},
-			}, nil
-		}
-		return nil, nil
+	newService := func() *service.GeneratorServiceImpl {
+		return service.NewGeneratorService(templateRepo, mockScaffoldRepo, tmpDir,
+			service.WithDependencyRepository(dependencyRepo))
 	}
 
-	// Create service
-	generatorService := service.NewGeneratorService(mockTemplateRepo, mockScaffoldRepo, tmpDir)
-
 	// Define options for benchmarking
 	options := model.ScaffoldOptions{
 		AppType:      "api",
@@ -56,29 +59,40 @@ func BenchmarkGenerateScaffold(b *testing.B) {
 		Features:     []string{"basic-auth", "sql-migrations"},
 	}
 
-	// Reset the timer before the benchmark loop
-	b.ResetTimer()
-
-	// Run the benchmark
-	for i := 0; i < b.N; i++ {
-		scaffold, err := generatorService.GenerateScaffold(options)
-		if err != nil {
-			b.Fatalf("Failed to generate scaffold: %v", err)
-		}
-
-		// Verify the scaffold was created
-		if scaffold == nil {
-			b.Fatal("Expected scaffold to be returned, got nil")
+	run := func(b *testing.B, generatorService func() *service.GeneratorServiceImpl) {
+		for i := 0; i < b.N; i++ {
+			scaffold, err := generatorService().GenerateScaffold(options)
+			if err != nil {
+				b.Fatalf("Failed to generate scaffold: %v", err)
+			}
+
+			// Verify the scaffold was created
+			if scaffold == nil {
+				b.Fatal("Expected scaffold to be returned, got nil")
+			}
+
+			// Check if the file exists
+			if _, err := os.Stat(scaffold.FilePath); os.IsNotExist(err) {
+				b.Fatalf("Generated scaffold file not found: %s", scaffold.FilePath)
+			}
+
+			// Clean up generated file
+			os.Remove(scaffold.FilePath)
 		}
+	}
 
-		// Check if the file exists
-		if _, err := os.Stat(scaffold.FilePath); os.IsNotExist(err) {
-			b.Fatalf("Generated scaffold file not found: %s", scaffold.FilePath)
+	b.Run("cached", func(b *testing.B) {
+		shared := newService()
+		if err := shared.CompileTemplates(); err != nil {
+			b.Fatalf("Failed to precompile templates: %v", err)
 		}
+		b.ResetTimer()
+		run(b, func() *service.GeneratorServiceImpl { return shared })
+	})
 
-		// Clean up generated file
-		os.Remove(scaffold.FilePath)
-	}
+	b.Run("uncached", func(b *testing.B) {
+		run(b, newService)
+	})
 }
 
 // BenchmarkTemplateRendering benchmarks the template rendering process
@@ -89,42 +103,28 @@ func BenchmarkTemplateRendering(b *testing.B) {
 		b.Fatalf("Failed to find project root: %v", err)
 	}
 
-	// Template data for benchmarking
-	templateData := struct {
-		AppName      string
-		ModulePath   string
-		RouterType   string
-		DatabaseType string
-		Features     []string
-	}{
-		AppName:      "BenchApp",
-		ModulePath:   "github.com/example/bench-app",
+	// Options for benchmarking
+	options := model.ScaffoldOptions{
+		AppType:      "api",
 		RouterType:   "echo",
 		DatabaseType: "postgresql",
+		ConfigType:   "env",
+		ModulePath:   "github.com/example/bench-app",
 		Features:     []string{"basic-auth", "sql-migrations"},
 	}
+	templateData := service.TemplateData(options)
+	funcMap := service.TemplateFuncs(options)
 
 	// Find a template file to benchmark
-	templateFile := filepath.Join(rootDir, "templates", "api", "echo", "go.mod.tmpl")
+	templateFile := filepath.Join(rootDir, "templates", "api", "echo", "internal", "config", "config.go.tmpl")
 	if _, err := os.Stat(templateFile); os.IsNotExist(err) {
 		b.Skipf("Template file not found: %s", templateFile)
 		return
 	}
 
-	// Reset the timer before the benchmark loop
-	b.ResetTimer()
-
-	// Run the benchmark
-	for i := 0; i < b.N; i++ {
-		// Parse and execute the template
-		tmpl, err := template.ParseFiles(templateFile)
-		if err != nil {
-			b.Fatalf("Failed to parse template: %v", err)
-		}
-
+	render := func(b *testing.B, tmpl *template.Template) {
 		output := new(bytes.Buffer)
-		err = tmpl.Execute(output, templateData)
-		if err != nil {
+		if err := tmpl.Execute(output, templateData); err != nil {
 			b.Fatalf("Failed to execute template: %v", err)
 		}
 
@@ -133,4 +133,32 @@ func BenchmarkTemplateRendering(b *testing.B) {
 			b.Fatal("Template output is empty")
 		}
 	}
+
+	// Parse the template for every execution, as generation did before caching
+	b.Run("parse-each", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tmpl, err := template.New(filepath.Base(templateFile)).Funcs(funcMap).ParseFiles(templateFile)
+			if err != nil {
+				b.Fatalf("Failed to parse template: %v", err)
+			}
+			render(b, tmpl)
+		}
+	})
+
+	// Parse once and re-bind the functions of each request to a clone
+	b.Run("parsed-once", func(b *testing.B) {
+		parsed, err := template.New(filepath.Base(templateFile)).Funcs(funcMap).ParseFiles(templateFile)
+		if err != nil {
+			b.Fatalf("Failed to parse template: %v", err)
+		}
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			tmpl, err := parsed.Clone()
+			if err != nil {
+				b.Fatalf("Failed to clone template: %v", err)
+			}
+			render(b, tmpl.Funcs(service.TemplateFuncs(options)))
+		}
+	})
 }
@@ -91,3 +91,59 @@ func TestGeneratedScaffold(t *testing.T) {
 		t.Errorf("Size does not match: expected 12345, got %d", scaffold.Size)
 	}
 }
+
+func TestParseTemplateID(t *testing.T) {
+	tests := []struct {
+		id      string
+		appType string
+		router  string
+	}{
+		{id: "api-echo", appType: "api", router: "echo"},
+		{id: "api-go-kit", appType: "api", router: "go-kit"},
+		{id: "api-chi-v5", appType: "api", router: "chi-v5"},
+	}
+
+	for _, tt := range tests {
+		appType, router, err := model.ParseTemplateID(tt.id)
+		if err != nil {
+			t.Errorf("ParseTemplateID(%q) returned error: %v", tt.id, err)
+			continue
+		}
+		if appType != tt.appType || router != tt.router {
+			t.Errorf("ParseTemplateID(%q) = %q, %q, want %q, %q", tt.id, appType, router, tt.appType, tt.router)
+		}
+		if id := model.NewTemplateID(appType, router); id != tt.id {
+			t.Errorf("NewTemplateID(%q, %q) = %q, want %q", appType, router, id, tt.id)
+		}
+	}
+
+	for _, id := range []string{"", "api", "api-", "-echo"} {
+		if _, _, err := model.ParseTemplateID(id); err == nil {
+			t.Errorf("ParseTemplateID(%q) should fail", id)
+		}
+	}
+}
+
+func TestCanonicalVersion(t *testing.T) {
+	tests := map[string]string{
+		"1.2.3":      "1.2.3",
+		"v1.2.3":     "1.2.3",
+		"v1.2":       "1.2.0",
+		"1.0.0-beta": "1.0.0-beta",
+		"main":       "",
+		"":           "",
+	}
+
+	for version, want := range tests {
+		if got := model.CanonicalVersion(version); got != want {
+			t.Errorf("CanonicalVersion(%q) = %q, want %q", version, got, want)
+		}
+	}
+
+	if !model.SameVersion("v1.0.0", "1.0.0") || !model.SameVersion("main", "main") {
+		t.Error("SameVersion should ignore a \"v\" prefix and match equal names")
+	}
+	if model.SameVersion("1.0.0", "1.0.1") || model.SameVersion("", "v") {
+		t.Error("SameVersion should not match different versions")
+	}
+}
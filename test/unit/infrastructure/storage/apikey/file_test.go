@@ -0,0 +1,106 @@
+package apikey_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/apikey"
+	"github.com/stretchr/testify/assert"
+)
+
+// hash returns the hex-encoded SHA-256 hash of a key, as stored in keys files
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeKeys writes a keys file into a temporary directory and returns its path
+func writeKeys(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "api-keys.json")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+// TestFileRepository ensures keys are found by their secret and resolve to their plan
+func TestFileRepository(t *testing.T) {
+	path := writeKeys(t, fmt.Sprintf(`{
+		"plans": {
+			"free": {"features": []},
+			"pro": {"name": "Pro", "features": ["user-accounts"]}
+		},
+		"keys": [
+			{"name": "hobbyist", "plan": "free", "sha256": %q},
+			{"name": "acme", "plan": "pro", "sha256": %q}
+		]
+	}`, hash("free-key"), hash("pro-key")))
+
+	repo, err := apikey.NewFileRepository(path)
+	assert.NoError(t, err)
+
+	key, err := repo.GetByKey("pro-key")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "acme", key.Name)
+		assert.Equal(t, "pro", key.Plan.ID)
+		assert.Equal(t, "Pro", key.Plan.Name)
+		assert.True(t, key.Plan.Entitles("user-accounts"))
+		assert.False(t, key.Plan.Entitles("automatic-https"))
+	}
+
+	key, err = repo.GetByKey("free-key")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "free", key.Plan.Name, "plans without a name should be named by ID")
+		assert.False(t, key.Plan.Entitles("user-accounts"))
+	}
+
+	_, err = repo.GetByKey(hash("pro-key"))
+	assert.True(t, errors.Is(err, repository.ErrNotFound), "the stored hash must not work as a key")
+
+	_, err = apikey.NewEmptyRepository().GetByKey("pro-key")
+	assert.True(t, errors.Is(err, repository.ErrNotFound))
+}
+
+// TestFileRepositoryValidation ensures malformed keys files are rejected
+func TestFileRepositoryValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "invalid JSON",
+			content: `{"keys":`,
+		},
+		{
+			name:    "missing name",
+			content: fmt.Sprintf(`{"plans":{"pro":{}},"keys":[{"plan":"pro","sha256":%q}]}`, hash("a")),
+		},
+		{
+			name:    "plaintext key",
+			content: `{"plans":{"pro":{}},"keys":[{"name":"acme","plan":"pro","sha256":"secret"}]}`,
+		},
+		{
+			name:    "unknown plan",
+			content: fmt.Sprintf(`{"plans":{"pro":{}},"keys":[{"name":"acme","plan":"team","sha256":%q}]}`, hash("a")),
+		},
+		{
+			name:    "duplicate name",
+			content: fmt.Sprintf(`{"plans":{"pro":{}},"keys":[{"name":"a","plan":"pro","sha256":%q},{"name":"a","plan":"pro","sha256":%q}]}`, hash("a"), hash("b")),
+		},
+		{
+			name:    "duplicate key",
+			content: fmt.Sprintf(`{"plans":{"pro":{}},"keys":[{"name":"a","plan":"pro","sha256":%q},{"name":"b","plan":"pro","sha256":%q}]}`, hash("a"), hash("a")),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := apikey.NewFileRepository(writeKeys(t, tt.content))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package dependency_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/dependency"
+	"github.com/regiwitanto/go-scaffold/templates"
+	"github.com/regiwitanto/go-scaffold/test/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBuiltInCatalog ensures the shipped catalog parses and covers every dependency used by templates
+func TestBuiltInCatalog(t *testing.T) {
+	rootDir, err := testutil.FindProjectRoot()
+	assert.NoError(t, err)
+
+	repo, err := dependency.NewCatalogRepository(filepath.Join(rootDir, "templates", "dependencies.json"))
+	assert.NoError(t, err)
+
+	for _, name := range []string{"echo", "chi", "gin", "crypto", "pq", "mysql", "migrate", "godotenv", "securecookie", "mail"} {
+		dep, err := repo.GetByName(name)
+		if assert.NoError(t, err, name) {
+			assert.Equal(t, name, dep.Name)
+			assert.NotEmpty(t, dep.Path)
+			assert.NotEmpty(t, dep.Version)
+			assert.Equal(t, len(dep.Requires) > 0, len(dep.Sums) > 0, "%s: required modules need checksums", name)
+		}
+	}
+
+	deps, err := repo.GetAll()
+	assert.NoError(t, err)
+	assert.Equal(t, "chi", deps[0].Name, "dependencies should be sorted by name")
+
+	// Every entry states the modules it needs, so that generated projects build
+	// offline; entries that need none say so with empty lists
+	for _, dep := range deps {
+		assert.NotNil(t, dep.Requires, "%s: requires must list the modules it needs, if only as []", dep.Name)
+		assert.NotNil(t, dep.Sums, "%s: sums must list the go.sum lines of its requirements, if only as []", dep.Name)
+		for _, require := range dep.Requires {
+			assert.True(t, hasSum(dep.Sums, require+" h1:"), "%s: %s needs a module checksum", dep.Name, require)
+			assert.True(t, hasSum(dep.Sums, require+"/go.mod h1:"), "%s: %s needs a go.mod checksum", dep.Name, require)
+		}
+	}
+}
+
+// hasSum reports whether a go.sum line starts with prefix
+func hasSum(sums []string, prefix string) bool {
+	for _, line := range sums {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestEmbeddedCatalog ensures the catalog embedded in the binary matches the one on disk
+func TestEmbeddedCatalog(t *testing.T) {
+	rootDir, err := testutil.FindProjectRoot()
+	assert.NoError(t, err)
+
+	onDisk, err := dependency.NewCatalogRepository(filepath.Join(rootDir, "templates", "dependencies.json"))
+	assert.NoError(t, err)
+	embedded, err := dependency.NewCatalogRepositoryFS(templates.FS, "dependencies.json")
+	assert.NoError(t, err)
+
+	expected, _ := onDisk.GetAll()
+	actual, err := embedded.GetAll()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+// TestCatalogValidation ensures incomplete catalog entries are rejected
+func TestCatalogValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "invalid JSON",
+			content: `{"dependencies":`,
+		},
+		{
+			name:    "missing version",
+			content: `{"dependencies":{"echo":{"path":"github.com/labstack/echo/v4","sum":"h1:a=","goModSum":"h1:b="}}}`,
+		},
+		{
+			name:    "missing checksums",
+			content: `{"dependencies":{"echo":{"path":"github.com/labstack/echo/v4","version":"v4.11.4"}}}`,
+		},
+		{
+			name:    "requirement without version",
+			content: `{"dependencies":{"echo":{"path":"github.com/labstack/echo/v4","version":"v4.11.4","sum":"h1:a=","goModSum":"h1:b=","requires":["github.com/labstack/gommon"]}}}`,
+		},
+		{
+			name:    "malformed go.sum line",
+			content: `{"dependencies":{"echo":{"path":"github.com/labstack/echo/v4","version":"v4.11.4","sum":"h1:a=","goModSum":"h1:b=","sums":["github.com/labstack/gommon v0.4.2"]}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "dependencies.json")
+			assert.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+
+			_, err := dependency.NewCatalogRepository(path)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := dependency.NewCatalogRepository(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
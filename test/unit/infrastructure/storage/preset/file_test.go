@@ -0,0 +1,91 @@
+package preset_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/preset"
+	"github.com/regiwitanto/go-scaffold/templates"
+	"github.com/regiwitanto/go-scaffold/test/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBuiltInPresets ensures the shipped presets parse and the embedded copy matches the one on disk
+func TestBuiltInPresets(t *testing.T) {
+	rootDir, err := testutil.FindProjectRoot()
+	assert.NoError(t, err)
+
+	onDisk, err := preset.LoadBuiltInFS(os.DirFS(filepath.Join(rootDir, "templates")), "presets.json")
+	assert.NoError(t, err)
+	embedded, err := preset.LoadBuiltInFS(templates.FS, "presets.json")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, onDisk, embedded)
+
+	repo, err := preset.NewFileRepository(filepath.Join(t.TempDir(), "presets.json"), embedded)
+	assert.NoError(t, err)
+	for _, id := range []string{"chi-minimal", "echo-postgres", "gin-mysql"} {
+		p, err := repo.GetByID(id)
+		if assert.NoError(t, err, id) {
+			assert.True(t, p.BuiltIn, id)
+			assert.NotEmpty(t, p.Name, id)
+			assert.Equal(t, "api", p.Options.AppType, id)
+			assert.Empty(t, p.Options.ModulePath, "%s: module paths are chosen per project", id)
+		}
+	}
+}
+
+// TestFileRepository ensures stored presets persist across restarts and never replace built-in ones
+func TestFileRepository(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "presets.json")
+	builtIn := []*model.Preset{{ID: "chi-minimal", Name: "Chi", BuiltIn: true}}
+
+	repo, err := preset.NewFileRepository(path, builtIn)
+	assert.NoError(t, err)
+
+	team := &model.Preset{
+		ID:      "team-echo",
+		Name:    "Team Echo",
+		Options: model.ScaffoldOptions{RouterType: "echo", Features: []string{"gitignore"}},
+		Owner:   "key:acme",
+	}
+	assert.NoError(t, repo.Save(team))
+	assert.True(t, errors.Is(repo.Save(team), repository.ErrAlreadyExists))
+	assert.True(t, errors.Is(repo.Save(&model.Preset{ID: "chi-minimal"}), repository.ErrAlreadyExists))
+	assert.True(t, errors.Is(repo.Update(&model.Preset{ID: "chi-minimal"}), repository.ErrNotFound))
+	assert.True(t, errors.Is(repo.Delete("chi-minimal"), repository.ErrNotFound))
+
+	assert.NoError(t, repo.Save(&model.Preset{ID: "scratch", Name: "Scratch"}))
+	assert.NoError(t, repo.Update(&model.Preset{ID: "scratch", Name: "Renamed"}))
+	assert.NoError(t, repo.Delete("scratch"))
+
+	// Reopening the file restores the stored presets only
+	reopened, err := preset.NewFileRepository(path, builtIn)
+	assert.NoError(t, err)
+
+	presets, err := reopened.GetAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.Preset{builtIn[0], team}, presets)
+
+	_, err = reopened.GetByID("scratch")
+	assert.True(t, errors.Is(err, repository.ErrNotFound))
+}
+
+// TestFileRepositoryValidation ensures malformed presets files are rejected
+func TestFileRepositoryValidation(t *testing.T) {
+	for name, content := range map[string]string{
+		"invalid JSON":  `{"presets":`,
+		"no definition": `{"presets":{"team-echo":null}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "presets.json")
+			assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+			_, err := preset.NewFileRepository(path, nil)
+			assert.Error(t, err)
+		})
+	}
+}
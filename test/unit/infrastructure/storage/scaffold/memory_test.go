@@ -0,0 +1,138 @@
+package scaffold_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/scaffold"
+	"github.com/stretchr/testify/assert"
+)
+
+// ids returns the IDs of scaffolds
+func ids(scaffolds []*model.GeneratedScaffold) []string {
+	result := []string{}
+	for _, s := range scaffolds {
+		result = append(result, s.ID)
+	}
+	return result
+}
+
+// TestInMemoryRepositoryList ensures scaffolds are filtered, sorted newest first and paginated
+func TestInMemoryRepositoryList(t *testing.T) {
+	repo := scaffold.NewInMemoryRepository()
+	for _, s := range []*model.GeneratedScaffold{
+		{ID: "a", Owner: "key:acme", CreatedAt: "2026-01-01T10:00:00Z", Options: model.ScaffoldOptions{RouterType: "echo", DatabaseType: "postgresql", Features: []string{"gitignore"}}},
+		{ID: "b", Owner: "key:acme", CreatedAt: "2026-01-02T10:00:00Z", Options: model.ScaffoldOptions{RouterType: "chi", DatabaseType: "none"}},
+		// Created an hour after b, in a different offset
+		{ID: "c", Owner: "key:acme", CreatedAt: "2026-01-02T12:00:00+01:00", Options: model.ScaffoldOptions{RouterType: "echo", DatabaseType: "mysql", PremiumFeatures: []string{"user-accounts"}}},
+		{ID: "d", Owner: "ip:192.0.2.1", CreatedAt: "2026-01-03T10:00:00Z", Options: model.ScaffoldOptions{RouterType: "echo"}},
+	} {
+		assert.NoError(t, repo.Save(s))
+	}
+
+	tests := []struct {
+		name          string
+		filter        model.ScaffoldFilter
+		expectedIDs   []string
+		expectedTotal int
+	}{
+		{name: "All", expectedIDs: []string{"d", "c", "b", "a"}, expectedTotal: 4},
+		{name: "Owner", filter: model.ScaffoldFilter{Owner: "key:acme"}, expectedIDs: []string{"c", "b", "a"}, expectedTotal: 3},
+		{name: "Router", filter: model.ScaffoldFilter{Owner: "key:acme", RouterType: "echo"}, expectedIDs: []string{"c", "a"}, expectedTotal: 2},
+		{name: "Database", filter: model.ScaffoldFilter{DatabaseType: "mysql"}, expectedIDs: []string{"c"}, expectedTotal: 1},
+		{name: "Feature", filter: model.ScaffoldFilter{Feature: "gitignore"}, expectedIDs: []string{"a"}, expectedTotal: 1},
+		{name: "Premium feature", filter: model.ScaffoldFilter{Feature: "user-accounts"}, expectedIDs: []string{"c"}, expectedTotal: 1},
+		{
+			name:          "Date range",
+			filter:        model.ScaffoldFilter{CreatedAfter: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), CreatedBefore: time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC)},
+			expectedIDs:   []string{"c", "b"},
+			expectedTotal: 2,
+		},
+		{name: "Page", filter: model.ScaffoldFilter{Offset: 1, Limit: 2}, expectedIDs: []string{"c", "b"}, expectedTotal: 4},
+		{name: "Past the end", filter: model.ScaffoldFilter{Offset: 10, Limit: 2}, expectedIDs: []string{}, expectedTotal: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scaffolds, total, err := repo.List(tt.filter)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedIDs, ids(scaffolds))
+			assert.Equal(t, tt.expectedTotal, total)
+		})
+	}
+}
+
+// TestInMemoryRepositoryNotFound ensures missing scaffolds are reported as repository.ErrNotFound
+func TestInMemoryRepositoryNotFound(t *testing.T) {
+	repo := scaffold.NewInMemoryRepository()
+
+	_, err := repo.GetByID("missing")
+	assert.True(t, errors.Is(err, repository.ErrNotFound))
+	_, err = repo.Delete("missing")
+	assert.True(t, errors.Is(err, repository.ErrNotFound))
+	_, err = repo.FindByHash("missing")
+	assert.True(t, errors.Is(err, repository.ErrNotFound))
+}
+
+// TestInMemoryRepositoryReferences ensures archives are counted per scaffold and found by the newest scaffold with a hash
+func TestInMemoryRepositoryReferences(t *testing.T) {
+	repo := scaffold.NewInMemoryRepository()
+	for _, s := range []*model.GeneratedScaffold{
+		{ID: "a", FilePath: "/tmp/a.zip", OptionsHash: "h1", CreatedAt: "2026-01-01T10:00:00Z"},
+		{ID: "b", FilePath: "/tmp/a.zip", OptionsHash: "h1", CreatedAt: "2026-01-02T10:00:00Z"},
+		{ID: "c", FilePath: "/tmp/c.zip", OptionsHash: "h2", CreatedAt: "2026-01-03T10:00:00Z"},
+	} {
+		assert.NoError(t, repo.Save(s))
+	}
+
+	found, err := repo.FindByHash("h1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "b", found.ID)
+	}
+
+	// Saving a scaffold again does not add a reference
+	assert.NoError(t, repo.Save(&model.GeneratedScaffold{ID: "b", FilePath: "/tmp/a.zip", OptionsHash: "h1"}))
+
+	references, err := repo.Delete("b")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, references)
+
+	found, err = repo.FindByHash("h1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "a", found.ID)
+	}
+
+	references, err = repo.Delete("a")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, references)
+
+	_, err = repo.FindByHash("h1")
+	assert.True(t, errors.Is(err, repository.ErrNotFound))
+}
+
+// TestInMemoryRepositoryExpire ensures expired scaffolds release their archive and are reported as expired
+func TestInMemoryRepositoryExpire(t *testing.T) {
+	repo := scaffold.NewInMemoryRepository()
+	assert.NoError(t, repo.Save(&model.GeneratedScaffold{ID: "a", FilePath: "/tmp/a.zip", OptionsHash: "h1"}))
+	assert.NoError(t, repo.Save(&model.GeneratedScaffold{ID: "b", FilePath: "/tmp/a.zip", OptionsHash: "h1"}))
+
+	references, err := repo.Expire("a")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, references)
+
+	_, err = repo.GetByID("a")
+	assert.True(t, errors.Is(err, repository.ErrExpired))
+	scaffolds, total, err := repo.List(model.ScaffoldFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, []string{"b"}, ids(scaffolds))
+
+	_, err = repo.Expire("a")
+	assert.True(t, errors.Is(err, repository.ErrNotFound))
+	references, err = repo.Expire("b")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, references)
+}
@@ -0,0 +1,91 @@
+package template_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/template"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeTemplateDirs creates the given template directories below root
+func makeTemplateDirs(t *testing.T, root string, dirs ...string) {
+	t.Helper()
+
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, filepath.FromSlash(dir)), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+}
+
+// TestCompositeRepositoryPriority ensures higher-priority sources shadow lower ones and templates report their origin
+func TestCompositeRepositoryPriority(t *testing.T) {
+	builtinDir := t.TempDir()
+	extraDir := t.TempDir()
+	packsDir := t.TempDir()
+	makeTemplateDirs(t, builtinDir, "api/echo", "api/chi", "api/gin")
+	makeTemplateDirs(t, extraDir, "api/chi", "api/fiber")
+	makeTemplateDirs(t, packsDir, "acme/api/echo", "acme/api/chi")
+
+	builtinRepo, err := template.NewFilesystemRepository(builtinDir)
+	assert.NoError(t, err)
+	extra, err := template.DirectorySource(extraDir)
+	assert.NoError(t, err)
+	packs, err := template.PackSources(packsDir)
+	assert.NoError(t, err)
+	if !assert.Len(t, packs, 1) {
+		return
+	}
+
+	repo := template.NewCompositeRepository(
+		packs[0],
+		extra,
+		template.Source{Origin: template.OriginBuiltin, Repository: builtinRepo},
+	)
+
+	templates, err := repo.GetByType("api")
+	assert.NoError(t, err)
+	origins := make(map[string]string)
+	paths := make(map[string]string)
+	for _, tmpl := range templates {
+		origins[tmpl.ID] = tmpl.Origin
+		paths[tmpl.ID] = tmpl.Path
+	}
+	assert.Equal(t, map[string]string{
+		"api-echo":  "pack:acme",
+		"api-chi":   "pack:acme",
+		"api-fiber": "dir:" + extraDir,
+		"api-gin":   template.OriginBuiltin,
+	}, origins)
+	assert.Equal(t, "api/echo", paths["api-echo"])
+
+	all, err := repo.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, all, 4)
+
+	tmpl, err := repo.GetByID("api-fiber")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "dir:"+extraDir, tmpl.Origin)
+	}
+	tmpl, err = repo.GetByID("api-gin")
+	if assert.NoError(t, err) {
+		assert.Equal(t, template.OriginBuiltin, tmpl.Origin)
+	}
+	_, err = repo.GetByID("api-missing")
+	assert.Error(t, err)
+
+	duplicates, err := repo.Duplicates()
+	assert.NoError(t, err)
+	assert.Equal(t, []template.Duplicate{
+		{ID: "api-chi", Origin: "pack:acme", Shadowed: []string{"dir:" + extraDir, template.OriginBuiltin}},
+		{ID: "api-echo", Origin: "pack:acme", Shadowed: []string{template.OriginBuiltin}},
+	}, duplicates)
+}
+
+// TestDirectorySourceMissing ensures a missing extra directory is reported
+func TestDirectorySourceMissing(t *testing.T) {
+	_, err := template.DirectorySource(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,150 @@
+package template_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/template"
+	"github.com/regiwitanto/go-scaffold/templates"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFilesystemRepositorySharedLayers ensures shared layers are attached to templates and not listed as routers
+func TestFilesystemRepositorySharedLayers(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"api/_base", "api/echo", "api/chi", "shared/partials"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+	}
+
+	repo, err := template.NewFilesystemRepository(root)
+	assert.NoError(t, err)
+
+	templates, err := repo.GetByType("api")
+	assert.NoError(t, err)
+	if assert.Len(t, templates, 2) {
+		for _, tmpl := range templates {
+			assert.NotEqual(t, "api-_base", tmpl.ID)
+			assert.Equal(t, "api/_base", tmpl.BasePath)
+			assert.Equal(t, "shared/partials", tmpl.PartialsPath)
+		}
+	}
+
+	tmpl, err := repo.GetByID("api-echo")
+	assert.NoError(t, err)
+	assert.Equal(t, "api/_base", tmpl.BasePath)
+
+	_, err = repo.GetByID("api-_base")
+	assert.Error(t, err)
+}
+
+// TestFilesystemRepositoryDashedRouters ensures routers whose names contain dashes can be fetched by ID
+func TestFilesystemRepositoryDashedRouters(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"api/go-kit", "api/chi-v5", "api/chi"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+	}
+
+	repo, err := template.NewFilesystemRepository(root)
+	assert.NoError(t, err)
+
+	for id, router := range map[string]string{"api-go-kit": "go-kit", "api-chi-v5": "chi-v5", "api-chi": "chi"} {
+		tmpl, err := repo.GetByID(id)
+		if assert.NoError(t, err, id) {
+			assert.Equal(t, id, tmpl.ID)
+			assert.Equal(t, "api", tmpl.Type)
+			assert.Equal(t, router, tmpl.Router)
+			assert.Equal(t, "api/"+router, tmpl.Path)
+		}
+	}
+
+	for _, id := range []string{"api", "api-", "api-chi/../chi", "api-missing"} {
+		_, err := repo.GetByID(id)
+		assert.Error(t, err, id)
+	}
+}
+
+// TestFSRepositoryEmbedded ensures the templates embedded in the binary are listed with their shared layers
+func TestFSRepositoryEmbedded(t *testing.T) {
+	repo := template.NewFSRepository(templates.FS)
+
+	list, err := repo.GetByType("api")
+	assert.NoError(t, err)
+	ids := make([]string, 0, len(list))
+	for _, tmpl := range list {
+		ids = append(ids, tmpl.ID)
+		assert.Equal(t, "api/_base", tmpl.BasePath)
+		assert.Equal(t, "shared/partials", tmpl.PartialsPath)
+		assert.NotNil(t, tmpl.FS)
+	}
+	assert.ElementsMatch(t, []string{"api-chi", "api-echo", "api-gin", "api-standard"}, ids)
+	assert.NotEmpty(t, list[0].Version)
+
+	tmpl, err := repo.GetByID("api-echo")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "api/echo", tmpl.Path)
+	}
+
+	_, err = repo.GetByID("api-fiber")
+	assert.Error(t, err)
+}
+
+// TestFilesystemRepositoryVersions ensures snapshots of older versions are served side by side
+func TestFilesystemRepositoryVersions(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"api/_base", "api/echo", "api/chi", "api@1.0.0/_base", "api@1.0.0/echo", "api@1.2.0/echo", "shared/partials", "shared@1.0.0/partials"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "api", "template.json"), []byte(`{"version": "2.0.0"}`), 0644))
+
+	repo, err := template.NewFilesystemRepository(root)
+	assert.NoError(t, err)
+
+	// Snapshots are not listed as templates or types
+	current, err := repo.GetByType("api")
+	assert.NoError(t, err)
+	if assert.Len(t, current, 2) {
+		assert.Equal(t, "2.0.0", current[0].Version)
+	}
+
+	versions, err := repo.GetVersions("api-echo")
+	assert.NoError(t, err)
+	var listed []string
+	for _, tmpl := range versions {
+		listed = append(listed, tmpl.Version+" "+tmpl.Path+" "+tmpl.BasePath+" "+tmpl.PartialsPath)
+	}
+	// Snapshots only use the partials of their own version
+	assert.Equal(t, []string{
+		"2.0.0 api/echo api/_base shared/partials",
+		"1.2.0 api@1.2.0/echo  ",
+		"1.0.0 api@1.0.0/echo api@1.0.0/_base shared@1.0.0/partials",
+	}, listed)
+
+	// Versions match with or without a "v" prefix
+	tmpl, err := repo.GetVersion("api-echo", "v1.0.0")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "api@1.0.0/echo", tmpl.Path)
+	}
+	_, err = repo.GetVersion("api-echo", "1.1.0")
+	assert.Error(t, err)
+
+	chiVersions, err := repo.GetVersions("api-chi")
+	assert.NoError(t, err)
+	assert.Len(t, chiVersions, 1)
+
+	_, err = repo.GetByID("api@1.0.0-echo")
+	assert.Error(t, err)
+}
+
+// TestFilesystemRepositoryInvalidVersion ensures versions must be semantic versions
+func TestFilesystemRepositoryInvalidVersion(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "api", "echo"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "api", "template.json"), []byte(`{"version": "latest"}`), 0644))
+
+	repo, err := template.NewFilesystemRepository(root)
+	assert.NoError(t, err)
+
+	_, err = repo.GetByType("api")
+	assert.Error(t, err)
+}
@@ -0,0 +1,180 @@
+package template_test
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/template"
+	"github.com/stretchr/testify/assert"
+)
+
+// git runs a git command in dir and returns its trimmed output
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commitFile writes a file into the work tree at dir and commits it
+func commitFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "--quiet", "-m", "update "+name)
+	return git(t, dir, "rev-parse", "HEAD")
+}
+
+// readTemplateFile reads a file of a template tree
+func readTemplateFile(t *testing.T, tmpl *model.Template, name string) string {
+	t.Helper()
+
+	content, err := fs.ReadFile(tmpl.FS, path.Join(tmpl.Path, name))
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", name, err)
+	}
+	return string(content)
+}
+
+// TestGitRepository ensures refs are pinned to commits and served as template versions
+func TestGitRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	// Prepare a bare repository with a tagged release and a newer commit on main
+	remote := filepath.Join(t.TempDir(), "templates.git")
+	work := t.TempDir()
+	git(t, work, "init", "--quiet", "--bare", "--initial-branch=main", remote)
+	git(t, work, "init", "--quiet", "--initial-branch=main")
+	push := func() { git(t, work, "push", "--quiet", "--tags", remote, "main") }
+
+	v1 := commitFile(t, work, "api/echo/main.go.tmpl", "v1")
+	git(t, work, "tag", "v1.0.0")
+	v2 := commitFile(t, work, "api/echo/main.go.tmpl", "v2")
+	push()
+
+	cacheDir := t.TempDir()
+	repo, err := template.NewGitRepository(remote, "main", cacheDir)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The default ref is served and reported as the version
+	templates, err := repo.GetByType("api")
+	assert.NoError(t, err)
+	if assert.Len(t, templates, 1) {
+		assert.Equal(t, "api-echo", templates[0].ID)
+		assert.Equal(t, "main", templates[0].Version)
+		assert.Equal(t, v2, templates[0].Revision)
+		assert.Equal(t, "v2", readTemplateFile(t, templates[0], "main.go.tmpl"))
+	}
+
+	// Tags and commits select older versions
+	for _, ref := range []string{"v1.0.0", v1} {
+		tmpl, err := repo.GetVersion("api-echo", ref)
+		if assert.NoError(t, err, ref) {
+			assert.Equal(t, ref, tmpl.Version)
+			assert.Equal(t, v1, tmpl.Revision)
+			assert.Equal(t, "v1", readTemplateFile(t, tmpl, "main.go.tmpl"))
+		}
+	}
+
+	// Tags that are semantic versions are listed once fetched, without checking them out
+	git(t, work, "tag", "release-candidate")
+	git(t, work, "tag", "-a", "-m", "release", "v1.1.0")
+	push()
+	_, err = repo.GetVersion("api-echo", "v1.1.0")
+	assert.Error(t, err, "unknown versions are not fetched on request")
+
+	assert.NoError(t, repo.Refresh())
+	versions, err := repo.GetVersions("api-echo")
+	if assert.NoError(t, err) && assert.Len(t, versions, 2) {
+		assert.Equal(t, "v1.1.0", versions[0].Version)
+		assert.Equal(t, v2, versions[0].Revision)
+		assert.Equal(t, "v1.0.0", versions[1].Version)
+		assert.Equal(t, v1, versions[1].Revision)
+	}
+	checkouts, err := filepath.Glob(filepath.Join(cacheDir, "*", "commits", "*"))
+	if assert.NoError(t, err) {
+		assert.Len(t, checkouts, 2, "only main and v1.0.0 are checked out")
+	}
+
+	tmpl, err := repo.GetVersion("api-echo", "v1.1.0")
+	if assert.NoError(t, err) {
+		assert.Equal(t, v2, tmpl.Revision)
+	}
+
+	// Only semantic version tags, branch names and full commit IDs are accepted
+	for _, ref := range []string{"v9.9.9", "--upload-pack=evil", "-x", "", "main~1", "main^", "HEAD@{1}", "v1.0.0:api",
+		"main..v1.0.0", "heads/main", v1[:7], strings.ToUpper(v1)} {
+		_, err = repo.GetVersion("api-echo", ref)
+		assert.Error(t, err, ref)
+	}
+
+	// A moving branch stays pinned until the repository is refreshed
+	v3 := commitFile(t, work, "api/echo/main.go.tmpl", "v3")
+	push()
+	tmpl, err = repo.GetByID("api-echo")
+	if assert.NoError(t, err) {
+		assert.Equal(t, v2, tmpl.Revision)
+	}
+
+	assert.NoError(t, repo.Refresh())
+	tmpl, err = repo.GetByID("api-echo")
+	if assert.NoError(t, err) {
+		assert.Equal(t, v3, tmpl.Revision)
+		assert.Equal(t, "v3", readTemplateFile(t, tmpl, "main.go.tmpl"))
+	}
+
+	// Checkouts no ref is pinned to are removed one refresh after they are unpinned,
+	// also when refreshed through a composite repository
+	v4 := commitFile(t, work, "api/echo/main.go.tmpl", "v4")
+	push()
+	composite := template.NewCompositeRepository(template.Source{Origin: "git:" + remote, Repository: repo})
+	checkedOut := func() []string {
+		dirs, err := filepath.Glob(filepath.Join(cacheDir, "*", "commits", "*"))
+		assert.NoError(t, err)
+		commits := make([]string, 0, len(dirs))
+		for _, dir := range dirs {
+			commits = append(commits, filepath.Base(dir))
+		}
+		return commits
+	}
+	assert.NoError(t, composite.Refresh())
+	assert.ElementsMatch(t, []string{v1, v2, v3, v4}, checkedOut(), "v3 is kept for renders still using it")
+	assert.NoError(t, composite.Refresh())
+	assert.ElementsMatch(t, []string{v1, v2, v4}, checkedOut(), "tags stay pinned and checked out")
+	tmpl, err = repo.GetVersion("api-echo", "v1.0.0")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "v1", readTemplateFile(t, tmpl, "main.go.tmpl"))
+	}
+
+	// A second repository reuses the cached clone
+	cached, err := template.NewGitRepository(remote, "v1.0.0", cacheDir)
+	if assert.NoError(t, err) {
+		tmpl, err := cached.GetByID("api-echo")
+		if assert.NoError(t, err) {
+			assert.Equal(t, v1, tmpl.Revision)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package template_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/template"
+	"github.com/stretchr/testify/assert"
+)
+
+// saveVersion stores a draft version of api-fiber whose main.go.tmpl holds content
+func saveVersion(t *testing.T, repo *template.ManagedRepository, version, content string) {
+	t.Helper()
+
+	tmpl := &model.Template{ID: "api-fiber", Name: "Fiber", Type: "api", Version: version, Status: model.TemplateStatusDraft}
+	files := fstest.MapFS{"main.go.tmpl": {Data: []byte(content)}, "static/logo.txt": {Data: []byte("logo")}}
+	if err := repo.Save(tmpl, files); err != nil {
+		t.Fatalf("Failed to save %s: %v", version, err)
+	}
+}
+
+// TestManagedRepository ensures uploaded versions are only served once published
+func TestManagedRepository(t *testing.T) {
+	repo, err := template.NewManagedRepository(t.TempDir())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	saveVersion(t, repo, "1.0.0", "v1")
+	saveVersion(t, repo, "1.1.0", "v1.1")
+
+	// A version cannot be uploaded twice, with or without a "v" prefix
+	err = repo.Save(&model.Template{ID: "api-fiber", Version: "1.0.0"}, fstest.MapFS{})
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+	err = repo.Save(&model.Template{ID: "api-fiber", Version: "v1.0.0"}, fstest.MapFS{})
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+
+	// Drafts are listed but not served
+	all, err := repo.List()
+	if assert.NoError(t, err) && assert.Len(t, all, 2) {
+		assert.Equal(t, "1.1.0", all[0].Version)
+		assert.Equal(t, model.TemplateStatusDraft, all[0].Status)
+	}
+	templates, err := repo.GetAll()
+	assert.NoError(t, err)
+	assert.Empty(t, templates)
+	_, err = repo.GetByID("api-fiber")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	// The newest published version is the default
+	_, err = repo.SetStatus("api-fiber", "v1.0.0", model.TemplateStatusPublished)
+	assert.NoError(t, err)
+	tmpl, err := repo.GetByID("api-fiber")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "1.0.0", tmpl.Version)
+		assert.Equal(t, "api", tmpl.Type)
+		assert.Equal(t, "v1", readTemplateFile(t, tmpl, "main.go.tmpl"))
+		assert.Equal(t, "logo", readTemplateFile(t, tmpl, "static/logo.txt"))
+	}
+
+	_, err = repo.SetStatus("api-fiber", "1.1.0", model.TemplateStatusPublished)
+	assert.NoError(t, err)
+	templates, err = repo.GetByType("api")
+	if assert.NoError(t, err) && assert.Len(t, templates, 1) {
+		assert.Equal(t, "1.1.0", templates[0].Version)
+	}
+
+	// Deprecated versions are served by version only
+	_, err = repo.SetStatus("api-fiber", "1.1.0", model.TemplateStatusDeprecated)
+	assert.NoError(t, err)
+	tmpl, err = repo.GetByID("api-fiber")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "1.0.0", tmpl.Version)
+	}
+	tmpl, err = repo.GetVersion("api-fiber", "v1.1.0")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "v1.1", readTemplateFile(t, tmpl, "main.go.tmpl"))
+	}
+	versions, err := repo.GetVersions("api-fiber")
+	assert.NoError(t, err)
+	assert.Len(t, versions, 2)
+
+	// Deleted versions are gone
+	assert.NoError(t, repo.Delete("api-fiber", "1.0.0"))
+	assert.ErrorIs(t, repo.Delete("api-fiber", "1.0.0"), repository.ErrNotFound)
+	_, err = repo.SetStatus("api-fiber", "1.0.0", model.TemplateStatusPublished)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = repo.SetStatus("..", "1.0.0", model.TemplateStatusPublished)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
@@ -0,0 +1,57 @@
+package template_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/template"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that changes below the watched root are reported in a single debounced batch
+func TestWatcherReportsChanges(t *testing.T) {
+	root := t.TempDir()
+	changes := make(chan []string, 10)
+
+	watcher, err := template.NewWatcher(root, 50*time.Millisecond, func(paths []string) {
+		changes <- paths
+	})
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+	defer watcher.Close()
+
+	// Directories created after startup are watched too
+	subDir := filepath.Join(root, "api", "echo")
+	if err := os.MkdirAll(subDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	waitForChange(t, changes)
+
+	mainPath := filepath.Join(subDir, "main.go.tmpl")
+	readmePath := filepath.Join(root, "README.md.tmpl")
+	for _, path := range []string{mainPath, readmePath} {
+		if err := os.WriteFile(path, []byte("package main"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	paths := waitForChange(t, changes)
+	assert.Contains(t, paths, mainPath)
+	assert.Contains(t, paths, readmePath)
+}
+
+// waitForChange returns the next batch of changed paths or fails the test after a timeout
+func waitForChange(t *testing.T, changes <-chan []string) []string {
+	t.Helper()
+
+	select {
+	case paths := <-changes:
+		return paths
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for template changes")
+		return nil
+	}
+}
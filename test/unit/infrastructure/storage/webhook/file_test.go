@@ -0,0 +1,100 @@
+package webhook_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFileRepository ensures stored webhooks persist across restarts, privately, and are listed per owner
+func TestFileRepository(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "webhooks.json")
+	repo, err := webhook.NewFileRepository(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ci := &model.Webhook{ID: "ci", URL: "https://ci.acme.test/hook", Secret: "whsec_ci", Owner: "key:acme", CreatedAt: "2026-01-02T00:00:00Z"}
+	chat := &model.Webhook{ID: "chat", URL: "https://chat.acme.test/hook", Owner: "key:acme", CreatedAt: "2026-01-03T00:00:00Z"}
+	other := &model.Webhook{ID: "other", URL: "https://other.test/hook", Owner: "key:other", CreatedAt: "2026-01-01T00:00:00Z"}
+	for _, w := range []*model.Webhook{ci, chat, other} {
+		assert.NoError(t, repo.Save(w))
+	}
+	assert.True(t, errors.Is(repo.Save(ci), repository.ErrAlreadyExists))
+	assert.True(t, errors.Is(repo.Update(&model.Webhook{ID: "missing"}), repository.ErrNotFound))
+	assert.True(t, errors.Is(repo.Delete("missing"), repository.ErrNotFound))
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "the file holds the secrets of the webhooks")
+
+	updated := *chat
+	updated.Events = []string{model.EventScaffoldFailed}
+	assert.NoError(t, repo.Update(&updated))
+	assert.NoError(t, repo.Delete("other"))
+
+	// Reload from disk
+	reloaded, err := webhook.NewFileRepository(path)
+	assert.NoError(t, err)
+	webhooks, err := reloaded.List("key:acme")
+	if !assert.NoError(t, err) {
+		return
+	}
+	if assert.Len(t, webhooks, 2) {
+		assert.Equal(t, "ci", webhooks[0].ID, "oldest first")
+		assert.Equal(t, "whsec_ci", webhooks[0].Secret)
+		assert.Equal(t, []string{model.EventScaffoldFailed}, webhooks[1].Events)
+	}
+	webhooks, err = reloaded.List("key:other")
+	assert.NoError(t, err)
+	assert.Empty(t, webhooks)
+}
+
+// TestFileRepositoryDeliveries ensures deliveries are logged newest first, capped, and removed with their webhook
+func TestFileRepositoryDeliveries(t *testing.T) {
+	repo, err := webhook.NewFileRepository(filepath.Join(t.TempDir(), "webhooks.json"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, repo.Save(&model.Webhook{ID: "ci", URL: "https://ci.acme.test/hook", Owner: "key:acme"}))
+
+	assert.True(t, errors.Is(repo.SaveDelivery(&model.WebhookDelivery{ID: "d", WebhookID: "missing"}), repository.ErrNotFound))
+
+	for i := 0; i < webhook.MaxDeliveries+5; i++ {
+		assert.NoError(t, repo.SaveDelivery(&model.WebhookDelivery{
+			ID:        fmt.Sprintf("delivery-%d", i),
+			WebhookID: "ci",
+			Status:    model.DeliveryPending,
+		}))
+	}
+
+	// Saving a delivery again updates its record in place
+	last := fmt.Sprintf("delivery-%d", webhook.MaxDeliveries+4)
+	assert.NoError(t, repo.SaveDelivery(&model.WebhookDelivery{ID: last, WebhookID: "ci", Status: model.DeliverySucceeded, Attempts: 2}))
+
+	deliveries, err := repo.ListDeliveries("ci")
+	assert.NoError(t, err)
+	if assert.Len(t, deliveries, webhook.MaxDeliveries) {
+		assert.Equal(t, last, deliveries[0].ID, "newest first")
+		assert.Equal(t, model.DeliverySucceeded, deliveries[0].Status)
+		assert.Equal(t, 2, deliveries[0].Attempts)
+		assert.Equal(t, "delivery-5", deliveries[len(deliveries)-1].ID, "the oldest deliveries are forgotten")
+	}
+
+	// Returned records are copies
+	deliveries[0].Status = model.DeliveryFailed
+	deliveries, err = repo.ListDeliveries("ci")
+	assert.NoError(t, err)
+	assert.Equal(t, model.DeliverySucceeded, deliveries[0].Status)
+
+	assert.NoError(t, repo.Delete("ci"))
+	_, err = repo.ListDeliveries("ci")
+	assert.True(t, errors.Is(err, repository.ErrNotFound))
+}
@@ -0,0 +1,49 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRequireAdminToken ensures only requests with the admin bearer token get through
+func TestRequireAdminToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		expectedCode  int
+	}{
+		{name: "Valid token", token: "secret", authorization: "Bearer secret", expectedCode: http.StatusOK},
+		{name: "Wrong token", token: "secret", authorization: "Bearer guess", expectedCode: http.StatusUnauthorized},
+		{name: "Missing header", token: "secret", expectedCode: http.StatusUnauthorized},
+		{name: "Other scheme", token: "secret", authorization: "Basic secret", expectedCode: http.StatusUnauthorized},
+		{name: "No token configured", authorization: "Bearer ", expectedCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/templates", nil)
+			if tt.authorization != "" {
+				req.Header.Set(echo.HeaderAuthorization, tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			handler := auth.RequireAdminToken(tt.token)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			assert.NoError(t, handler(c))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			if tt.expectedCode == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", rec.Header().Get(echo.HeaderWWWAuthenticate))
+			}
+		})
+	}
+}
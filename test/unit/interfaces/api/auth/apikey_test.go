@@ -0,0 +1,62 @@
+package auth_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/auth"
+	"github.com/regiwitanto/go-scaffold/test/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAPIKey ensures requests are authenticated by their API key, or let through anonymously without one
+func TestAPIKey(t *testing.T) {
+	pro := &model.APIKey{Name: "acme", PlanID: "pro", Plan: &model.Plan{ID: "pro"}}
+
+	tests := []struct {
+		name         string
+		header       string
+		lookupErr    error
+		expectedCode int
+		expectedKey  *model.APIKey
+	}{
+		{name: "Valid key", header: "secret", expectedCode: http.StatusOK, expectedKey: pro},
+		{name: "Anonymous", expectedCode: http.StatusOK},
+		{name: "Unknown key", header: "guess", expectedCode: http.StatusUnauthorized},
+		{name: "Lookup failure", header: "secret", lookupErr: errors.New("disk error"), expectedCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/features", nil)
+			if tt.header != "" {
+				req.Header.Set(auth.HeaderAPIKey, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			keys := &mocks.MockAPIKeyRepository{}
+			if tt.header == "secret" {
+				keys.GetByKeyFunc = func(key string) (*model.APIKey, error) {
+					return pro, tt.lookupErr
+				}
+			}
+
+			var key *model.APIKey
+			handler := auth.APIKey(keys)(func(c echo.Context) error {
+				key = auth.APIKeyFrom(c)
+				return c.NoContent(http.StatusOK)
+			})
+
+			assert.NoError(t, handler(c))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, tt.expectedKey, key)
+			assert.Equal(t, tt.header != "", keys.GetByKeyCalled)
+		})
+	}
+}
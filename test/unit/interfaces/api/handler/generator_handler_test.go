@@ -1,14 +1,25 @@
 package handler_test
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	"github.com/regiwitanto/go-scaffold/internal/domain/service"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/auth"
 	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/handler"
 	"github.com/regiwitanto/go-scaffold/test/mocks"
 	"github.com/stretchr/testify/assert"
@@ -79,6 +90,44 @@ func TestHandleListTemplates(t *testing.T) {
 	assert.True(t, mockService.GetAllTemplatesCalled)
 }
 
+// Test for HandleListTemplateVersions
+func TestHandleListTemplateVersions(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/templates/api-echo/versions", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("api-echo")
+
+	mockService := &mocks.MockGeneratorService{}
+	h := handler.NewGeneratorHandler(mockService)
+
+	// Test
+	if assert.NoError(t, h.HandleListTemplateVersions(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response []*model.Template
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		if assert.Len(t, response, 2) {
+			assert.Equal(t, "1.1.0", response[0].Version)
+			assert.Equal(t, "1.0.0", response[1].Version)
+		}
+	}
+	assert.Equal(t, "api-echo", mockService.GetTemplateVersionsID)
+
+	// Unknown templates are not found
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	mockService.GetTemplateVersionsFunc = func(id string) ([]*model.Template, error) {
+		return nil, errors.New("template not found: " + id)
+	}
+	if assert.NoError(t, h.HandleListTemplateVersions(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	}
+}
+
 // Test for HandleListFeatures
 func TestHandleListFeatures(t *testing.T) {
 	// Setup
@@ -219,6 +268,517 @@ func TestHandleGenerateScaffold(t *testing.T) {
 	assert.True(t, mockService.GenerateScaffoldCalled)
 }
 
+// Test for HandleGetOptionsSchema
+func TestHandleGetOptionsSchema(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/schema/options", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	mockService := new(mocks.MockGeneratorService)
+	h := handler.NewGeneratorHandler(mockService)
+
+	if assert.NoError(t, h.HandleGetOptionsSchema(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var schema model.JSONSchema
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &schema))
+		assert.Equal(t, []string{"chi", "echo"}, schema.Properties["routerType"].Enum)
+		assert.Equal(t, "go-module-path", schema.Properties["modulePath"].Format)
+	}
+	assert.True(t, mockService.GetOptionsSchemaCalled)
+
+	// Catalog errors are reported as server errors
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	mockService.GetOptionsSchemaFunc = func() (*model.JSONSchema, error) {
+		return nil, errors.New("catalog unavailable")
+	}
+	if assert.NoError(t, h.HandleGetOptionsSchema(c)) {
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	}
+}
+
+// Test that validation problems are returned with their fields and codes
+func TestHandleGenerateScaffoldValidationError(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(`{"appType": "api"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	problems := []service.Problem{
+		{Field: "routerType", Code: service.ProblemRequired, Message: "router type is required"},
+		{Field: "features[1]", Code: service.ProblemDuplicate, Message: `feature "gitignore" is already listed at features[0]`},
+	}
+	mockService := &mocks.MockGeneratorService{
+		GenerateScaffoldFunc: func(options model.ScaffoldOptions) (*model.GeneratedScaffold, error) {
+			return nil, &service.ValidationError{Message: "invalid scaffold options", Problems: problems}
+		},
+	}
+	h := handler.NewGeneratorHandler(mockService)
+
+	if assert.NoError(t, h.HandleGenerateScaffold(c)) {
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		var response handler.ValidationErrorResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, "invalid scaffold options", response.Error)
+		assert.Equal(t, problems, response.Problems)
+	}
+}
+
+// premiumCatalog is a feature catalog with regular and premium features
+var premiumCatalog = []*model.Feature{
+	{ID: "gitignore", Name: "Gitignore"},
+	{ID: "user-accounts", Name: "User Accounts", IsPremium: true},
+	{ID: "automatic-https", Name: "Automatic HTTPS", IsPremium: true},
+}
+
+// withAPIKey runs a handler behind the API key middleware, authenticating the
+// key "pro-key" on a plan including user-accounts and "free-key" on a plan without premium features
+func withAPIKey(h echo.HandlerFunc) echo.HandlerFunc {
+	keys := &mocks.MockAPIKeyRepository{
+		GetByKeyFunc: func(key string) (*model.APIKey, error) {
+			switch key {
+			case "pro-key":
+				return &model.APIKey{Name: "acme", PlanID: "pro", Plan: &model.Plan{ID: "pro", Features: []string{"user-accounts"}}}, nil
+			case "free-key":
+				return &model.APIKey{Name: "hobby", PlanID: "free", Plan: &model.Plan{ID: "free"}}, nil
+			}
+			return nil, repository.ErrNotFound
+		},
+	}
+	return auth.APIKey(keys)(h)
+}
+
+// TestHandleGenerateScaffoldEntitlements ensures premium features need an API key on a plan that includes them
+func TestHandleGenerateScaffoldEntitlements(t *testing.T) {
+	tests := []struct {
+		name            string
+		apiKey          string
+		premiumFeatures string
+		expectedCode    int
+		expectedFields  []string
+	}{
+		{name: "Anonymous without premium features", premiumFeatures: `[]`, expectedCode: http.StatusOK},
+		{name: "Anonymous with premium features", premiumFeatures: `["user-accounts"]`, expectedCode: http.StatusPaymentRequired, expectedFields: []string{"premiumFeatures[0]"}},
+		{name: "Entitled plan", apiKey: "pro-key", premiumFeatures: `["user-accounts"]`, expectedCode: http.StatusOK},
+		{name: "Plan without the feature", apiKey: "pro-key", premiumFeatures: `["user-accounts", "automatic-https"]`, expectedCode: http.StatusForbidden, expectedFields: []string{"premiumFeatures[1]"}},
+		{name: "Unknown features are left to validation", premiumFeatures: `["unknown"]`, expectedCode: http.StatusOK},
+		{name: "Invalid API key", apiKey: "guess", premiumFeatures: `[]`, expectedCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			body := `{"appType": "api", "routerType": "echo", "modulePath": "github.com/example/app", "premiumFeatures": ` + tt.premiumFeatures + `}`
+			req := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if tt.apiKey != "" {
+				req.Header.Set(auth.HeaderAPIKey, tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			mockService := &mocks.MockGeneratorService{
+				GetAvailableFeaturesFunc: func() ([]*model.Feature, error) {
+					return premiumCatalog, nil
+				},
+			}
+			h := handler.NewGeneratorHandler(mockService)
+
+			assert.NoError(t, withAPIKey(h.HandleGenerateScaffold)(c))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, tt.expectedCode == http.StatusOK, mockService.GenerateScaffoldCalled)
+
+			if len(tt.expectedFields) > 0 {
+				var response handler.ValidationErrorResponse
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+				var fields []string
+				for _, problem := range response.Problems {
+					assert.Equal(t, service.ProblemNotEntitled, problem.Code)
+					fields = append(fields, problem.Field)
+				}
+				assert.Equal(t, tt.expectedFields, fields)
+			}
+		})
+	}
+}
+
+// TestHandleGenerateScaffoldPresetEntitlements ensures premium features requested
+// through a preset need an API key on a plan that includes them too
+func TestHandleGenerateScaffoldPresetEntitlements(t *testing.T) {
+	tests := []struct {
+		name         string
+		apiKey       string
+		expectedCode int
+	}{
+		{name: "Anonymous", expectedCode: http.StatusPaymentRequired},
+		{name: "Plan without the feature", apiKey: "free-key", expectedCode: http.StatusForbidden},
+		{name: "Entitled plan", apiKey: "pro-key", expectedCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(`{"presetId": "premium", "modulePath": "github.com/example/app"}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if tt.apiKey != "" {
+				req.Header.Set(auth.HeaderAPIKey, tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			mockService := &mocks.MockGeneratorService{
+				GetAvailableFeaturesFunc: func() ([]*model.Feature, error) {
+					return premiumCatalog, nil
+				},
+				ResolveOptionsFunc: func(options model.ScaffoldOptions) (model.ScaffoldOptions, error) {
+					options.AppType, options.RouterType = "api", "echo"
+					options.PremiumFeatures = []string{"user-accounts"}
+					return options, nil
+				},
+			}
+			h := handler.NewGeneratorHandler(mockService)
+
+			assert.NoError(t, withAPIKey(h.HandleGenerateScaffold)(c))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.True(t, mockService.ResolveOptionsCalled)
+			assert.Equal(t, tt.expectedCode == http.StatusOK, mockService.GenerateScaffoldCalled)
+			if mockService.GenerateScaffoldCalled {
+				assert.Equal(t, []string{"user-accounts"}, mockService.GenerateScaffoldOptions.PremiumFeatures, "the resolved options are generated")
+			}
+
+			if tt.expectedCode != http.StatusOK {
+				var response handler.ValidationErrorResponse
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				if assert.Len(t, response.Problems, 1) {
+					assert.Equal(t, "premiumFeatures[0]", response.Problems[0].Field)
+					assert.Equal(t, service.ProblemNotEntitled, response.Problems[0].Code)
+				}
+			}
+		})
+	}
+}
+
+// TestHandleListFeaturesEntitlements ensures features are marked with whether the plan of the caller includes them
+func TestHandleListFeaturesEntitlements(t *testing.T) {
+	tests := []struct {
+		name             string
+		apiKey           string
+		expectedPlan     string
+		expectedEntitled map[string]bool
+	}{
+		{
+			name:             "Anonymous",
+			expectedEntitled: map[string]bool{"gitignore": true, "user-accounts": false, "automatic-https": false},
+		},
+		{
+			name:             "Plan",
+			apiKey:           "pro-key",
+			expectedPlan:     "pro",
+			expectedEntitled: map[string]bool{"gitignore": true, "user-accounts": true, "automatic-https": false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/features", nil)
+			if tt.apiKey != "" {
+				req.Header.Set(auth.HeaderAPIKey, tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := handler.NewGeneratorHandler(&mocks.MockGeneratorService{
+				GetAvailableFeaturesFunc: func() ([]*model.Feature, error) {
+					return premiumCatalog, nil
+				},
+			})
+
+			if assert.NoError(t, withAPIKey(h.HandleListFeatures)(c)) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+
+				var response handler.FeaturesResponse
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+				entitled := make(map[string]bool)
+				for _, feature := range append(response.Features, response.PremiumFeatures...) {
+					entitled[feature.ID] = feature.Entitled
+				}
+				assert.Equal(t, tt.expectedEntitled, entitled)
+
+				if tt.expectedPlan == "" {
+					assert.Nil(t, response.Plan)
+				} else if assert.NotNil(t, response.Plan) {
+					assert.Equal(t, tt.expectedPlan, response.Plan.ID)
+				}
+			}
+		})
+	}
+}
+
+// TestHandleGenerateScaffoldQuota ensures generations are counted against the quotas of the client
+func TestHandleGenerateScaffoldQuota(t *testing.T) {
+	tests := []struct {
+		name         string
+		reserveErr   error
+		completeErr  error
+		expectedCode int
+	}{
+		{name: "Within quota", expectedCode: http.StatusOK},
+		{name: "Quota used up", reserveErr: &service.QuotaError{Message: "daily quota of 1 generations used up", RetryAfter: time.Hour}, expectedCode: http.StatusTooManyRequests},
+		{name: "Archive over storage quota", completeErr: &service.QuotaError{Message: "scaffold of 500 bytes exceeds the storage quota of 1000 bytes, 600 bytes are used"},
+			expectedCode: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			body := `{"appType": "api", "routerType": "echo", "modulePath": "github.com/example/app"}`
+			req := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			mockService := &mocks.MockGeneratorService{}
+			usage := &mocks.MockUsageService{
+				ReserveGenerationFunc: func(client string, plan *model.Plan) error {
+					return tt.reserveErr
+				},
+				CompleteGenerationFunc: func(client string, scaffold *model.GeneratedScaffold) error {
+					return tt.completeErr
+				},
+			}
+			h := handler.NewGeneratorHandler(mockService, handler.WithUsageService(usage))
+
+			assert.NoError(t, h.HandleGenerateScaffold(c))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, "ip:192.0.2.1", usage.ReserveGenerationArg)
+			assert.Equal(t, tt.reserveErr == nil, mockService.GenerateScaffoldCalled)
+			assert.Equal(t, tt.reserveErr == nil, usage.CompleteGenerationCalled)
+
+			if tt.reserveErr != nil {
+				assert.Equal(t, "3600", rec.Header().Get(echo.HeaderRetryAfter))
+			} else {
+				assert.NotNil(t, usage.CompleteGenerationArg, "the generated scaffold should be recorded")
+			}
+
+			// Archives over the storage quota are not kept
+			assert.Equal(t, tt.completeErr != nil, mockService.DeleteScaffoldCalled)
+			if tt.completeErr != nil {
+				assert.Equal(t, "mock-id", mockService.DeleteScaffoldID)
+				assert.Contains(t, rec.Body.String(), "exceeds the storage quota")
+			}
+		})
+	}
+}
+
+// TestHandleGenerateScaffoldFresh ensures ?fresh asks the service for a new archive
+func TestHandleGenerateScaffoldFresh(t *testing.T) {
+	tests := []struct {
+		query         string
+		expectedCode  int
+		expectedFresh bool
+	}{
+		{query: "", expectedCode: http.StatusOK},
+		{query: "?fresh=true", expectedCode: http.StatusOK, expectedFresh: true},
+		{query: "?fresh=false", expectedCode: http.StatusOK},
+		{query: "?fresh=always", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			e := echo.New()
+			body := `{"appType": "api", "routerType": "echo", "modulePath": "github.com/example/app"}`
+			req := httptest.NewRequest(http.MethodPost, "/generate"+tt.query, strings.NewReader(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			mockService := &mocks.MockGeneratorService{}
+			h := handler.NewGeneratorHandler(mockService)
+
+			assert.NoError(t, h.HandleGenerateScaffold(c))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, tt.expectedCode == http.StatusOK, mockService.GenerateScaffoldCalled)
+			assert.Equal(t, tt.expectedFresh, mockService.GenerateScaffoldOptions.Fresh)
+		})
+	}
+}
+
+// TestHandleGetUsage ensures the usage of the caller is returned when usage is tracked
+func TestHandleGetUsage(t *testing.T) {
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/usage", nil)
+	rec := httptest.NewRecorder()
+	h := handler.NewGeneratorHandler(&mocks.MockGeneratorService{})
+	assert.NoError(t, h.HandleGetUsage(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/usage", nil)
+	req.Header.Set(auth.HeaderAPIKey, "pro-key")
+	rec = httptest.NewRecorder()
+	usage := &mocks.MockUsageService{
+		GetUsageFunc: func(client string, plan *model.Plan) *model.Usage {
+			return &model.Usage{Client: client, Plan: plan.ID, GenerationsToday: 3}
+		},
+	}
+	h = handler.NewGeneratorHandler(&mocks.MockGeneratorService{}, handler.WithUsageService(usage))
+	if assert.NoError(t, withAPIKey(h.HandleGetUsage)(e.NewContext(req, rec))) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response model.Usage
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, "key:acme", response.Client)
+		assert.Equal(t, "pro", response.Plan)
+		assert.Equal(t, 3, response.GenerationsToday)
+	}
+}
+
+// TestHandleListScaffolds ensures the caller's scaffolds are listed with the filter of the query
+func TestHandleListScaffolds(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedCode   int
+		expectedFilter model.ScaffoldFilter
+		expectedFields []string
+	}{
+		{
+			name:           "Defaults",
+			expectedCode:   http.StatusOK,
+			expectedFilter: model.ScaffoldFilter{Owner: "ip:192.0.2.1", Limit: 20},
+		},
+		{
+			name:         "Filters",
+			query:        "routerType=echo&databaseType=mysql&feature=gitignore&from=2026-01-01T00:00:00Z&to=2026-01-31&offset=40&limit=10",
+			expectedCode: http.StatusOK,
+			expectedFilter: model.ScaffoldFilter{
+				Owner:         "ip:192.0.2.1",
+				RouterType:    "echo",
+				DatabaseType:  "mysql",
+				Feature:       "gitignore",
+				CreatedAfter:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+				CreatedBefore: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+				Offset:        40,
+				Limit:         10,
+			},
+		},
+		{
+			name:           "Invalid query",
+			query:          "from=yesterday&offset=-1&limit=1000",
+			expectedCode:   http.StatusBadRequest,
+			expectedFields: []string{"from", "offset", "limit"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/scaffolds?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			mockService := &mocks.MockGeneratorService{}
+			h := handler.NewGeneratorHandler(mockService)
+
+			assert.NoError(t, h.HandleListScaffolds(c))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+
+			if tt.expectedCode == http.StatusOK {
+				assert.Equal(t, tt.expectedFilter, mockService.ListScaffoldsFilter)
+				return
+			}
+
+			assert.False(t, mockService.ListScaffoldsCalled)
+			var response handler.ValidationErrorResponse
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			var fields []string
+			for _, problem := range response.Problems {
+				fields = append(fields, problem.Field)
+			}
+			assert.Equal(t, tt.expectedFields, fields)
+		})
+	}
+}
+
+// TestHandleGetScaffold ensures callers only see the scaffolds they generated
+func TestHandleGetScaffold(t *testing.T) {
+	mockService := &mocks.MockGeneratorService{
+		GetScaffoldFunc: func(id string) (*model.GeneratedScaffold, error) {
+			owner := "ip:192.0.2.1"
+			if id == "other" {
+				owner = "key:acme"
+			}
+			return &model.GeneratedScaffold{ID: id, Owner: owner, FilePath: "/tmp/scaffolds/" + id + ".zip", Options: model.ScaffoldOptions{RouterType: "echo"}}, nil
+		},
+	}
+	h := handler.NewGeneratorHandler(mockService)
+
+	for id, expectedCode := range map[string]int{"mine": http.StatusOK, "other": http.StatusNotFound} {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/scaffolds/"+id, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+
+		assert.NoError(t, h.HandleGetScaffold(c))
+		assert.Equal(t, expectedCode, rec.Code, id)
+		if expectedCode == http.StatusOK {
+			assert.Contains(t, rec.Body.String(), `"routerType":"echo"`)
+			assert.NotContains(t, rec.Body.String(), "/tmp/scaffolds", "the archive path should not be exposed")
+		}
+	}
+}
+
+// TestHandleDeleteScaffold ensures deleting a scaffold frees the storage of its owner
+func TestHandleDeleteScaffold(t *testing.T) {
+	tests := []struct {
+		name         string
+		owner        string
+		deleteErr    error
+		expectedCode int
+	}{
+		{name: "Own scaffold", owner: "ip:192.0.2.1", expectedCode: http.StatusNoContent},
+		{name: "Other client", owner: "key:acme", expectedCode: http.StatusNotFound},
+		{name: "Deleted concurrently", owner: "ip:192.0.2.1", deleteErr: repository.ErrNotFound, expectedCode: http.StatusNotFound},
+		{name: "Failure", owner: "ip:192.0.2.1", deleteErr: errors.New("disk error"), expectedCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodDelete, "/scaffolds/123", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("123")
+
+			mockService := &mocks.MockGeneratorService{
+				GetScaffoldFunc: func(id string) (*model.GeneratedScaffold, error) {
+					return &model.GeneratedScaffold{ID: id, Owner: tt.owner, Size: 100}, nil
+				},
+				DeleteScaffoldFunc: func(id string) error {
+					return tt.deleteErr
+				},
+			}
+			usage := &mocks.MockUsageService{}
+			h := handler.NewGeneratorHandler(mockService, handler.WithUsageService(usage))
+
+			assert.NoError(t, h.HandleDeleteScaffold(c))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, tt.owner == "ip:192.0.2.1", mockService.DeleteScaffoldCalled)
+			assert.Equal(t, tt.expectedCode == http.StatusNoContent, usage.ReleaseStorageCalled)
+		})
+	}
+}
+
 // Test for HandleDownloadScaffold
 func TestHandleDownloadScaffold(t *testing.T) {
 	// Setup
@@ -264,3 +824,466 @@ func TestHandleDownloadScaffold(t *testing.T) {
 	// Verify the function was called
 	assert.True(t, mockService.GetScaffoldCalled)
 }
+
+// TestHandleDownloadScaffoldDigest ensures downloads carry the SHA-256 of the archive
+func TestHandleDownloadScaffoldDigest(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "123.zip")
+	assert.NoError(t, os.WriteFile(zipPath, []byte("zip"), 0644))
+	sum := sha256.Sum256([]byte("zip"))
+
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/download/123", nil), rec)
+	c.SetParamNames("id")
+	c.SetParamValues("123")
+
+	mockService := &mocks.MockGeneratorService{
+		GetScaffoldFunc: func(id string) (*model.GeneratedScaffold, error) {
+			return &model.GeneratedScaffold{ID: id, FilePath: zipPath, SHA256: hex.EncodeToString(sum[:])}, nil
+		},
+	}
+	h := handler.NewGeneratorHandler(mockService)
+
+	assert.NoError(t, h.HandleDownloadScaffold(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "zip", rec.Body.String())
+	assert.Equal(t, "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":", rec.Header().Get(handler.HeaderReprDigest))
+}
+
+// Test that the detached signature of an archive is served raw, named after the
+// archive, and 404 for unsigned archives
+func TestHandleDownloadSignature(t *testing.T) {
+	signature := []byte(strings.Repeat("s", 64))
+	scaffolds := map[string]*model.GeneratedScaffold{
+		"signed":   {ID: "signed", Signature: base64.StdEncoding.EncodeToString(signature)},
+		"named":    {ID: "named", Signature: base64.StdEncoding.EncodeToString(signature), ArchiveName: "billing-api.zip"},
+		"unicode":  {ID: "unicode", Signature: base64.StdEncoding.EncodeToString(signature), ArchiveName: "café.zip"},
+		"unsigned": {ID: "unsigned"},
+	}
+	mockService := &mocks.MockGeneratorService{
+		GetScaffoldFunc: func(id string) (*model.GeneratedScaffold, error) {
+			if scaffold, ok := scaffolds[id]; ok {
+				return scaffold, nil
+			}
+			return nil, repository.ErrNotFound
+		},
+	}
+	h := handler.NewGeneratorHandler(mockService)
+
+	tests := []struct {
+		id          string
+		status      int
+		body        string
+		disposition string
+	}{
+		{"signed", http.StatusOK, string(signature), `attachment; filename=signed.zip.sig`},
+		{"named", http.StatusOK, string(signature), `attachment; filename=billing-api.zip.sig`},
+		{"unicode", http.StatusOK, string(signature), `attachment; filename*=utf-8''caf%C3%A9.zip.sig`},
+		{"unsigned", http.StatusNotFound, `{"error":"Scaffold is not signed"}`, ""},
+		{"missing", http.StatusNotFound, `{"error":"Scaffold not found"}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/download/"+tt.id+"/signature", nil), rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+
+			assert.NoError(t, h.HandleDownloadSignature(c))
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, tt.body, strings.TrimSpace(rec.Body.String()))
+			if tt.status == http.StatusOK {
+				assert.Equal(t, echo.MIMEOctetStream, rec.Header().Get(echo.HeaderContentType))
+				assert.Equal(t, tt.disposition, rec.Header().Get(echo.HeaderContentDisposition))
+			}
+		})
+	}
+}
+
+// Test that the public signing keys are listed
+func TestHandleListKeys(t *testing.T) {
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/keys", nil), rec)
+
+	mockService := &mocks.MockGeneratorService{
+		GetPublicKeysFunc: func() ([]*model.PublicKey, error) {
+			return []*model.PublicKey{{ID: "0123456789abcdef", Algorithm: "ed25519", PublicKey: "a2V5", PEM: "pem"}}, nil
+		},
+	}
+	h := handler.NewGeneratorHandler(mockService)
+
+	assert.NoError(t, h.HandleListKeys(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"keys":[{"id":"0123456789abcdef","algorithm":"ed25519","publicKey":"a2V5","pem":"pem"}]}`, rec.Body.String())
+	assert.True(t, mockService.GetPublicKeysCalled)
+}
+
+// Test that generation issues a download link when downloads require one
+func TestHandleGenerateScaffoldDownloadLink(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(`{"appType":"api","routerType":"echo","modulePath":"github.com/example/api"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	links := &mocks.MockDownloadLinkService{}
+	h := handler.NewGeneratorHandler(&mocks.MockGeneratorService{}, handler.WithDownloadLinks(links))
+
+	assert.NoError(t, h.HandleGenerateScaffold(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var response handler.GenerateResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "/api/download/mock-id?expires=1893456000&sig=mock-signature", response.DownloadURL)
+	if assert.NotNil(t, response.DownloadExpiresAt) {
+		assert.Equal(t, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), response.DownloadExpiresAt.UTC())
+	}
+	assert.False(t, links.IssueLinkOneTime)
+	assert.Zero(t, links.IssueLinkTTL)
+}
+
+// Test that owners can issue download links for their scaffolds
+func TestHandleIssueDownloadLink(t *testing.T) {
+	tests := []struct {
+		name         string
+		owner        string
+		body         string
+		links        bool
+		issueErr     error
+		expectedCode int
+		expectedBody string
+	}{
+		{name: "Reusable", owner: "ip:192.0.2.1", body: `{}`, links: true, expectedCode: http.StatusCreated,
+			expectedBody: `{"url":"/api/download/123?expires=1893456000&sig=mock-signature","expiresAt":"2030-01-01T00:00:00Z","oneTime":false}`},
+		{name: "One-time", owner: "ip:192.0.2.1", body: `{"oneTime":true,"expiresIn":300}`, links: true, expectedCode: http.StatusCreated,
+			expectedBody: `{"url":"/api/download/123?expires=1893456000&once=mock-nonce&sig=mock-signature","expiresAt":"2030-01-01T00:00:00Z","oneTime":true}`},
+		{name: "Invalid lifetime", owner: "ip:192.0.2.1", body: `{"expiresIn":-1}`, links: true, expectedCode: http.StatusBadRequest,
+			issueErr: &service.ValidationError{
+				Message:  "invalid download link",
+				Problems: []service.Problem{{Field: "expiresIn", Code: service.ProblemInvalidValue, Message: "too long"}},
+			},
+			expectedBody: `{"error":"invalid download link","problems":[{"field":"expiresIn","code":"invalid_value","message":"too long"}]}`},
+		{name: "Other client", owner: "key:acme", body: `{}`, links: true, expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"Scaffold not found"}`},
+		{name: "Not enabled", owner: "ip:192.0.2.1", body: `{}`, expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"Download links are not enabled"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/scaffolds/123/links", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("123")
+
+			mockService := &mocks.MockGeneratorService{
+				GetScaffoldFunc: func(id string) (*model.GeneratedScaffold, error) {
+					return &model.GeneratedScaffold{ID: id, Owner: tt.owner}, nil
+				},
+			}
+			links := &mocks.MockDownloadLinkService{}
+			if tt.issueErr != nil {
+				links.IssueLinkFunc = func(scaffoldID string, ttl time.Duration, oneTime bool) (*model.DownloadLink, error) {
+					return nil, tt.issueErr
+				}
+			}
+			var opts []handler.GeneratorHandlerOption
+			if tt.links {
+				opts = append(opts, handler.WithDownloadLinks(links))
+			}
+			h := handler.NewGeneratorHandler(mockService, opts...)
+
+			assert.NoError(t, h.HandleIssueDownloadLink(c))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+			if tt.name == "One-time" {
+				assert.Equal(t, 5*time.Minute, links.IssueLinkTTL)
+			}
+		})
+	}
+}
+
+// Test that downloads require a valid signed link when download links are enabled
+func TestHandleDownloadScaffoldLink(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "123.zip")
+	assert.NoError(t, os.WriteFile(zipPath, []byte("zip"), 0644))
+
+	tests := []struct {
+		name           string
+		query          string
+		checkErr       error
+		redeemErr      error
+		expectedCode   int
+		expectedBody   string
+		expectRedeemed bool
+	}{
+		{name: "Valid link", query: "?expires=1893456000&sig=s1g", expectedCode: http.StatusOK, expectedBody: "zip"},
+		{name: "Valid one-time link", query: "?expires=1893456000&once=n0nce&sig=s1g", expectedCode: http.StatusOK, expectedBody: "zip",
+			expectRedeemed: true},
+		{name: "Expired link", query: "?expires=1893456000&sig=s1g", checkErr: service.ErrLinkExpired, expectedCode: http.StatusForbidden,
+			expectedBody: `{"error":"download link has expired"}`},
+		{name: "Used link", query: "?expires=1893456000&once=n0nce&sig=s1g", checkErr: service.ErrLinkUsed, expectedCode: http.StatusForbidden,
+			expectedBody: `{"error":"download link has already been used"}`},
+		{name: "Used while downloading", query: "?expires=1893456000&once=n0nce&sig=s1g", redeemErr: service.ErrLinkUsed,
+			expectedCode: http.StatusForbidden, expectedBody: `{"error":"download link has already been used"}`, expectRedeemed: true},
+		{name: "No link", checkErr: service.ErrLinkInvalid, expectedCode: http.StatusForbidden,
+			expectedBody: `{"error":"download link is invalid"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/download/123"+tt.query, nil), rec)
+			c.SetParamNames("id")
+			c.SetParamValues("123")
+
+			mockService := &mocks.MockGeneratorService{
+				GetScaffoldFunc: func(id string) (*model.GeneratedScaffold, error) {
+					return &model.GeneratedScaffold{ID: id, FilePath: zipPath, ArchiveName: "billing-api.zip"}, nil
+				},
+			}
+			links := &mocks.MockDownloadLinkService{
+				CheckLinkFunc: func(link *model.DownloadLink) error {
+					return tt.checkErr
+				},
+				RedeemLinkFunc: func(link *model.DownloadLink) error {
+					return tt.redeemErr
+				},
+			}
+			h := handler.NewGeneratorHandler(mockService, handler.WithDownloadLinks(links))
+
+			assert.NoError(t, h.HandleDownloadScaffold(c))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rec.Body.String()))
+			assert.Equal(t, tt.expectRedeemed, links.RedeemLinkCalled)
+			if tt.checkErr != nil {
+				assert.False(t, mockService.GetScaffoldCalled)
+			}
+			if tt.expectedCode == http.StatusForbidden {
+				assert.Empty(t, rec.Header().Get(echo.HeaderContentDisposition))
+			}
+			if assert.NotNil(t, links.CheckLinkArg) && tt.query != "" {
+				assert.Equal(t, "123", links.CheckLinkArg.ScaffoldID)
+				assert.Equal(t, int64(1893456000), links.CheckLinkArg.ExpiresAt.Unix())
+				assert.Equal(t, "s1g", links.CheckLinkArg.Signature)
+			}
+			if tt.expectRedeemed {
+				assert.Equal(t, "n0nce", links.RedeemLinkArg.Nonce)
+			}
+		})
+	}
+}
+
+// Test that a one-time link is not used up by a download that fails
+func TestHandleDownloadScaffoldOneTimeLinkNotUsedOnFailure(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "123.zip")
+	assert.NoError(t, os.WriteFile(zipPath, []byte("zip"), 0644))
+
+	tests := []struct {
+		name         string
+		scaffold     *model.GeneratedScaffold
+		expectedCode int
+	}{
+		{name: "Expired scaffold", scaffold: &model.GeneratedScaffold{ID: "123", FilePath: zipPath, ExpiresAt: "2020-01-01T00:00:00Z"},
+			expectedCode: http.StatusGone},
+		{name: "Archive missing", scaffold: &model.GeneratedScaffold{ID: "123", FilePath: zipPath + ".missing"},
+			expectedCode: http.StatusGone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/download/123?expires=1893456000&once=n0nce&sig=s1g", nil), rec)
+			c.SetParamNames("id")
+			c.SetParamValues("123")
+
+			mockService := &mocks.MockGeneratorService{
+				GetScaffoldFunc: func(id string) (*model.GeneratedScaffold, error) {
+					return tt.scaffold, nil
+				},
+			}
+			links := &mocks.MockDownloadLinkService{}
+			h := handler.NewGeneratorHandler(mockService, handler.WithDownloadLinks(links))
+
+			assert.NoError(t, h.HandleDownloadScaffold(c))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.True(t, links.CheckLinkCalled)
+			assert.False(t, links.RedeemLinkCalled)
+		})
+	}
+}
+
+// Test that downloads are named after the project and answer conditional and range requests
+func TestHandleDownloadScaffoldCaching(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "123.zip")
+	assert.NoError(t, os.WriteFile(zipPath, []byte("0123456789"), 0644))
+	sum := sha256.Sum256([]byte("0123456789"))
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+
+	mockService := &mocks.MockGeneratorService{
+		GetScaffoldFunc: func(id string) (*model.GeneratedScaffold, error) {
+			return &model.GeneratedScaffold{ID: id, FilePath: zipPath, SHA256: hex.EncodeToString(sum[:]), ArchiveName: "billing-api.zip"}, nil
+		},
+	}
+	h := handler.NewGeneratorHandler(mockService)
+
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		expectedCode int
+		expectedBody string
+	}{
+		{name: "Full download", expectedCode: http.StatusOK, expectedBody: "0123456789"},
+		{name: "Cached", headers: map[string]string{"If-None-Match": etag}, expectedCode: http.StatusNotModified},
+		{name: "Changed", headers: map[string]string{"If-None-Match": `"other"`}, expectedCode: http.StatusOK, expectedBody: "0123456789"},
+		{name: "Resumed", headers: map[string]string{"Range": "bytes=4-"}, expectedCode: http.StatusPartialContent, expectedBody: "456789"},
+		{name: "Resumed from same archive", headers: map[string]string{"Range": "bytes=4-", "If-Range": etag}, expectedCode: http.StatusPartialContent, expectedBody: "456789"},
+		{name: "Resumed from other archive", headers: map[string]string{"Range": "bytes=4-", "If-Range": `"other"`}, expectedCode: http.StatusOK, expectedBody: "0123456789"},
+		{name: "Unsatisfiable range", headers: map[string]string{"Range": "bytes=20-"}, expectedCode: http.StatusRequestedRangeNotSatisfiable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/download/123", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("123")
+
+			assert.NoError(t, h.HandleDownloadScaffold(c))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			if tt.expectedCode < http.StatusBadRequest {
+				assert.Equal(t, etag, rec.Header().Get(handler.HeaderETag))
+			}
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, rec.Body.String())
+				assert.Equal(t, "application/zip", rec.Header().Get(echo.HeaderContentType))
+				assert.Equal(t, "attachment; filename=billing-api.zip", rec.Header().Get(echo.HeaderContentDisposition))
+				assert.Equal(t, "bytes", rec.Header().Get("Accept-Ranges"))
+			}
+		})
+	}
+}
+
+// Test that a one-time link survives revalidation and is used up by the first range it serves
+func TestHandleDownloadScaffoldOneTimeLinkCaching(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "123.zip")
+	assert.NoError(t, os.WriteFile(zipPath, []byte("0123456789"), 0644))
+	sum := sha256.Sum256([]byte("0123456789"))
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+
+	mockService := &mocks.MockGeneratorService{
+		GetScaffoldFunc: func(id string) (*model.GeneratedScaffold, error) {
+			return &model.GeneratedScaffold{ID: id, FilePath: zipPath, SHA256: hex.EncodeToString(sum[:]), ArchiveName: "billing-api.zip"}, nil
+		},
+	}
+	used := false
+	links := &mocks.MockDownloadLinkService{
+		CheckLinkFunc: func(link *model.DownloadLink) error {
+			if used {
+				return service.ErrLinkUsed
+			}
+			return nil
+		},
+		RedeemLinkFunc: func(link *model.DownloadLink) error {
+			if used {
+				return service.ErrLinkUsed
+			}
+			used = true
+			return nil
+		},
+	}
+	h := handler.NewGeneratorHandler(mockService, handler.WithDownloadLinks(links))
+
+	// Requests run in order against the same link
+	steps := []struct {
+		name         string
+		headers      map[string]string
+		expectedCode int
+		expectedBody string
+		expectUsed   bool
+	}{
+		{name: "Revalidated", headers: map[string]string{"If-None-Match": etag}, expectedCode: http.StatusNotModified},
+		{name: "Unsatisfiable range", headers: map[string]string{"Range": "bytes=20-"}, expectedCode: http.StatusRequestedRangeNotSatisfiable,
+			expectedBody: "invalid range: failed to overlap"},
+		{name: "Range", headers: map[string]string{"Range": "bytes=4-"}, expectedCode: http.StatusPartialContent, expectedBody: "456789",
+			expectUsed: true},
+		{name: "Revalidated after use", headers: map[string]string{"If-None-Match": etag}, expectedCode: http.StatusForbidden,
+			expectedBody: `{"error":"download link has already been used"}`, expectUsed: true},
+		{name: "Range after use", headers: map[string]string{"Range": "bytes=0-3"}, expectedCode: http.StatusForbidden,
+			expectedBody: `{"error":"download link has already been used"}`, expectUsed: true},
+	}
+
+	for _, step := range steps {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/download/123?expires=1893456000&once=n0nce&sig=s1g", nil)
+		for name, value := range step.headers {
+			req.Header.Set(name, value)
+		}
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("123")
+
+		assert.NoError(t, h.HandleDownloadScaffold(c), step.name)
+		assert.Equal(t, step.expectedCode, rec.Code, step.name)
+		assert.Equal(t, step.expectedBody, strings.TrimSpace(rec.Body.String()), step.name)
+		assert.Equal(t, step.expectUsed, used, step.name)
+	}
+}
+
+// Test that expired scaffolds and archives that no longer exist are gone rather than not found
+func TestHandleDownloadScaffoldGone(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "123.zip")
+	assert.NoError(t, os.WriteFile(zipPath, []byte("zip"), 0644))
+
+	tests := []struct {
+		name         string
+		scaffold     *model.GeneratedScaffold
+		err          error
+		expectedCode int
+		expectedBody string
+	}{
+		{name: "Expired and removed", err: fmt.Errorf("scaffold 123: %w", repository.ErrExpired), expectedCode: http.StatusGone,
+			expectedBody: `{"error":"Scaffold has expired"}`},
+		{name: "Expired but not yet removed", scaffold: &model.GeneratedScaffold{ID: "123", FilePath: zipPath, ExpiresAt: "2020-01-01T00:00:00Z"},
+			expectedCode: http.StatusGone, expectedBody: `{"error":"Scaffold has expired"}`},
+		{name: "Archive missing", scaffold: &model.GeneratedScaffold{ID: "123", FilePath: zipPath + ".missing"},
+			expectedCode: http.StatusGone, expectedBody: `{"error":"Scaffold archive is no longer available"}`},
+		{name: "Not found", err: fmt.Errorf("scaffold 123: %w", repository.ErrNotFound), expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"Scaffold not found"}`},
+		{name: "Not yet expired", scaffold: &model.GeneratedScaffold{ID: "123", FilePath: zipPath, ExpiresAt: "2999-01-01T00:00:00Z"},
+			expectedCode: http.StatusOK, expectedBody: "zip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/download/123", nil), rec)
+			c.SetParamNames("id")
+			c.SetParamValues("123")
+
+			mockService := &mocks.MockGeneratorService{
+				GetScaffoldFunc: func(id string) (*model.GeneratedScaffold, error) {
+					return tt.scaffold, tt.err
+				},
+			}
+			h := handler.NewGeneratorHandler(mockService)
+
+			assert.NoError(t, h.HandleDownloadScaffold(c))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	"github.com/regiwitanto/go-scaffold/internal/domain/service"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/auth"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/handler"
+	"github.com/regiwitanto/go-scaffold/test/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// presetCatalog returns a preset service with a built-in preset, a preset of
+// the client of the pro-key API key and a preset of another client
+func presetCatalog() *mocks.MockPresetService {
+	presets := map[string]*model.Preset{
+		"chi-minimal": {ID: "chi-minimal", Name: "Chi", BuiltIn: true},
+		"team-echo":   {ID: "team-echo", Name: "Team Echo", Owner: "key:acme"},
+		"other-gin":   {ID: "other-gin", Name: "Other Gin", Owner: "key:other"},
+	}
+	return &mocks.MockPresetService{
+		GetPresetFunc: func(id string) (*model.Preset, error) {
+			if preset, ok := presets[id]; ok {
+				return preset, nil
+			}
+			return nil, fmt.Errorf("preset %s: %w", id, repository.ErrNotFound)
+		},
+	}
+}
+
+// Test for HandleListPresets and HandleGetPreset
+func TestHandleGetPresets(t *testing.T) {
+	e := echo.New()
+	mockService := presetCatalog()
+	h := handler.NewPresetHandler(mockService, &mocks.MockGeneratorService{})
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/api/presets", nil), rec)
+	if assert.NoError(t, h.HandleListPresets(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var presets []model.Preset
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &presets))
+		assert.Len(t, presets, 1)
+	}
+
+	for id, expectedCode := range map[string]int{"chi-minimal": http.StatusOK, "other-gin": http.StatusOK, "missing": http.StatusNotFound} {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/api/presets/"+id, nil), rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+
+		if assert.NoError(t, h.HandleGetPreset(c)) {
+			assert.Equal(t, expectedCode, rec.Code, id)
+		}
+	}
+}
+
+// Test for HandleCreatePreset
+func TestHandleCreatePreset(t *testing.T) {
+	tests := []struct {
+		name         string
+		apiKey       string
+		body         string
+		createErr    error
+		expectedCode int
+	}{
+		{name: "Created", apiKey: "pro-key", body: `{"id":"team-echo","name":"Team Echo","options":{"routerType":"echo"}}`, expectedCode: http.StatusCreated},
+		{name: "Anonymous", body: `{"id":"team-echo","name":"Team Echo"}`, expectedCode: http.StatusUnauthorized},
+		{name: "Entitled premium features", apiKey: "pro-key", body: `{"id":"team-echo","name":"Team Echo","options":{"routerType":"echo","premiumFeatures":["user-accounts"]}}`, expectedCode: http.StatusCreated},
+		{name: "Premium features outside the plan", apiKey: "free-key", body: `{"id":"team-echo","name":"Team Echo","options":{"premiumFeatures":["user-accounts"]}}`, expectedCode: http.StatusForbidden},
+		{name: "Invalid body", apiKey: "pro-key", body: `{"id":`, expectedCode: http.StatusBadRequest},
+		{
+			name:         "Invalid preset",
+			apiKey:       "pro-key",
+			body:         `{"id":"Team Echo"}`,
+			createErr:    &service.ValidationError{Message: "invalid preset", Problems: []service.Problem{{Field: "id", Code: service.ProblemInvalidFormat}}},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Existing preset",
+			apiKey:       "pro-key",
+			body:         `{"id":"chi-minimal","name":"Chi"}`,
+			createErr:    fmt.Errorf("preset chi-minimal: %w", repository.ErrAlreadyExists),
+			expectedCode: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/api/presets", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if tt.apiKey != "" {
+				req.Header.Set(auth.HeaderAPIKey, tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			mockService := &mocks.MockPresetService{}
+			if tt.createErr != nil {
+				mockService.CreatePresetFunc = func(preset *model.Preset) (*model.Preset, error) {
+					return nil, tt.createErr
+				}
+			}
+			h := handler.NewPresetHandler(mockService, &mocks.MockGeneratorService{
+				GetAvailableFeaturesFunc: func() ([]*model.Feature, error) {
+					return premiumCatalog, nil
+				},
+			})
+
+			if assert.NoError(t, withAPIKey(h.HandleCreatePreset)(c)) {
+				assert.Equal(t, tt.expectedCode, rec.Code)
+			}
+			assert.Equal(t, tt.createErr != nil || tt.expectedCode == http.StatusCreated, mockService.CreatePresetCalled)
+			if tt.expectedCode == http.StatusCreated {
+				assert.Equal(t, "team-echo", mockService.PresetArg.ID)
+				assert.Equal(t, "echo", mockService.PresetArg.Options.RouterType)
+				assert.Equal(t, "key:acme", mockService.PresetArg.Owner, "presets are owned by the client creating them")
+			}
+		})
+	}
+}
+
+// Test for HandleUpdatePreset and HandleDeletePreset
+func TestHandleChangePreset(t *testing.T) {
+	tests := []struct {
+		name         string
+		id           string
+		apiKey       string
+		expectedCode int
+	}{
+		{name: "Own preset", id: "team-echo", apiKey: "pro-key"},
+		{name: "Anonymous", id: "team-echo", expectedCode: http.StatusUnauthorized},
+		{name: "Built-in preset", id: "chi-minimal", apiKey: "pro-key", expectedCode: http.StatusForbidden},
+		{name: "Preset of another client", id: "other-gin", apiKey: "pro-key", expectedCode: http.StatusForbidden},
+		{name: "Missing preset", id: "missing", apiKey: "pro-key", expectedCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			mockService := presetCatalog()
+			h := handler.NewPresetHandler(mockService, &mocks.MockGeneratorService{})
+
+			// Replace
+			req := httptest.NewRequest(http.MethodPut, "/api/presets/"+tt.id, strings.NewReader(`{"id":"renamed","name":"Renamed"}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if tt.apiKey != "" {
+				req.Header.Set(auth.HeaderAPIKey, tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+
+			expectedCode := tt.expectedCode
+			if expectedCode == 0 {
+				expectedCode = http.StatusOK
+			}
+			if assert.NoError(t, withAPIKey(h.HandleUpdatePreset)(c)) {
+				assert.Equal(t, expectedCode, rec.Code)
+			}
+			assert.Equal(t, tt.expectedCode == 0, mockService.UpdatePresetCalled)
+			if mockService.UpdatePresetCalled {
+				assert.Equal(t, tt.id, mockService.PresetArg.ID, "the ID of the path wins over the body")
+				assert.Equal(t, "Renamed", mockService.PresetArg.Name)
+			}
+
+			// Delete
+			req = httptest.NewRequest(http.MethodDelete, "/api/presets/"+tt.id, nil)
+			if tt.apiKey != "" {
+				req.Header.Set(auth.HeaderAPIKey, tt.apiKey)
+			}
+			rec = httptest.NewRecorder()
+			c = e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+
+			expectedCode = tt.expectedCode
+			if expectedCode == 0 {
+				expectedCode = http.StatusNoContent
+			}
+			if assert.NoError(t, withAPIKey(h.HandleDeletePreset)(c)) {
+				assert.Equal(t, expectedCode, rec.Code)
+			}
+			assert.Equal(t, tt.expectedCode == 0, mockService.DeletePresetCalled)
+		})
+	}
+}
+
+// TestHandleUpdatePresetEntitlements ensures presets cannot be given premium features the plan of their owner does not include
+func TestHandleUpdatePresetEntitlements(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPut, "/api/presets/team-echo", strings.NewReader(`{"name":"Team Echo","options":{"premiumFeatures":["automatic-https"]}}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(auth.HeaderAPIKey, "pro-key")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("team-echo")
+
+	mockService := presetCatalog()
+	h := handler.NewPresetHandler(mockService, &mocks.MockGeneratorService{
+		GetAvailableFeaturesFunc: func() ([]*model.Feature, error) {
+			return premiumCatalog, nil
+		},
+	})
+
+	if assert.NoError(t, withAPIKey(h.HandleUpdatePreset)(c)) {
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		var response handler.ValidationErrorResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		if assert.Len(t, response.Problems, 1) {
+			assert.Equal(t, "options.premiumFeatures[0]", response.Problems[0].Field)
+			assert.Equal(t, service.ProblemNotEntitled, response.Problems[0].Code)
+		}
+	}
+	assert.False(t, mockService.UpdatePresetCalled)
+}
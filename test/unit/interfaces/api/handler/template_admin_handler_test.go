@@ -0,0 +1,136 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	"github.com/regiwitanto/go-scaffold/internal/domain/service"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/handler"
+	"github.com/regiwitanto/go-scaffold/test/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// uploadRequest returns a multipart upload request with the given form fields and archive
+func uploadRequest(t *testing.T, fields map[string]string, archive []byte) *http.Request {
+	t.Helper()
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+	if archive != nil {
+		part, err := writer.CreateFormFile("file", "template.zip")
+		if err != nil {
+			t.Fatalf("Failed to create form file: %v", err)
+		}
+		part.Write(archive)
+	}
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/templates", body)
+	req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+	return req
+}
+
+// Test for HandleUploadTemplate
+func TestHandleUploadTemplate(t *testing.T) {
+	tests := []struct {
+		name         string
+		archive      []byte
+		uploadErr    error
+		expectedCode int
+	}{
+		{name: "Uploaded", archive: []byte("zip"), expectedCode: http.StatusCreated},
+		{name: "Missing archive", expectedCode: http.StatusBadRequest},
+		{
+			name:         "Invalid template",
+			archive:      []byte("zip"),
+			uploadErr:    &service.ValidationError{Message: "template api-fiber is invalid", Problems: []service.Problem{{Code: service.ProblemInvalidTemplate, Message: "main.go.tmpl:1: unexpected EOF"}}},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Existing version",
+			archive:      []byte("zip"),
+			uploadErr:    fmt.Errorf("template api-fiber version 1.0.0: %w", repository.ErrAlreadyExists),
+			expectedCode: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := uploadRequest(t, map[string]string{"id": "api-fiber", "version": "1.0.0", "name": "Fiber"}, tt.archive)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			mockService := &mocks.MockTemplateAdminService{}
+			if tt.uploadErr != nil {
+				mockService.UploadTemplateFunc = func(upload model.TemplateUpload, archive io.Reader) (*model.Template, error) {
+					return nil, tt.uploadErr
+				}
+			}
+			h := handler.NewTemplateAdminHandler(mockService)
+
+			if assert.NoError(t, h.HandleUploadTemplate(c)) {
+				assert.Equal(t, tt.expectedCode, rec.Code)
+			}
+
+			switch tt.expectedCode {
+			case http.StatusCreated:
+				assert.Equal(t, model.TemplateUpload{ID: "api-fiber", Version: "1.0.0", Name: "Fiber"}, mockService.UploadTemplateArg)
+			case http.StatusUnprocessableEntity:
+				var response handler.ValidationErrorResponse
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				assert.Equal(t, "template api-fiber is invalid", response.Error)
+				assert.Equal(t, []service.Problem{{Code: service.ProblemInvalidTemplate, Message: "main.go.tmpl:1: unexpected EOF"}}, response.Problems)
+			}
+		})
+	}
+}
+
+// Test for the template lifecycle endpoints
+func TestHandleTemplateLifecycle(t *testing.T) {
+	e := echo.New()
+	mockService := &mocks.MockTemplateAdminService{
+		DeleteTemplateFunc: func(id, version string) error {
+			return fmt.Errorf("template %s version %s: %w", id, version, repository.ErrNotFound)
+		},
+	}
+	h := handler.NewTemplateAdminHandler(mockService)
+
+	request := func(method string, fn echo.HandlerFunc) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(method, "/", nil), rec)
+		c.SetParamNames("id", "version")
+		c.SetParamValues("api-fiber", "1.0.0")
+		assert.NoError(t, fn(c))
+		return rec
+	}
+
+	rec := request(http.MethodPost, h.HandlePublishTemplate)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"published"`)
+	assert.Equal(t, "api-fiber", mockService.IDArg)
+	assert.Equal(t, "1.0.0", mockService.VersionArg)
+
+	rec = request(http.MethodPost, h.HandleDeprecateTemplate)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"deprecated"`)
+
+	rec = request(http.MethodGet, h.HandleListTemplates)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, mockService.ListTemplatesCalled)
+
+	rec = request(http.MethodDelete, h.HandleDeleteTemplate)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,182 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	"github.com/regiwitanto/go-scaffold/internal/domain/service"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/auth"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/handler"
+	"github.com/regiwitanto/go-scaffold/test/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// webhookSubscriptions returns a webhook service with a webhook of the client
+// of the pro-key API key and a webhook of another client
+func webhookSubscriptions() *mocks.MockWebhookService {
+	webhooks := map[string]*model.Webhook{
+		"own-hook":   {ID: "own-hook", URL: "https://ci.acme.test/hook", Secret: "whsec_own", Owner: "key:acme"},
+		"other-hook": {ID: "other-hook", URL: "https://other.test/hook", Secret: "whsec_other", Owner: "key:other"},
+	}
+	return &mocks.MockWebhookService{
+		ListWebhooksFunc: func(owner string) ([]*model.Webhook, error) {
+			return []*model.Webhook{webhooks["own-hook"]}, nil
+		},
+		GetWebhookFunc: func(id string) (*model.Webhook, error) {
+			if webhook, ok := webhooks[id]; ok {
+				return webhook, nil
+			}
+			return nil, fmt.Errorf("webhook %s: %w", id, repository.ErrNotFound)
+		},
+	}
+}
+
+// webhookContext returns a context for a webhook request with an optional API key and id parameter
+func webhookContext(method, target, body, apiKey, id string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if apiKey != "" {
+		req.Header.Set(auth.HeaderAPIKey, apiKey)
+	}
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	if id != "" {
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+	}
+	return c, rec
+}
+
+// Test for HandleCreateWebhook and HandleListWebhooks
+func TestHandleCreateWebhook(t *testing.T) {
+	tests := []struct {
+		name         string
+		apiKey       string
+		body         string
+		createErr    error
+		expectedCode int
+	}{
+		{name: "Created", apiKey: "pro-key", body: `{"url":"https://ci.acme.test/hook","events":["scaffold.generated"]}`, expectedCode: http.StatusCreated},
+		{name: "Anonymous", body: `{"url":"https://ci.acme.test/hook"}`, expectedCode: http.StatusUnauthorized},
+		{name: "Invalid body", apiKey: "pro-key", body: `{"url":`, expectedCode: http.StatusBadRequest},
+		{
+			name:         "Invalid webhook",
+			apiKey:       "pro-key",
+			body:         `{"url":"ftp://ci.acme.test"}`,
+			createErr:    &service.ValidationError{Message: "invalid webhook", Problems: []service.Problem{{Field: "url", Code: service.ProblemInvalidFormat}}},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockWebhookService{
+				CreateWebhookFunc: func(webhook *model.Webhook) (*model.Webhook, error) {
+					if tt.createErr != nil {
+						return nil, tt.createErr
+					}
+					created := *webhook
+					created.ID = "new-hook"
+					created.Secret = "whsec_new"
+					return &created, nil
+				},
+			}
+			h := handler.NewWebhookHandler(mockService)
+			c, rec := webhookContext(http.MethodPost, "/api/webhooks", tt.body, tt.apiKey, "")
+
+			if assert.NoError(t, withAPIKey(h.HandleCreateWebhook)(c)) {
+				assert.Equal(t, tt.expectedCode, rec.Code)
+			}
+			if tt.expectedCode == http.StatusCreated {
+				assert.Equal(t, "key:acme", mockService.WebhookArg.Owner, "webhooks are owned by the client creating them")
+				assert.Equal(t, []string{model.EventScaffoldGenerated}, mockService.WebhookArg.Events)
+
+				var webhook model.Webhook
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &webhook))
+				assert.Equal(t, "whsec_new", webhook.Secret, "the secret is returned on creation")
+			}
+		})
+	}
+
+	h := handler.NewWebhookHandler(webhookSubscriptions())
+	c, rec := webhookContext(http.MethodGet, "/api/webhooks", "", "pro-key", "")
+	if assert.NoError(t, withAPIKey(h.HandleListWebhooks)(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var webhooks []model.Webhook
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &webhooks))
+		if assert.Len(t, webhooks, 1) {
+			assert.Empty(t, webhooks[0].Secret, "secrets are only returned on creation")
+		}
+	}
+
+	c, rec = webhookContext(http.MethodGet, "/api/webhooks", "", "", "")
+	if assert.NoError(t, withAPIKey(h.HandleListWebhooks)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+}
+
+// TestHandleWebhookOwnership ensures clients only see and change their own webhooks
+func TestHandleWebhookOwnership(t *testing.T) {
+	tests := []struct {
+		name         string
+		id           string
+		apiKey       string
+		expectedCode int
+	}{
+		{name: "Own webhook", id: "own-hook", apiKey: "pro-key"},
+		{name: "Anonymous", id: "own-hook", expectedCode: http.StatusUnauthorized},
+		{name: "Webhook of another client", id: "other-hook", apiKey: "pro-key", expectedCode: http.StatusNotFound},
+		{name: "Missing webhook", id: "missing", apiKey: "pro-key", expectedCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := webhookSubscriptions()
+			h := handler.NewWebhookHandler(mockService)
+
+			requests := []struct {
+				method     string
+				target     string
+				body       string
+				handle     echo.HandlerFunc
+				successful int
+			}{
+				{http.MethodGet, "/api/webhooks/" + tt.id, "", h.HandleGetWebhook, http.StatusOK},
+				{http.MethodPut, "/api/webhooks/" + tt.id, `{"url":"https://ci.acme.test/v2"}`, h.HandleUpdateWebhook, http.StatusOK},
+				{http.MethodGet, "/api/webhooks/" + tt.id + "/deliveries?status=failed", "", h.HandleListDeliveries, http.StatusOK},
+				{http.MethodPost, "/api/webhooks/" + tt.id + "/ping", "", h.HandlePingWebhook, http.StatusAccepted},
+				{http.MethodDelete, "/api/webhooks/" + tt.id, "", h.HandleDeleteWebhook, http.StatusNoContent},
+			}
+			for _, r := range requests {
+				c, rec := webhookContext(r.method, r.target, r.body, tt.apiKey, tt.id)
+
+				expectedCode := tt.expectedCode
+				if expectedCode == 0 {
+					expectedCode = r.successful
+				}
+				if assert.NoError(t, withAPIKey(r.handle)(c), r.target) {
+					assert.Equal(t, expectedCode, rec.Code, "%s %s", r.method, r.target)
+					assert.NotContains(t, rec.Body.String(), "whsec_", "%s %s", r.method, r.target)
+				}
+			}
+
+			owned := tt.expectedCode == 0
+			assert.Equal(t, owned, mockService.UpdateWebhookCalled)
+			assert.Equal(t, owned, mockService.ListDeliveriesCalled)
+			assert.Equal(t, owned, mockService.PingCalled)
+			assert.Equal(t, owned, mockService.DeleteWebhookCalled)
+			if owned {
+				assert.Equal(t, "https://ci.acme.test/v2", mockService.WebhookArg.URL)
+				assert.Equal(t, "own-hook", mockService.WebhookArg.ID)
+				assert.Equal(t, "failed", mockService.StateArg)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package openapi_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+type audit struct {
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type node struct {
+	audit
+	Name     string            `json:"name"`
+	Count    int               `json:"count,omitempty"`
+	Tags     []string          `json:"tags"`
+	Labels   map[string]string `json:"labels"`
+	Children []*node           `json:"children"`
+	Internal string            `json:"-"`
+	private  string
+}
+
+// TestSchema ensures schemas follow the JSON encoding of Go types
+func TestSchema(t *testing.T) {
+	b := openapi.NewBuilder(openapi.Info{Title: "Test", Version: "1.0.0"})
+
+	ref := b.Schema(reflect.TypeOf(&node{}))
+	assert.Equal(t, "#/components/schemas/node", ref.Ref)
+
+	schema := b.Document().Components.Schemas["node"]
+	if !assert.NotNil(t, schema) {
+		return
+	}
+	assert.Equal(t, "object", schema.Type)
+	assert.ElementsMatch(t, []string{"createdAt", "name", "count", "tags", "labels", "children"}, keys(schema.Properties))
+	assert.Equal(t, &model.JSONSchema{Type: "string", Format: "date-time"}, schema.Properties["createdAt"])
+	assert.Equal(t, &model.JSONSchema{Type: "integer"}, schema.Properties["count"])
+	assert.Equal(t, &model.JSONSchema{Type: "array", Items: &model.JSONSchema{Type: "string"}}, schema.Properties["tags"])
+	assert.Equal(t, &model.JSONSchema{Type: "object", AdditionalProperties: &model.JSONSchema{Type: "string"}}, schema.Properties["labels"])
+	assert.Equal(t, &model.JSONSchema{Type: "array", Items: ref}, schema.Properties["children"])
+}
+
+// TestAdd ensures routes are added with OpenAPI paths, parameters and bodies
+func TestAdd(t *testing.T) {
+	b := openapi.NewBuilder(openapi.Info{Title: "Test", Version: "1.0.0"}, openapi.Server{URL: "/api"})
+	b.AddSecurityScheme("token", openapi.SecurityScheme{Type: "http", Scheme: "bearer"})
+	b.Add(openapi.Route{
+		Method:   http.MethodPost,
+		Path:     "/nodes/:id/children",
+		Security: "token",
+		Params:   map[string]string{"id": "Node ID"},
+		Query:    map[string]string{"dryRun": "Only validate"},
+		Body:     node{},
+		Replies: []openapi.Reply{
+			{Status: http.StatusCreated, Body: node{}},
+			{Status: http.StatusNoContent},
+			{Status: http.StatusOK, ContentType: "application/zip", Headers: map[string]string{"Repr-Digest": "Digest"}},
+		},
+	})
+
+	doc := b.Document()
+	assert.Equal(t, openapi.Version, doc.OpenAPI)
+	assert.Equal(t, []string{"POST /nodes/{id}/children"}, doc.Operations())
+
+	op := doc.Paths["/nodes/{id}/children"]["post"]
+	if !assert.NotNil(t, op) {
+		return
+	}
+	assert.Equal(t, []openapi.Parameter{
+		{Name: "id", In: "path", Description: "Node ID", Required: true, Schema: &model.JSONSchema{Type: "string"}},
+		{Name: "dryRun", In: "query", Description: "Only validate", Schema: &model.JSONSchema{Type: "string"}},
+	}, op.Parameters)
+	assert.Equal(t, []map[string][]string{{"token": {}}}, op.Security)
+	assert.Equal(t, "#/components/schemas/node", op.RequestBody.Content[openapi.MIMEApplicationJSON].Schema.Ref)
+	assert.Equal(t, "Created", op.Responses["201"].Description)
+	assert.Nil(t, op.Responses["204"].Content)
+	assert.Contains(t, op.Responses["200"].Content, "application/zip")
+	assert.Equal(t, map[string]openapi.Header{"Repr-Digest": {Description: "Digest", Schema: &model.JSONSchema{Type: "string"}}}, op.Responses["200"].Headers)
+	assert.Nil(t, op.Responses["201"].Headers)
+}
+
+// keys returns the keys of a map of schemas
+func keys(m map[string]*model.JSONSchema) []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,114 @@
+package ratelimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/domain/repository"
+	"github.com/regiwitanto/go-scaffold/internal/domain/service"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/auth"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/ratelimit"
+	"github.com/regiwitanto/go-scaffold/test/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRateLimit ensures requests over the rate limit of their client are rejected with Retry-After
+func TestRateLimit(t *testing.T) {
+	tests := []struct {
+		name               string
+		allowErr           error
+		expectedCode       int
+		expectedRetryAfter string
+	}{
+		{name: "Allowed", expectedCode: http.StatusOK},
+		{name: "Rate limited", allowErr: &service.QuotaError{Message: "rate limit exceeded", RetryAfter: 1500 * time.Millisecond}, expectedCode: http.StatusTooManyRequests, expectedRetryAfter: "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			usage := &mocks.MockUsageService{
+				AllowFunc: func(client string, plan *model.Plan) error {
+					return tt.allowErr
+				},
+			}
+			handler := ratelimit.RateLimit(usage)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			assert.NoError(t, handler(c))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, tt.expectedRetryAfter, rec.Header().Get(echo.HeaderRetryAfter))
+			assert.Equal(t, "ip:192.0.2.1", usage.AllowArg, "anonymous requests should be tracked by IP address")
+		})
+	}
+}
+
+// TestGuardAPIKeys ensures invalid API keys count against the rate limit of their IP address before keys are checked
+func TestGuardAPIKeys(t *testing.T) {
+	remaining := 2
+	usage := &mocks.MockUsageService{
+		GetUsageFunc: func(client string, plan *model.Plan) *model.Usage {
+			return &model.Usage{Client: client, Limits: model.Limits{RequestsPerMinute: 60}, RequestsRemaining: remaining}
+		},
+		AllowFunc: func(client string, plan *model.Plan) error {
+			remaining--
+			return nil
+		},
+	}
+	keys := &mocks.MockAPIKeyRepository{
+		GetByKeyFunc: func(key string) (*model.APIKey, error) {
+			if key == "pro-key" {
+				return &model.APIKey{Name: "acme"}, nil
+			}
+			return nil, repository.ErrNotFound
+		},
+	}
+	handler := ratelimit.GuardAPIKeys(usage)(auth.APIKey(keys)(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}))
+
+	// Requests run in order from the same IP address
+	steps := []struct {
+		name              string
+		key               string
+		expectedCode      int
+		expectedRemaining int
+		expectKeyChecked  bool
+	}{
+		{name: "Invalid key", key: "guess-1", expectedCode: http.StatusUnauthorized, expectedRemaining: 1, expectKeyChecked: true},
+		{name: "Valid key", key: "pro-key", expectedCode: http.StatusOK, expectedRemaining: 1, expectKeyChecked: true},
+		{name: "Anonymous", expectedCode: http.StatusOK, expectedRemaining: 1},
+		{name: "Last invalid key", key: "guess-2", expectedCode: http.StatusUnauthorized, expectedRemaining: 0, expectKeyChecked: true},
+		{name: "Throttled invalid key", key: "guess-3", expectedCode: http.StatusTooManyRequests, expectedRemaining: 0},
+		{name: "Throttled valid key", key: "pro-key", expectedCode: http.StatusTooManyRequests, expectedRemaining: 0},
+	}
+
+	for _, step := range steps {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+		if step.key != "" {
+			req.Header.Set(auth.HeaderAPIKey, step.key)
+		}
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		keys.GetByKeyCalled = false
+
+		assert.NoError(t, handler(c), step.name)
+		assert.Equal(t, step.expectedCode, rec.Code, step.name)
+		assert.Equal(t, step.expectedRemaining, remaining, step.name)
+		assert.Equal(t, step.expectKeyChecked, keys.GetByKeyCalled, step.name)
+		if step.expectedCode == http.StatusTooManyRequests {
+			assert.Equal(t, "1", rec.Header().Get(echo.HeaderRetryAfter), step.name)
+		}
+	}
+	assert.Equal(t, "ip:192.0.2.1", usage.AllowArg, "invalid keys should be counted against the IP address")
+}
@@ -0,0 +1,72 @@
+package routes_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/regiwitanto/go-scaffold/internal/infrastructure/storage/apikey"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/handler"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/openapi"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/api/routes"
+	"github.com/regiwitanto/go-scaffold/test/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// apiPrefix is the server URL of the OpenAPI document; routes outside it are not part of the API
+const apiPrefix = "/api"
+
+// TestEveryRouteIsDocumented ensures every API route is in the OpenAPI document served at /api/docs, and vice versa
+func TestEveryRouteIsDocumented(t *testing.T) {
+	e := echo.New()
+	routes.SetupRoutes(e, handler.NewGeneratorHandler(&mocks.MockGeneratorService{}), handler.NewPresetHandler(&mocks.MockPresetService{}, &mocks.MockGeneratorService{}), handler.NewWebhookHandler(&mocks.MockWebhookService{}), apikey.NewEmptyRepository(), nil)
+	routes.SetupAdminRoutes(e, handler.NewTemplateAdminHandler(&mocks.MockTemplateAdminService{}), "secret")
+
+	req := httptest.NewRequest(http.MethodGet, apiPrefix+"/docs", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if !assert.Equal(t, http.StatusOK, rec.Code) {
+		return
+	}
+
+	var docs openapi.Document
+	if !assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &docs)) {
+		return
+	}
+	assert.Equal(t, openapi.Version, docs.OpenAPI)
+	if assert.Len(t, docs.Servers, 1) {
+		assert.Equal(t, apiPrefix, docs.Servers[0].URL)
+	}
+
+	documented := make(map[string]bool)
+	for _, operation := range docs.Operations() {
+		documented[operation] = true
+	}
+
+	routed := make(map[string]bool)
+	for _, route := range e.Routes() {
+		// Groups with middleware register not-found handlers, which are not operations
+		if !strings.HasPrefix(route.Path, apiPrefix+"/") || route.Method == echo.RouteNotFound {
+			continue
+		}
+
+		// Echo path parameters, e.g. :id, are {id} in OpenAPI paths
+		segments := strings.Split(strings.TrimPrefix(route.Path, apiPrefix), "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") {
+				segments[i] = "{" + segment[1:] + "}"
+			}
+		}
+		operation := route.Method + " " + strings.Join(segments, "/")
+
+		routed[operation] = true
+		assert.True(t, documented[operation], "route %s %s is not documented", route.Method, route.Path)
+	}
+
+	for operation := range documented {
+		assert.True(t, routed[operation], "documented operation %s is not routed", operation)
+	}
+}
@@ -0,0 +1,134 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/regiwitanto/go-scaffold/internal/domain/model"
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubLinter returns fixed findings
+type stubLinter struct {
+	findings []model.LintFinding
+}
+
+// LintTemplates implements the TemplateLinter interface
+func (l *stubLinter) LintTemplates(fsys fs.FS) ([]model.LintFinding, error) {
+	return l.findings, nil
+}
+
+var findings = []model.LintFinding{
+	{Rule: model.LintRuleUnknownField, Severity: model.LintSeverityError, Path: "api/echo/Makefile.tmpl", Line: 2, Message: "unknown field .Binary"},
+	{Rule: model.LintRuleUnreachableFile, Severity: model.LintSeverityWarning, Path: "api/echo/dotenv.go.tmpl", Message: "always renders empty"},
+}
+
+// TestLintTemplatesText ensures findings are printed one per line with a summary
+func TestLintTemplatesText(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+
+	code := cli.LintTemplates([]string{dir}, &stubLinter{findings: findings}, &stdout, &stderr)
+
+	assert.Equal(t, cli.ExitFindings, code)
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if assert.Len(t, lines, 3) {
+		assert.Equal(t, dir+"/api/echo/Makefile.tmpl:2: error: unknown field .Binary (unknown-field)", lines[0])
+		assert.Equal(t, dir+"/api/echo/dotenv.go.tmpl: warning: always renders empty (unreachable-file)", lines[1])
+		assert.Equal(t, "2 problems (1 errors, 1 warnings)", lines[2])
+	}
+
+	// Warnings alone do not fail the command
+	stdout.Reset()
+	code = cli.LintTemplates([]string{dir}, &stubLinter{findings: findings[1:]}, &stdout, &stderr)
+	assert.Equal(t, cli.ExitOK, code)
+}
+
+// TestLintTemplatesJSON ensures findings can be written as JSON
+func TestLintTemplatesJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	code := cli.LintTemplates([]string{"-format", "json", t.TempDir()}, &stubLinter{}, &stdout, &stderr)
+
+	assert.Equal(t, cli.ExitOK, code)
+	assert.JSONEq(t, `[]`, stdout.String())
+
+	stdout.Reset()
+	cli.LintTemplates([]string{"-format", "json", t.TempDir()}, &stubLinter{findings: findings}, &stdout, &stderr)
+	var decoded []model.LintFinding
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &decoded))
+	assert.Equal(t, findings, decoded)
+}
+
+// TestLintTemplatesSARIF ensures findings can be written as a SARIF log
+func TestLintTemplatesSARIF(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+
+	cli.LintTemplates([]string{"-format", "sarif", dir}, &stubLinter{findings: findings}, &stdout, &stderr)
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region *struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if !assert.NoError(t, json.Unmarshal(stdout.Bytes(), &log)) {
+		return
+	}
+
+	assert.Equal(t, "2.1.0", log.Version)
+	if assert.Len(t, log.Runs, 1) && assert.Len(t, log.Runs[0].Results, 2) {
+		run := log.Runs[0]
+		assert.Equal(t, "go-scaffold", run.Tool.Driver.Name)
+		assert.Len(t, run.Tool.Driver.Rules, 4)
+
+		result := run.Results[0]
+		assert.Equal(t, model.LintRuleUnknownField, result.RuleID)
+		assert.Equal(t, "error", result.Level)
+		assert.Equal(t, dir+"/api/echo/Makefile.tmpl", result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+		assert.Equal(t, 2, result.Locations[0].PhysicalLocation.Region.StartLine)
+		assert.Nil(t, run.Results[1].Locations[0].PhysicalLocation.Region)
+	}
+}
+
+// TestLintTemplatesUsage ensures invalid invocations are reported
+func TestLintTemplatesUsage(t *testing.T) {
+	tests := [][]string{
+		{},
+		{"-format", "xml", t.TempDir()},
+		{t.TempDir() + "/missing"},
+	}
+
+	for _, args := range tests {
+		var stdout, stderr bytes.Buffer
+		code := cli.LintTemplates(args, &stubLinter{}, &stdout, &stderr)
+		assert.Equal(t, cli.ExitUsage, code, args)
+		assert.NotEmpty(t, stderr.String(), args)
+	}
+}
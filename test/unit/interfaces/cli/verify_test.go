@@ -0,0 +1,82 @@
+package cli_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/regiwitanto/go-scaffold/internal/interfaces/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubVerifier records its arguments and returns a fixed error
+type stubVerifier struct {
+	err       error
+	signature []byte
+	publicKey []byte
+}
+
+// VerifyArchive implements the ArchiveVerifier interface
+func (v *stubVerifier) VerifyArchive(archive, signature, publicKeyPEM []byte) error {
+	v.signature = signature
+	v.publicKey = publicKeyPEM
+	return v.err
+}
+
+// writeFiles writes files keyed by name to a new directory and returns it
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+// TestVerifyWithKey ensures the signature next to the archive is checked with the given key
+func TestVerifyWithKey(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"project.zip": "zip", "project.zip.sig": "sig", "public.pem": "pem"})
+	archive := filepath.Join(dir, "project.zip")
+	verifier := &stubVerifier{}
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Verify([]string{"-key", filepath.Join(dir, "public.pem"), archive}, verifier, &stdout, &stderr)
+
+	assert.Equal(t, cli.ExitOK, code)
+	assert.Equal(t, []byte("sig"), verifier.signature)
+	assert.Equal(t, []byte("pem"), verifier.publicKey)
+	assert.Equal(t, archive+": signature and checksums OK\n", stdout.String())
+	assert.Empty(t, stderr.String())
+}
+
+// TestVerifyFindings ensures every problem is printed and fails the command
+func TestVerifyFindings(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"project.zip": "zip"})
+	archive := filepath.Join(dir, "project.zip")
+	verifier := &stubVerifier{err: errors.Join(errors.New("codebase/main.go: checksum does not match SHA256SUMS"), errors.New("codebase/extra.go: not listed in SHA256SUMS"))}
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Verify([]string{archive}, verifier, &stdout, &stderr)
+
+	assert.Equal(t, cli.ExitFindings, code)
+	assert.Nil(t, verifier.publicKey)
+	assert.Equal(t, archive+": codebase/main.go: checksum does not match SHA256SUMS\n"+archive+": codebase/extra.go: not listed in SHA256SUMS\n", stdout.String())
+	assert.Contains(t, stderr.String(), "signature is not checked")
+}
+
+// TestVerifyUsage ensures missing arguments and unreadable files are usage errors
+func TestVerifyUsage(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"project.zip": "zip", "public.pem": "pem"})
+	var stdout, stderr bytes.Buffer
+
+	assert.Equal(t, cli.ExitUsage, cli.Verify(nil, &stubVerifier{}, &stdout, &stderr))
+	assert.Equal(t, cli.ExitUsage, cli.Verify([]string{filepath.Join(dir, "missing.zip")}, &stubVerifier{}, &stdout, &stderr))
+	// The signature defaults to project.zip.sig, which does not exist
+	assert.Equal(t, cli.ExitUsage, cli.Verify([]string{"-key", filepath.Join(dir, "public.pem"), filepath.Join(dir, "project.zip")}, &stubVerifier{}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "failed to read signature")
+}